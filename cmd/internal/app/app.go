@@ -3,14 +3,26 @@ package app
 import (
 	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/db"
+	"anon-confessions/cmd/internal/jobs"
+	"anon-confessions/cmd/internal/lockout"
 	"anon-confessions/cmd/internal/middleware"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
 	"anon-confessions/cmd/internal/modules/comments"
+	"anon-confessions/cmd/internal/modules/drafts"
 	"anon-confessions/cmd/internal/modules/posts"
 	"anon-confessions/cmd/internal/modules/user"
 	"anon-confessions/cmd/internal/websocket"
 	"anon-confessions/docs"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -18,16 +30,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// shutdownTimeout is how long requests in flight get to finish once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
 type App struct {
-	Config config.Config
-	DB     *gorm.DB
-	Router *gin.Engine
+	Config   config.Config
+	DB       *gorm.DB
+	Router   *gin.Engine
+	stopJobs func()
 }
 
 type HandlerContainer struct {
 	UserHandler     *user.UserHandler
 	PostsHandler    *posts.PostsHandler
 	CommentsHandler *comments.CommentsHandler
+	BoardsHandler   *boards.BoardsHandler
+	DraftsHandler   *drafts.DraftsHandler
 }
 
 // @title           Anonymous Confessions API
@@ -43,15 +61,27 @@ type HandlerContainer struct {
 // @host            localhost: cfg.Port
 // @BasePath        /api/v1
 //
+// @securityDefinitions.apikey BearerAuth
+// @in               header
+// @name             Authorization
+// @description      A session access token obtained from POST /users/sessions, sent as "Bearer <token>".
+//
 // @securityDefinitions.apikey AccountNumberAuth
 // @in               header
 // @name             X-Account-Number
-// @description      A unique account number for user authentication.
+// @description      A unique account number, or its recovery phrase, for user authentication. Deprecated in favour of BearerAuth.
 //
+// @securityDefinitions.apikey APIKeyAuth
+// @in               header
+// @name             X-API-Key
+// @description      A scoped API key created with POST /users/me/api-keys, for bots and integrations.
+//
+// @security         BearerAuth
 // @security         AccountNumberAuth
 func swaggerInfo() {}
 
 // NewApp initializes the application by loading configuration, setting up database connection, running migrations, and initializing services and handlers.
+// It also starts the background jobs, which run until Shutdown is called.
 func NewApp(cfg *config.Config) (*App, error) {
 	docs.SwaggerInfo.Host = fmt.Sprintf("localhost:%s", cfg.Port)
 
@@ -66,58 +96,117 @@ func NewApp(cfg *config.Config) (*App, error) {
 	hub := websocket.NewHub()
 	go hub.Run()
 
-	// MIDDLEWARE
-	slog.Info("Setting up middleware...")
-	authMiddleware := middleware.Authentication(dbConn)
-
 	// Repositories
 	slog.Info("Initializing repositories...")
 	userRepo := user.NewSQLiteUserRepository(dbConn)
 	postsRepo := posts.NewSQLitePostsRepository(dbConn)
 	commentsRepo := comments.NewSQLiteCommentsRepository(dbConn)
+	boardsRepo := boards.NewSQLiteBoardsRepository(dbConn)
+	draftsRepo := drafts.NewSQLiteDraftsRepository(dbConn)
+
+	var lockoutStore lockout.Store = lockout.NewMemoryStore()
+	if cfg.Lockout.Store == "sqlite" {
+		lockoutStore = lockout.NewSQLiteStore(dbConn)
+	}
 
 	// Services
 	slog.Info("Initializing services...")
-	userService := user.NewUserService(userRepo)
-	postsService := posts.NewPostsService(postsRepo, hub)
-	commentsService := comments.NewCommentsService(commentsRepo, hub)
+	userService := user.NewUserService(userRepo, lockout.NewGuard(lockoutStore, cfg.Lockout), cfg, hub)
+	postsService := posts.NewPostsService(postsRepo, cfg, hub)
+	commentsService := comments.NewCommentsService(commentsRepo, cfg, hub)
+	boardsService := boards.NewBoardsService(boardsRepo)
+	draftsService := drafts.NewDraftsService(draftsRepo, cfg)
+
+	// MIDDLEWARE
+	slog.Info("Setting up middleware...")
+	authMiddleware := middleware.Authentication(userService, cfg.Auth)
+	moderatorMiddleware := middleware.RequireRole(userService, models.RoleModerator, models.RoleAdmin)
+	adminMiddleware := middleware.RequireRole(userService, models.RoleAdmin)
 
 	// Handlers
 	slog.Info("Initializing handlers...")
 	userHandler := user.NewUserHandler(userService)
-	postsHandler := posts.NewPostsHandler(postsService)
-	commentsHandler := comments.NewCommentsHandler(commentsService, postsService)
+	postsHandler := posts.NewPostsHandler(postsService, userService, boardsService)
+	commentsHandler := comments.NewCommentsHandler(commentsService, userService)
+	boardsHandler := boards.NewBoardsHandler(boardsService)
+	draftsHandler := drafts.NewDraftsHandler(draftsService, postsService, boardsService)
 
 	handlers := &HandlerContainer{
 		UserHandler:     userHandler,
 		PostsHandler:    postsHandler,
 		CommentsHandler: commentsHandler,
+		BoardsHandler:   boardsHandler,
+		DraftsHandler:   draftsHandler,
 	}
 
 	slog.Info("Setting up router...")
-	router := setupRouter(handlers, authMiddleware, hub)
+	router := setupRouter(handlers, authMiddleware, moderatorMiddleware, adminMiddleware, hub)
+
+	slog.Info("Starting background jobs...")
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	waitJobs := jobs.Start(jobsCtx,
+		jobs.Job{Name: "post rankings", Interval: cfg.Ranking.Interval, Run: postsService.RefreshRankings},
+		jobs.Job{Name: "expired posts reaper", Interval: cfg.Content.ReaperInterval, Run: postsService.ReapExpiredPosts},
+		jobs.Job{Name: "scheduled posts", Interval: cfg.Content.SchedulerInterval, Run: postsService.PublishScheduledPosts},
+		jobs.Job{Name: "deleted posts purge", Interval: cfg.Content.PurgeInterval, Run: postsService.PurgeDeletedPosts},
+		jobs.Job{Name: "deleted comments purge", Interval: cfg.Content.PurgeInterval, Run: commentsService.PurgeDeletedComments},
+		jobs.Job{Name: "expired drafts purge", Interval: cfg.Content.PurgeInterval, Run: draftsService.PurgeExpiredDrafts},
+	)
 
 	slog.Info("Application initialized successfully")
 	app := &App{
 		Config: *cfg,
 		DB:     dbConn,
 		Router: router,
+		stopJobs: func() {
+			cancelJobs()
+			waitJobs()
+		},
 	}
 
 	return app, nil
 }
 
+// Run serves HTTP requests until the process receives SIGINT or SIGTERM, then lets requests in flight finish
+// and stops the background jobs.
 func (a *App) Run() error {
+	defer a.Shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: fmt.Sprintf(":%v", a.Config.Port), Handler: a.Router}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
 	slog.Info("Starting HTTP server", slog.String("port", a.Config.Port))
-	if err := a.Router.Run(fmt.Sprintf(":%v", a.Config.Port)); err != nil {
+	select {
+	case err := <-serverErr:
 		slog.Error("Server failed to start", slog.String("error", err.Error()))
 		return fmt.Errorf("server failed to start: %w", err)
+	case <-ctx.Done():
 	}
-	slog.Info("HTTP server started successfully")
+
+	slog.Info("Shutting down HTTP server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+
+	slog.Info("HTTP server stopped")
 	return nil
 }
 
-func setupRouter(h *HandlerContainer, authMiddleware gin.HandlerFunc, hub *websocket.Hub) *gin.Engine {
+// Shutdown stops the background jobs and waits for the ones running to finish.
+func (a *App) Shutdown() {
+	slog.Info("Stopping background jobs...")
+	a.stopJobs()
+}
+
+func setupRouter(h *HandlerContainer, authMiddleware, moderatorMiddleware, adminMiddleware gin.HandlerFunc, hub *websocket.Hub) *gin.Engine {
 	router := gin.Default()
 
 	// Swagger documentation route
@@ -132,8 +221,30 @@ func setupRouter(h *HandlerContainer, authMiddleware gin.HandlerFunc, hub *webso
 	authenticated := api.Group("/")
 	authenticated.Use(authMiddleware)
 	{
+		// API keys are limited to their scopes and cannot manage the account
+		account := authenticated.Group("/")
+		account.Use(middleware.RequireFullAccess())
+		user.RegisterAuthenticatedUsersRoutes(account, h.UserHandler)
+		drafts.RegisterDraftsRoutes(account, h.DraftsHandler)
 		posts.RegisterPostRoutes(authenticated, h.PostsHandler)
 		comments.RegisterCommentsRoutes(authenticated, h.CommentsHandler)
+		boards.RegisterBoardsRoutes(authenticated, h.BoardsHandler)
+	}
+
+	// Routes that require the moderator or admin role
+	admin := api.Group("/admin")
+	admin.Use(authMiddleware, moderatorMiddleware)
+	{
+		adminAccounts := admin.Group("/")
+		adminAccounts.Use(middleware.RequireFullAccess())
+		user.RegisterAdminUsersRoutes(adminAccounts, h.UserHandler)
+		posts.RegisterAdminPostRoutes(admin, h.PostsHandler)
+		comments.RegisterAdminCommentsRoutes(admin, h.CommentsHandler)
+
+		// Boards are managed by admins only
+		adminOnly := admin.Group("/")
+		adminOnly.Use(adminMiddleware)
+		boards.RegisterAdminBoardsRoutes(adminOnly, h.BoardsHandler)
 	}
 
 	// Routes that do not require authentication
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/lockout"
+	"anon-confessions/cmd/internal/middleware"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
+	"anon-confessions/cmd/internal/modules/comments"
+	"anon-confessions/cmd/internal/modules/drafts"
+	"anon-confessions/cmd/internal/modules/posts"
+	"anon-confessions/cmd/internal/modules/user"
+	"anon-confessions/cmd/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAPIKeysCannotReachAccountRoutes tests if the routes under /users/me, drafts included, turn away API keys
+// whatever their scopes.
+func TestAPIKeysCannotReachAccountRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	cfg := &config.Config{
+		Auth:    config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret"},
+		Content: config.ContentConfig{PseudonymSecret: "test-pseudonym-secret", MaxDrafts: 2, DraftTTL: time.Hour},
+	}
+	hub := websocket.NewHub()
+
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{}), cfg, hub)
+	postsService := posts.NewPostsService(posts.NewSQLitePostsRepository(db), cfg, hub)
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	draftsService := drafts.NewDraftsService(drafts.NewSQLiteDraftsRepository(db), cfg)
+	handlers := &HandlerContainer{
+		UserHandler:     user.NewUserHandler(userService),
+		PostsHandler:    posts.NewPostsHandler(postsService, userService, boardsService),
+		CommentsHandler: comments.NewCommentsHandler(comments.NewCommentsService(comments.NewSQLiteCommentsRepository(db), cfg, hub), userService),
+		BoardsHandler:   boards.NewBoardsHandler(boardsService),
+		DraftsHandler:   drafts.NewDraftsHandler(draftsService, postsService, boardsService),
+	}
+	allowAll := func(c *gin.Context) { c.Next() }
+	router := setupRouter(handlers, middleware.Authentication(userService, cfg.Auth), allowAll, allowAll, hub)
+
+	apiKey := models.APIKeyDBModel{
+		UserId:  1,
+		Name:    "every scope",
+		Prefix:  "ack_full",
+		KeyHash: helper.HashToken("ack_full-scopes"),
+		Scopes:  []string{models.ScopePostsRead, models.ScopePostsWrite},
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/v1/users/me/drafts"},
+		{http.MethodPost, "/api/v1/users/me/drafts"},
+		{http.MethodGet, "/api/v1/users/me/drafts/1"},
+		{http.MethodPut, "/api/v1/users/me/drafts/1"},
+		{http.MethodDelete, "/api/v1/users/me/drafts/1"},
+		{http.MethodPost, "/api/v1/users/me/drafts/1/publish"},
+		{http.MethodGet, "/api/v1/users/me/api-keys"},
+	}
+	for _, route := range routes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("X-API-Key", "ack_full-scopes")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for %s %s with an API key, got %d", http.StatusForbidden, route.method, route.path, w.Code)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -15,10 +19,110 @@ type Migrations struct {
 	DBURL         string
 }
 
+// AuthConfig holds the secrets and lifetimes used to authenticate users.
+// AccountPepper keys the lookup fingerprint stored next to each account number hash,
+// TokenSecret signs access tokens and LegacyAccountHeader keeps the X-Account-Number header working.
+type AuthConfig struct {
+	AccountPepper       string
+	TokenSecret         string
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	LegacyAccountHeader bool
+}
+
+// ExportConfig controls personal data exports. Interval is the minimum time between two exports of the same user.
+type ExportConfig struct {
+	Interval time.Duration
+}
+
+// ContentConfig controls how posts and comments are presented.
+// PseudonymSecret keys the per-post pseudonyms shown instead of comment authors.
+// PublicEditHistory lets everyone see the previous versions of edited posts and comments, not only their authors and moderators.
+// Deleted posts and comments can be restored by their authors for RestoreWindow. The purge job removes them for good
+// once that has passed, running every PurgeInterval; 0 disables it.
+// Ephemeral posts live for at most MaxPostExpiry and are removed once expired by the reaper, running every ReaperInterval.
+// Scheduled posts can be published at most MaxScheduleAhead from now, by the scheduler, which looks for due posts every
+// SchedulerInterval; 0 disables it.
+// Users keep at most MaxDrafts drafts, each expiring DraftTTL after its last update. Expired drafts are removed by the purge job.
+type ContentConfig struct {
+	PseudonymSecret   string
+	PublicEditHistory bool
+	RestoreWindow     time.Duration
+	PurgeInterval     time.Duration
+	MaxPostExpiry     time.Duration
+	ReaperInterval    time.Duration
+	MaxScheduleAhead  time.Duration
+	SchedulerInterval time.Duration
+	MaxDrafts         int
+	DraftTTL          time.Duration
+}
+
+// SignupConfig controls the proof-of-work challenge that has to be solved to create an account.
+// Difficulty is the number of leading zero bits a solution needs, 0 disables the challenge. While more than
+// RateThreshold accounts are created within RateWindow, the difficulty grows by one bit each time the signup
+// rate doubles, up to MaxDifficulty.
+// InviteOnly requires an invite code for every new account. InviteMaxUses caps how many accounts a single
+// invite of a regular user can create; moderators and admins are not capped.
+type SignupConfig struct {
+	ChallengeSecret string
+	ChallengeTTL    time.Duration
+	Difficulty      int
+	MaxDifficulty   int
+	RateWindow      time.Duration
+	RateThreshold   int
+	InviteOnly      bool
+	InviteMaxUses   int
+}
+
+// LockoutConfig controls the throttling of failed authentication attempts.
+// A client IP is locked out after MaxFailures failures, an account number prefix of PrefixLength digits after
+// PrefixMaxFailures. Lockouts start at BaseDelay and double with every further failure up to MaxDelay.
+// Failures are forgotten after Window without new ones. Store is either "memory" or "sqlite".
+type LockoutConfig struct {
+	Store             string
+	MaxFailures       int
+	PrefixLength      int
+	PrefixMaxFailures int
+	BaseDelay         time.Duration
+	MaxDelay          time.Duration
+	Window            time.Duration
+}
+
+// HashingConfig holds the argon2id parameters used to hash account numbers.
+// Argon2Memory is in KiB. Changing a parameter rehashes each account on its next successful login.
+// At most MaxConcurrent hashes are computed at once, which bounds their memory to MaxConcurrent * Argon2Memory;
+// 0 uses the number of CPUs.
+type HashingConfig struct {
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	MaxConcurrent     int
+}
+
+// RankingConfig controls the background job scoring posts for the hot and trending feeds.
+// The job runs every Interval, 0 disables it. Posts older than HotWindow drop out of the hot feed, and only likes
+// and comments of the last TrendingWindow count towards trending, each counting half as much every quarter of it.
+// Windows that are not positive fall back to their defaults.
+type RankingConfig struct {
+	Interval       time.Duration
+	HotWindow      time.Duration
+	TrendingWindow time.Duration
+}
+
+// Config is the configuration of the application. Env is "development" unless APP_ENV says otherwise;
+// outside development the secrets have to be set, see CheckSecrets.
 type Config struct {
+	Env        string
 	Port       string
 	DB         SQLiteConfig
 	Migrations Migrations
+	Auth       AuthConfig
+	Export     ExportConfig
+	Content    ContentConfig
+	Signup     SignupConfig
+	Lockout    LockoutConfig
+	Hashing    HashingConfig
+	Ranking    RankingConfig
 }
 
 var (
@@ -26,6 +130,41 @@ var (
 	defaultDBURL          = "sqlite3://" + defaultFileName
 	defaultMigrationsPath = "file://./cmd/internal/db/migrations_files"
 	defaultPort           = "9000"
+	defaultEnv            = "development"
+	defaultAccountPepper  = "anon-confessions-development-pepper"
+	defaultTokenSecret    = "anon-confessions-development-token-secret"
+	defaultAccessTTL      = 15 * time.Minute
+	defaultRefreshTTL     = 30 * 24 * time.Hour
+	defaultExportInterval = time.Hour
+	defaultPseudonymKey   = "anon-confessions-development-pseudonym-secret"
+	defaultChallengeKey   = "anon-confessions-development-challenge-secret"
+	defaultChallengeTTL   = 5 * time.Minute
+	defaultDifficulty     = 20
+	defaultMaxDifficulty  = 26
+	defaultRateWindow     = time.Minute
+	defaultRateThreshold  = 30
+	defaultInviteMaxUses  = 5
+	defaultLockoutStore   = "memory"
+	defaultMaxFailures    = 5
+	defaultPrefixLength   = 6
+	defaultPrefixFailures = 50
+	defaultLockoutDelay   = 30 * time.Second
+	defaultMaxLockout     = time.Hour
+	defaultLockoutWindow  = 15 * time.Minute
+	defaultArgon2Memory   = 64 * 1024
+	defaultArgon2Time     = 3
+	defaultArgon2Threads  = 2
+	defaultRankInterval   = 5 * time.Minute
+	defaultHotWindow      = 7 * 24 * time.Hour
+	defaultTrendingWindow = 24 * time.Hour
+	defaultRestoreWindow  = 72 * time.Hour
+	defaultPurgeInterval  = time.Hour
+	defaultMaxPostExpiry  = 30 * 24 * time.Hour
+	defaultReaperInterval = time.Minute
+	defaultSchedInterval  = 15 * time.Second
+	defaultScheduleAhead  = 30 * 24 * time.Hour
+	defaultMaxDrafts      = 20
+	defaultDraftTTL       = 30 * 24 * time.Hour
 )
 
 // LoadConfig loads the application configuration from environment variables.
@@ -34,6 +173,7 @@ var (
 func LoadConfig() *Config {
 
 	cfg := &Config{
+		Env:  getEnv("APP_ENV", defaultEnv),
 		Port: getEnv("PORT", defaultPort),
 		DB: SQLiteConfig{
 			File: getEnv("DB_FILE", defaultFileName),
@@ -42,11 +182,94 @@ func LoadConfig() *Config {
 			MigrationPath: getEnv("MIGRATIONS_PATH", defaultMigrationsPath),
 			DBURL:         getEnv("DB_URL", defaultDBURL),
 		},
+		Auth: AuthConfig{
+			AccountPepper:       getEnv("ACCOUNT_PEPPER", defaultAccountPepper),
+			TokenSecret:         getEnv("TOKEN_SECRET", defaultTokenSecret),
+			AccessTokenTTL:      getEnvDuration("ACCESS_TOKEN_TTL", defaultAccessTTL),
+			RefreshTokenTTL:     getEnvDuration("REFRESH_TOKEN_TTL", defaultRefreshTTL),
+			LegacyAccountHeader: getEnvBool("LEGACY_ACCOUNT_HEADER", true),
+		},
+		Export: ExportConfig{
+			Interval: getEnvDuration("EXPORT_INTERVAL", defaultExportInterval),
+		},
+		Content: ContentConfig{
+			PseudonymSecret:   getEnv("PSEUDONYM_SECRET", defaultPseudonymKey),
+			PublicEditHistory: getEnvBool("PUBLIC_EDIT_HISTORY", false),
+			RestoreWindow:     getEnvDuration("RESTORE_WINDOW", defaultRestoreWindow),
+			PurgeInterval:     getEnvDuration("PURGE_INTERVAL", defaultPurgeInterval),
+			MaxPostExpiry:     getEnvDuration("MAX_POST_EXPIRY", defaultMaxPostExpiry),
+			ReaperInterval:    getEnvDuration("EXPIRY_REAPER_INTERVAL", defaultReaperInterval),
+			MaxScheduleAhead:  getEnvDuration("MAX_SCHEDULE_AHEAD", defaultScheduleAhead),
+			SchedulerInterval: getEnvDuration("SCHEDULER_INTERVAL", defaultSchedInterval),
+			MaxDrafts:         getEnvInt("MAX_DRAFTS", defaultMaxDrafts),
+			DraftTTL:          getEnvDuration("DRAFT_TTL", defaultDraftTTL),
+		},
+		Signup: SignupConfig{
+			ChallengeSecret: getEnv("CHALLENGE_SECRET", defaultChallengeKey),
+			ChallengeTTL:    getEnvDuration("CHALLENGE_TTL", defaultChallengeTTL),
+			Difficulty:      getEnvInt("CHALLENGE_DIFFICULTY", defaultDifficulty),
+			MaxDifficulty:   getEnvInt("CHALLENGE_MAX_DIFFICULTY", defaultMaxDifficulty),
+			RateWindow:      getEnvDuration("SIGNUP_RATE_WINDOW", defaultRateWindow),
+			RateThreshold:   getEnvInt("SIGNUP_RATE_THRESHOLD", defaultRateThreshold),
+			InviteOnly:      getEnvBool("INVITE_ONLY", false),
+			InviteMaxUses:   getEnvInt("INVITE_MAX_USES", defaultInviteMaxUses),
+		},
+		Lockout: LockoutConfig{
+			Store:             getEnv("LOCKOUT_STORE", defaultLockoutStore),
+			MaxFailures:       getEnvInt("LOCKOUT_MAX_FAILURES", defaultMaxFailures),
+			PrefixLength:      getEnvInt("LOCKOUT_PREFIX_LENGTH", defaultPrefixLength),
+			PrefixMaxFailures: getEnvInt("LOCKOUT_PREFIX_MAX_FAILURES", defaultPrefixFailures),
+			BaseDelay:         getEnvDuration("LOCKOUT_BASE_DELAY", defaultLockoutDelay),
+			MaxDelay:          getEnvDuration("LOCKOUT_MAX_DELAY", defaultMaxLockout),
+			Window:            getEnvDuration("LOCKOUT_WINDOW", defaultLockoutWindow),
+		},
+		Hashing: HashingConfig{
+			Argon2Memory:      uint32(getEnvInt("ARGON2_MEMORY", defaultArgon2Memory)),
+			Argon2Iterations:  uint32(getEnvInt("ARGON2_ITERATIONS", defaultArgon2Time)),
+			Argon2Parallelism: uint8(getEnvInt("ARGON2_PARALLELISM", defaultArgon2Threads)),
+			MaxConcurrent:     getEnvInt("HASH_MAX_CONCURRENT", 0),
+		},
+		Ranking: RankingConfig{
+			Interval:       getEnvDuration("RANKING_INTERVAL", defaultRankInterval),
+			HotWindow:      getEnvPositiveDuration("HOT_WINDOW", defaultHotWindow),
+			TrendingWindow: getEnvPositiveDuration("TRENDING_WINDOW", defaultTrendingWindow),
+		},
 	}
 
 	return cfg
 }
 
+// CheckSecrets makes sure no secret runs with its public development default.
+// In development a warning is logged for each of them, elsewhere an error naming them is returned.
+func (cfg *Config) CheckSecrets() error {
+	secrets := []struct {
+		name, value, fallback string
+	}{
+		{"ACCOUNT_PEPPER", cfg.Auth.AccountPepper, defaultAccountPepper},
+		{"TOKEN_SECRET", cfg.Auth.TokenSecret, defaultTokenSecret},
+		{"CHALLENGE_SECRET", cfg.Signup.ChallengeSecret, defaultChallengeKey},
+		{"PSEUDONYM_SECRET", cfg.Content.PseudonymSecret, defaultPseudonymKey},
+	}
+
+	var missing []string
+	for _, secret := range secrets {
+		if secret.value == "" || secret.value == secret.fallback {
+			missing = append(missing, secret.name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if cfg.Env == defaultEnv {
+		for _, name := range missing {
+			slog.Warn("Secret not set, using the public development default", slog.String("env", name))
+		}
+		return nil
+	}
+	return fmt.Errorf("secrets must be set outside development: %v", missing)
+}
+
 // getEnv retrieves the value of the environment variable named by the key.
 // If the variable is not present, it returns the default value provided.
 func getEnv(key, defaultValue string) string {
@@ -56,3 +279,56 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvDuration retrieves the environment variable named by the key as a time.Duration (e.g. "15m").
+// If the variable is not present or cannot be parsed, it returns the default value provided.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvPositiveDuration retrieves the environment variable named by the key like getEnvDuration,
+// but also falls back to the default value, with a warning, when the duration is not positive.
+func getEnvPositiveDuration(key string, defaultValue time.Duration) time.Duration {
+	duration := getEnvDuration(key, defaultValue)
+	if duration <= 0 {
+		slog.Warn("Duration must be positive, using the default", slog.String("env", key), slog.Duration("default", defaultValue))
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvInt retrieves the environment variable named by the key as an integer.
+// If the variable is not present or cannot be parsed, it returns the default value provided.
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+// getEnvBool retrieves the environment variable named by the key as a boolean.
+// If the variable is not present or cannot be parsed, it returns the default value provided.
+func getEnvBool(key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
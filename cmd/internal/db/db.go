@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -38,6 +39,11 @@ func DbConnection(dbName string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
+	if err := CheckFTS5(db); err != nil {
+		slog.Error("Database is missing a required feature", "error", err)
+		return nil, err
+	}
+
 	// Configure the connection pool.
 	sqlDB.SetMaxOpenConns(10)               // Limit max open connections
 	sqlDB.SetMaxIdleConns(5)                // Limit idle connections
@@ -46,3 +52,21 @@ func DbConnection(dbName string) (*gorm.DB, error) {
 	slog.Info("Database connection established successfully", "dbName", dbName)
 	return db, nil
 }
+
+// ErrFTS5Missing is returned when SQLite was compiled without FTS5, which the search index needs.
+// mattn/go-sqlite3 only includes it when built with the sqlite_fts5 tag.
+var ErrFTS5Missing = errors.New("SQLite was built without FTS5, build with -tags sqlite_fts5 as the Makefile does")
+
+// CheckFTS5 returns ErrFTS5Missing unless the SQLite the binary was built with supports FTS5.
+// Without it the migrations fail on the search index with the less helpful "no such module: fts5".
+func CheckFTS5(db *gorm.DB) error {
+	var enabled bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error; err != nil {
+		return fmt.Errorf("failed to check for FTS5: %w", err)
+	}
+	if !enabled {
+		return ErrFTS5Missing
+	}
+
+	return nil
+}
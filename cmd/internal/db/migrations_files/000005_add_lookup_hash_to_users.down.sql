@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_lookup_hash;
+
+ALTER TABLE users DROP COLUMN lookup_hash;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN lookup_hash TEXT;
+
+CREATE UNIQUE INDEX idx_users_lookup_hash ON users(lookup_hash);
@@ -0,0 +1 @@
+ALTER TABLE posts_likes DROP COLUMN created_at;
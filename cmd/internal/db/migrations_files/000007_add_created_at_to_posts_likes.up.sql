@@ -0,0 +1,3 @@
+-- SQLite cannot add a column with a non-constant default, so the timestamp is set on insert.
+-- Likes given before this migration keep a NULL timestamp.
+ALTER TABLE posts_likes ADD COLUMN created_at DATETIME;
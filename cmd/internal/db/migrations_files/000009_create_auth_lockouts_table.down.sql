@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_auth_lockouts_expires_at;
+
+DROP TABLE IF EXISTS auth_lockouts;
@@ -0,0 +1,8 @@
+CREATE TABLE auth_lockouts (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    locked_until DATETIME,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_auth_lockouts_expires_at ON auth_lockouts(expires_at);
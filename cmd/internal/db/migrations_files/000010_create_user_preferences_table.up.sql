@@ -0,0 +1,10 @@
+CREATE TABLE user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    sort_by TEXT NOT NULL DEFAULT 'creation_date',
+    sort_order TEXT NOT NULL DEFAULT 'asc',
+    page_size INTEGER NOT NULL DEFAULT 10,
+    muted_words TEXT NOT NULL DEFAULT '[]',
+    show_nsfw BOOLEAN NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
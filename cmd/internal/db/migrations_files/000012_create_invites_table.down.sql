@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_invite_id;
+
+ALTER TABLE users DROP COLUMN invite_id;
+
+DROP TABLE IF EXISTS invites;
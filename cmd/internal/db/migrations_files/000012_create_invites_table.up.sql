@@ -0,0 +1,19 @@
+CREATE TABLE invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    creator_id INTEGER,
+    code_hash TEXT NOT NULL,
+    max_uses INTEGER NOT NULL,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_invites_code_hash ON invites(code_hash);
+CREATE INDEX idx_invites_creator_id ON invites(creator_id);
+
+-- Invites are revoked rather than deleted, so the column needs no foreign key and can be dropped again.
+ALTER TABLE users ADD COLUMN invite_id INTEGER;
+
+CREATE INDEX idx_users_invite_id ON users(invite_id);
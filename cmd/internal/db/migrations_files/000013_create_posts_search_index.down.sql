@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS posts_fts_after_insert;
+DROP TRIGGER IF EXISTS posts_fts_after_delete;
+DROP TRIGGER IF EXISTS posts_fts_after_update;
+
+DROP TABLE IF EXISTS posts_fts;
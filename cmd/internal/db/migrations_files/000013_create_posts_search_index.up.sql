@@ -0,0 +1,20 @@
+-- Full-text index over the content of posts, ranked with the built-in bm25() so the database can order and page
+-- the results. mattn/go-sqlite3 only includes FTS5 when built with the sqlite_fts5 tag.
+CREATE VIRTUAL TABLE posts_fts USING fts5(content, content='posts', content_rowid='id', tokenize='unicode61');
+
+INSERT INTO posts_fts(posts_fts) VALUES ('rebuild');
+
+-- An FTS5 index with external content is told the old values of the rows it removes, so every trigger can run
+-- after the change.
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF content ON posts BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER posts_fts_after_delete AFTER DELETE ON posts BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER posts_fts_after_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_board_id;
+ALTER TABLE posts DROP COLUMN board_id;
+DROP TABLE IF EXISTS boards;
@@ -0,0 +1,17 @@
+CREATE TABLE boards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    rules TEXT NOT NULL DEFAULT '[]',
+    created_by INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_boards_slug ON boards(slug);
+
+-- Every existing post moves to the general board, which cannot be deleted.
+INSERT INTO boards (id, slug, description) VALUES (1, 'general', 'Confessions that do not fit anywhere else.');
+
+ALTER TABLE posts ADD COLUMN board_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_posts_board_id ON posts(board_id);
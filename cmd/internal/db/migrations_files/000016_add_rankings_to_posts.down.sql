@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_posts_trending_score;
+DROP INDEX IF EXISTS idx_posts_hot_score;
+ALTER TABLE posts DROP COLUMN trending_score;
+ALTER TABLE posts DROP COLUMN hot_score;
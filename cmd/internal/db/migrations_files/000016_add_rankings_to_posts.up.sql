@@ -0,0 +1,6 @@
+-- Scores are refreshed by a background job, so the hot and trending feeds can be read straight from an index.
+ALTER TABLE posts ADD COLUMN hot_score REAL NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN trending_score REAL NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_posts_hot_score ON posts(hot_score DESC, id DESC);
+CREATE INDEX idx_posts_trending_score ON posts(trending_score DESC, id DESC);
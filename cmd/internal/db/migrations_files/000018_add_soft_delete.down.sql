@@ -0,0 +1,28 @@
+DROP TRIGGER IF EXISTS posts_fts_after_restore;
+DROP TRIGGER IF EXISTS posts_fts_after_soft_delete;
+DROP TRIGGER IF EXISTS posts_fts_after_insert;
+DROP TRIGGER IF EXISTS posts_fts_after_delete;
+DROP TRIGGER IF EXISTS posts_fts_after_update;
+
+-- Posts still marked as deleted are removed, since nothing would tell them apart anymore.
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+DELETE FROM posts WHERE deleted_at IS NOT NULL;
+
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF content ON posts BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER posts_fts_after_delete AFTER DELETE ON posts BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER posts_fts_after_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+DROP INDEX IF EXISTS idx_comments_deleted_at;
+DROP INDEX IF EXISTS idx_posts_deleted_at;
+
+ALTER TABLE comments DROP COLUMN deleted_at;
+ALTER TABLE posts DROP COLUMN deleted_at;
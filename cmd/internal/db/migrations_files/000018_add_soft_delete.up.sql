@@ -0,0 +1,35 @@
+-- Deleting a post or comment only marks it, so its author can restore it until the purge job removes it for good.
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX idx_posts_deleted_at ON posts(deleted_at);
+CREATE INDEX idx_comments_deleted_at ON comments(deleted_at);
+
+-- Deleted posts leave the search index and come back when restored. They are not in the index, so the existing
+-- triggers must never remove them from it.
+DROP TRIGGER posts_fts_after_update;
+DROP TRIGGER posts_fts_after_delete;
+DROP TRIGGER posts_fts_after_insert;
+
+CREATE TRIGGER posts_fts_after_update AFTER UPDATE OF content ON posts WHEN old.deleted_at IS NULL BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER posts_fts_after_delete AFTER DELETE ON posts WHEN old.deleted_at IS NULL BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER posts_fts_after_insert AFTER INSERT ON posts WHEN new.deleted_at IS NULL BEGIN
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER posts_fts_after_soft_delete AFTER UPDATE OF deleted_at ON posts
+WHEN old.deleted_at IS NULL AND new.deleted_at IS NOT NULL BEGIN
+    INSERT INTO posts_fts(posts_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER posts_fts_after_restore AFTER UPDATE OF deleted_at ON posts
+WHEN old.deleted_at IS NOT NULL AND new.deleted_at IS NULL BEGIN
+    INSERT INTO posts_fts(rowid, content) VALUES (new.id, new.content);
+END;
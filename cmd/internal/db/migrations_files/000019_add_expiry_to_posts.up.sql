@@ -0,0 +1,4 @@
+-- Ephemeral posts are hidden once expires_at has passed and removed with their comments and likes by the reaper.
+ALTER TABLE posts ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX idx_posts_expires_at ON posts(expires_at);
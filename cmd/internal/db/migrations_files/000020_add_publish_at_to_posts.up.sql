@@ -0,0 +1,5 @@
+-- Scheduled posts keep publish_at until the scheduler publishes them, which clears it again.
+-- Until then they are only visible to their authors.
+ALTER TABLE posts ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX idx_posts_publish_at ON posts(publish_at);
@@ -0,0 +1,14 @@
+-- Drafts are private to their author until published as a post. They expire a while after their last update.
+CREATE TABLE drafts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    board TEXT NOT NULL DEFAULT 'general',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_drafts_user_id ON drafts(user_id);
+CREATE INDEX idx_drafts_expires_at ON drafts(expires_at);
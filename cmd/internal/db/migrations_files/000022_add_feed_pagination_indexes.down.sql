@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_total_likes_id;
+DROP INDEX IF EXISTS idx_posts_created_at_id;
@@ -0,0 +1,14 @@
+-- The feed pages through posts by comparing (sort key, id) against a cursor, which these indexes serve directly.
+-- The hot and trending feeds already have theirs, see 000016.
+-- created_at is compared as text, so every value is rewritten to the UTC form the application writes,
+-- "YYYY-MM-DD HH:MM:SS.fff+00:00" without trailing zeros. publish_at becomes created_at once a post is published.
+UPDATE posts
+SET created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00'
+WHERE created_at IS NOT NULL AND created_at NOT LIKE '%+00:00';
+
+UPDATE posts
+SET publish_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', publish_at), '0'), '.') || '+00:00'
+WHERE publish_at IS NOT NULL AND publish_at NOT LIKE '%+00:00';
+
+CREATE INDEX idx_posts_created_at_id ON posts(created_at, id);
+CREATE INDEX idx_posts_total_likes_id ON posts(total_likes, id);
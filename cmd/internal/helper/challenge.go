@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// maxSolutionLength bounds the solutions that are hashed, so verifying a solution stays cheap.
+const maxSolutionLength = 64
+
+// SignupChallenge is a proof-of-work challenge that has to be solved before an account can be created.
+type SignupChallenge struct {
+	Nonce      string `json:"n"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// SignChallenge encodes the challenge and signs it with the given secret, so it can be handed to
+// the client and verified later without storing it.
+func SignChallenge(secret string, challenge SignupChallenge) (string, error) {
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signToken(secret, unsigned), nil
+}
+
+// ParseChallenge verifies the signature and expiry of a challenge created by SignChallenge and returns it.
+func ParseChallenge(secret, token string) (*SignupChallenge, error) {
+	unsigned, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signToken(secret, unsigned))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(unsigned)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var challenge SignupChallenge
+	if err := json.Unmarshal(payload, &challenge); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= challenge.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &challenge, nil
+}
+
+// VerifyProofOfWork reports whether solution solves the challenge in the hashcash style:
+// SHA-256("<challenge>:<solution>") has to start with at least difficulty zero bits.
+func VerifyProofOfWork(challenge, solution string, difficulty int) bool {
+	if solution == "" || len(solution) > maxSolutionLength {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+	return leadingZeroBits(sum[:]) >= difficulty
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorSorts are the orders of the posts feed cursors can be taken in.
+var cursorSorts = []string{
+	models.PreferenceSortByCreationDate,
+	models.PreferenceSortByLikes,
+	models.PostSortHot,
+	models.PostSortTrending,
+}
+
+// PostSortOrder returns the field and direction the posts feed is sorted by, following GenerateOrderClause.
+func PostSortOrder(postQueryParam models.PostQueryParams) (string, string) {
+	if postQueryParam.Sort != "" {
+		return postQueryParam.Sort, "desc"
+	}
+	if postQueryParam.SortByCreationDate != "" {
+		return models.PreferenceSortByCreationDate, postQueryParam.SortByCreationDate
+	}
+	if postQueryParam.SortByLikes != "" {
+		return models.PreferenceSortByLikes, postQueryParam.SortByLikes
+	}
+
+	return models.PreferenceSortByCreationDate, "asc"
+}
+
+// EncodeCursor turns a cursor into the opaque string handed to clients.
+func EncodeCursor(cursor models.PostCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor created by EncodeCursor.
+func DecodeCursor(encoded string) (*models.PostCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor models.PostCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	validSort := slices.Contains(cursorSorts, cursor.SortBy)
+	validOrder := cursor.Order == "asc" || cursor.Order == "desc"
+	if !validSort || !validOrder || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
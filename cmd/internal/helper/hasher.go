@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrMismatchedHash is returned when a secret does not match the hash it is verified against.
+	ErrMismatchedHash = errors.New("secret does not match hash")
+	// ErrUnknownHashFormat is returned for hashes that were not produced by any supported algorithm.
+	ErrUnknownHashFormat = errors.New("unknown hash format")
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Hasher hashes account numbers with argon2id. Hashes are encoded in the PHC string format, e.g.
+// "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>", so every hash records the algorithm and parameters it was made with.
+// Hashes made with older parameters, and the bcrypt hashes of accounts created before argon2id was introduced,
+// keep verifying and are reported as needing a rehash.
+// Every argon2id run takes the configured memory, so the number of hashes computed at once is bounded by slots.
+type Hasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	slots       chan struct{}
+}
+
+// NewHasher returns a hasher using the configured argon2id parameters.
+// Values below the minimum argon2id accepts are raised to it.
+func NewHasher(cfg config.HashingConfig) *Hasher {
+	concurrency := cfg.MaxConcurrent
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	return &Hasher{
+		memory:      max(cfg.Argon2Memory, 8*uint32(max(cfg.Argon2Parallelism, 1))),
+		iterations:  max(cfg.Argon2Iterations, 1),
+		parallelism: max(cfg.Argon2Parallelism, 1),
+		slots:       make(chan struct{}, concurrency),
+	}
+}
+
+// idKey computes an argon2id key once a slot is free.
+func (h *Hasher) idKey(secret, salt []byte, iterations, memory uint32, parallelism uint8, keyLength uint32) []byte {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+
+	return argon2.IDKey(secret, salt, iterations, memory, parallelism, keyLength)
+}
+
+// Hash returns the encoded argon2id hash of the secret under a random salt.
+func (h *Hasher) Hash(secret string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := h.idKey([]byte(secret), salt, h.iterations, h.memory, h.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks the secret against an encoded hash. It returns ErrMismatchedHash if the secret does not match.
+// needsRehash reports whether a matching hash was made with another algorithm or other parameters than
+// the current ones, in which case it should be replaced with a fresh hash of the secret.
+func (h *Hasher) Verify(encoded, secret string) (needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return h.verifyArgon2id(encoded, secret)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(secret)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, ErrMismatchedHash
+			}
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+func (h *Hasher) verifyArgon2id(encoded, secret string) (bool, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrUnknownHashFormat
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrUnknownHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, ErrUnknownHashFormat
+	}
+
+	candidate := h.idKey([]byte(secret), salt, iterations, memory, parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return false, ErrMismatchedHash
+	}
+
+	needsRehash := memory != h.memory || iterations != h.iterations || parallelism != h.parallelism
+	return needsRehash, nil
+}
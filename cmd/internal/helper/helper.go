@@ -5,15 +5,16 @@ package helper
 
 import (
 	"anon-confessions/cmd/internal/models"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"log"
 	"math/big"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // GenerateAccountNumber generates a random 16-digit account number.
@@ -29,23 +30,13 @@ func GenerateAccountNumber() (string, error) {
 	return fmt.Sprintf("%016d", n), nil
 }
 
-// HashAccountNumber hashes the given account number using bcrypt.
-// It returns the hashed account number as a string.
-// If an error occurs during hashing, it logs the error
-func HashAccountNumber(accountNumber string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(accountNumber), bcrypt.DefaultCost)
-
-	if err != nil {
-		log.Printf("Error hashing account number: %s", accountNumber)
-	}
-
-	return string(hash)
-}
-
-// CompareHashAndPassword compares a hashed password with its possible plaintext equivalent.
-// It returns nil on success, or an error on failure.
-func CompareHashAndPassword(hashedPassword, password []byte) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// AccountLookupHash returns a keyed fingerprint of the account number (HMAC-SHA256 under the server-side pepper).
+// Unlike the account number hash it is deterministic, so it can be stored in an indexed column and used to find
+// the owner of an account number with a single query before the hash is checked.
+func AccountLookupHash(pepper, accountNumber string) string {
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(accountNumber))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 type SuccessMessage struct {
@@ -76,6 +67,30 @@ func RetrieveLoggedInUserId(c *gin.Context) int {
 	return intUserId
 }
 
+// RetrieveSessionId retrieves the ID of the session the request was authenticated with.
+// It returns 0 when the request was not authenticated with a session token, e.g. through the legacy account number header.
+func RetrieveSessionId(c *gin.Context) int {
+	sessionID, ok := c.Get("sessionID")
+	if !ok {
+		return 0
+	}
+
+	intSessionId, _ := sessionID.(int)
+	return intSessionId
+}
+
+// RetrieveAPIKeyScopes retrieves the scopes of the API key the request was authenticated with.
+// The second return value is false when the request was not authenticated with an API key, in which case it has full access.
+func RetrieveAPIKeyScopes(c *gin.Context) ([]string, bool) {
+	scopes, ok := c.Get("apiKeyScopes")
+	if !ok {
+		return nil, false
+	}
+
+	stringScopes, _ := scopes.([]string)
+	return stringScopes, true
+}
+
 // ParseIDParam retrieves the parameter specified from the route parameter as an integer.
 // If the format is invalid, it aborts the HTTP request with a 400 Bad Request status.
 func ParseIDParam(c *gin.Context, param string) int {
@@ -90,15 +105,41 @@ func ParseIDParam(c *gin.Context, param string) int {
 
 // GenerateSortOrder generates an SQL ORDER BY clause based on the provided sorting preferences.
 // It will give priority to the SortByLikes field if it is set.
+// The hot and trending rankings take priority over both, with the post ID breaking ties.
 func GenerateOrderClause(postQueryParam models.PostQueryParams) string {
 	var orderClause string
 
+	switch postQueryParam.Sort {
+	case models.PostSortHot:
+		return "posts.hot_score DESC, posts.id DESC"
+	case models.PostSortTrending:
+		return "posts.trending_score DESC, posts.id DESC"
+	}
+
 	if postQueryParam.SortByLikes != "" {
-		orderClause = "total_likes " + postQueryParam.SortByLikes
+		orderClause = "posts.total_likes " + postQueryParam.SortByLikes
 	}
 	if postQueryParam.SortByCreationDate != "" {
-		orderClause = "created_at " + postQueryParam.SortByCreationDate
+		orderClause = "posts.created_at " + postQueryParam.SortByCreationDate
 	}
 
 	return orderClause
 }
+
+// GroupByBoard returns the IDs of the given content grouped by the slug of their board.
+func GroupByBoard(content []models.BoardContent) map[string][]int {
+	groups := make(map[string][]int)
+	for _, c := range content {
+		groups[c.Board] = append(groups[c.Board], c.ID)
+	}
+	return groups
+}
+
+// ContentIDs returns the IDs of the given content.
+func ContentIDs(content []models.BoardContent) []int {
+	ids := make([]int, 0, len(content))
+	for _, c := range content {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
@@ -1,11 +1,22 @@
 package helper
 
 import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/models"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAccountNumber(t *testing.T) {
@@ -20,12 +31,53 @@ func TestAccountNumber(t *testing.T) {
 }
 
 func TestHashedAccountNumber(t *testing.T) {
-	hashedAccountNumber := HashAccountNumber("1234567890123456")
-	if len(hashedAccountNumber) == 0 {
-		t.Fatalf("Expected hashed account number to be non-empty, got empty string")
+	hasher := NewHasher(config.HashingConfig{Argon2Memory: 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+
+	hashedAccountNumber, err := hasher.Hash("1234567890123456")
+	if err != nil {
+		t.Fatalf("Error hashing account number: %v", err)
+	}
+	if !strings.HasPrefix(hashedAccountNumber, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Expected an encoded argon2id hash, got %s", hashedAccountNumber)
 	}
 
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("1234567890123456"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Error creating bcrypt hash: %v", err)
+	}
+	outdatedHash, err := NewHasher(config.HashingConfig{Argon2Memory: 2048, Argon2Iterations: 1, Argon2Parallelism: 1}).Hash("1234567890123456")
+	if err != nil {
+		t.Fatalf("Error hashing account number: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		hash        string
+		secret      string
+		needsRehash bool
+		wantErr     error
+	}{
+		{name: "Current argon2id hash", hash: hashedAccountNumber, secret: "1234567890123456"},
+		{name: "Wrong account number", hash: hashedAccountNumber, secret: "1234567890123457", wantErr: ErrMismatchedHash},
+		{name: "Outdated argon2id parameters", hash: outdatedHash, secret: "1234567890123456", needsRehash: true},
+		{name: "Legacy bcrypt hash", hash: string(legacyHash), secret: "1234567890123456", needsRehash: true},
+		{name: "Wrong account number for bcrypt hash", hash: string(legacyHash), secret: "1234567890123457", wantErr: ErrMismatchedHash},
+		{name: "Unknown hash format", hash: "1234567890123456", secret: "1234567890123456", wantErr: ErrUnknownHashFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			needsRehash, err := hasher.Verify(tt.hash, tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if needsRehash != tt.needsRehash {
+				t.Errorf("Expected needsRehash %v, got %v", tt.needsRehash, needsRehash)
+			}
+		})
+	}
 }
+
 func TestRetrieveLoggedInUserId(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -146,3 +198,234 @@ func TestParseIDParam(t *testing.T) {
 		})
 	}
 }
+
+func TestRecoveryPhrase(t *testing.T) {
+	accountNumber := "3998442793406687"
+
+	phrase, err := EncodeRecoveryPhrase(accountNumber)
+	if err != nil {
+		t.Fatalf("Error encoding recovery phrase: %v", err)
+	}
+
+	words := strings.Fields(phrase)
+	if len(words) != recoveryPhraseLength {
+		t.Fatalf("Expected %d words, got %d", recoveryPhraseLength, len(words))
+	}
+
+	// Replace the first word with a different word from the list to simulate a typo.
+	typo := append([]string{}, words...)
+	typo[0] = recoveryWords[(int(recoveryWordIndex[words[0]])+1)%len(recoveryWords)]
+
+	tests := []struct {
+		name     string
+		phrase   string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Valid phrase", phrase: phrase, expected: accountNumber},
+		{name: "Valid phrase in upper case", phrase: strings.ToUpper(phrase), expected: accountNumber},
+		{name: "Mistyped word", phrase: strings.Join(typo, " "), wantErr: true},
+		{name: "Unknown word", phrase: strings.Join(append([]string{"qwerty"}, words[1:]...), " "), wantErr: true},
+		{name: "Missing word", phrase: strings.Join(words[1:], " "), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeRecoveryPhrase(tt.phrase)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecoveryPhrase) {
+					t.Fatalf("Expected ErrInvalidRecoveryPhrase, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if decoded != tt.expected {
+				t.Errorf("Expected account number %s, got %s", tt.expected, decoded)
+			}
+		})
+	}
+
+	// Account numbers pass through ParseAccountCredential unchanged
+	credential, err := ParseAccountCredential(accountNumber)
+	if err != nil || credential != accountNumber {
+		t.Errorf("Expected account number to pass through unchanged, got %s (%v)", credential, err)
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	token, err := SignChallenge("test-secret", SignupChallenge{Nonce: "nonce", Difficulty: 8, ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Error signing challenge: %v", err)
+	}
+
+	challenge, err := ParseChallenge("test-secret", token)
+	if err != nil || challenge.Difficulty != 8 {
+		t.Fatalf("Expected the signed challenge to parse, got %+v, %v", challenge, err)
+	}
+	if _, err := ParseChallenge("other-secret", token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a challenge signed with another secret to be rejected, got %v", err)
+	}
+
+	// Find the first solution and the first non-solution by brute force, as a client would.
+	var solution, wrong string
+	for i := 0; solution == "" || wrong == ""; i++ {
+		candidate := strconv.Itoa(i)
+		sum := sha256.Sum256([]byte(token + ":" + candidate))
+		if sum[0] == 0 && solution == "" {
+			solution = candidate
+		} else if sum[0] != 0 && wrong == "" {
+			wrong = candidate
+		}
+	}
+
+	if !VerifyProofOfWork(token, solution, 8) {
+		t.Errorf("Expected %q to solve the challenge", solution)
+	}
+	if VerifyProofOfWork(token, wrong, 8) {
+		t.Errorf("Expected %q not to solve the challenge", wrong)
+	}
+	if VerifyProofOfWork(token, "", 0) {
+		t.Errorf("Expected an empty solution to be rejected")
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := map[string]string{
+		"secret crush":         `"secret" "crush"`,
+		`"unbalanced OR NEAR(`: `"unbalanced" "OR" "NEAR"`,
+		"confess*":             `"confess"*`,
+		"!!! ---":              "",
+	}
+	for query, expected := range tests {
+		if match := BuildMatchQuery(query); match != expected {
+			t.Errorf("Expected %q to become %q, got %q", query, expected, match)
+		}
+	}
+
+	snippets := map[string]string{
+		"a \x01<b>crush</b>\x02 & more":    "a <mark>&lt;b&gt;crush&lt;/b&gt;</mark> &amp; more",
+		"\x02stray\x01 open \x01twice\x01": "stray<mark> open twice</mark>",
+	}
+	for snippet, expected := range snippets {
+		if highlighted := HighlightSnippet(snippet); highlighted != expected {
+			t.Errorf("Expected %q to become %q, got %q", snippet, expected, highlighted)
+		}
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	tests := map[string][]string{
+		"Feeling #Blessed and #blessed again #tbt": {"blessed", "tbt"},
+		"#start, mid#dle and #end.":                {"start", "end"},
+		"see example.com/page#anchor or &#39;":     {},
+		"#2024 was rough #year2024":                {"year2024"},
+		"#café_au_lait":                            {"café_au_lait"},
+	}
+	for content, expected := range tests {
+		if tags := ExtractTags(content); !slices.Equal(tags, expected) {
+			t.Errorf("Expected %q to have tags %v, got %v", content, expected, tags)
+		}
+	}
+
+	many := strings.Repeat("#tag ", 3) + "#a #b #c #d #e #f #g #h #i #j #k"
+	if tags := ExtractTags(many); len(tags) != 10 || tags[0] != "tag" {
+		t.Errorf("Expected ten tags starting with the first one, got %v", tags)
+	}
+	if tag := NormalizeTag(" #GoLang "); tag != "golang" {
+		t.Errorf("Expected a normalized tag, got %q", tag)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	cursor := models.PostCursor{
+		SortBy:    models.PreferenceSortByCreationDate,
+		Order:     "desc",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC),
+		ID:        42,
+		Backward:  true,
+	}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	if err != nil || *decoded != cursor {
+		t.Fatalf("Expected the cursor to survive encoding, got %+v, %v", decoded, err)
+	}
+
+	invalid := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		EncodeCursor(models.PostCursor{SortBy: "comments", Order: "asc", ID: 1}),
+		EncodeCursor(models.PostCursor{SortBy: models.PreferenceSortByLikes, Order: "asc"}),
+	}
+	for _, encoded := range invalid {
+		if _, err := DecodeCursor(encoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected %q to be rejected, got %v", encoded, err)
+		}
+	}
+}
+
+func TestRankingScores(t *testing.T) {
+	if HotScore(10, 0, 0) <= HotScore(10, 0, 24*time.Hour) {
+		t.Errorf("Expected older posts to score lower")
+	}
+	if HotScore(0, 5, time.Hour) != HotScore(10, 0, time.Hour) {
+		t.Errorf("Expected a comment to count as much as two likes")
+	}
+	if score := HotScore(4, 0, 0); math.Abs(score-4/math.Pow(2, 1.8)) > 1e-9 {
+		t.Errorf("Unexpected score %f for a new post", score)
+	}
+
+	if weight := TrendingWeight(6*time.Hour, 6*time.Hour); math.Abs(weight-0.5) > 1e-9 {
+		t.Errorf("Expected a reaction to count half after one half-life, got %f", weight)
+	}
+	if TrendingWeight(-time.Minute, time.Hour) != 1 {
+		t.Errorf("Expected reactions from the future to count fully")
+	}
+}
+
+func TestCommentPseudonyms(t *testing.T) {
+	const secret, postID = "test-pseudonym-secret", 7
+
+	// Find two users whose handles collide in the thread
+	seen := make(map[string]int)
+	first, second := 0, 0
+	for userID := 1; second == 0; userID++ {
+		name := Pseudonym(secret, postID, userID)
+		if other, ok := seen[name]; ok {
+			first, second = other, userID
+		}
+		seen[name] = userID
+	}
+
+	comments := []models.Comment{{ID: 3, UserID: second}, {ID: 1, UserID: first}, {ID: 2, UserID: second}}
+	AnnotateCommentAuthors(secret, postID, first, []int{first, second}, comments)
+	base := Pseudonym(secret, postID, first)
+	if comments[1].Author != base || comments[0].Author != base+" 2" || comments[2].Author != base+" 2" {
+		t.Errorf("Expected the later user to get a suffixed handle, got %+v", comments)
+	}
+
+	// Handles do not change when the earlier user's comments are deleted
+	remaining := []models.Comment{{ID: 3, UserID: second}, {ID: 2, UserID: second}}
+	AnnotateCommentAuthors(secret, postID, first, []int{first, second}, remaining)
+	if remaining[0].Author != base+" 2" || remaining[1].Author != base+" 2" {
+		t.Errorf("Expected the later user to keep the suffixed handle, got %+v", remaining)
+	}
+
+	// Users not among the participants yet come last
+	AnnotateCommentAuthors(secret, postID, first, nil, comments)
+	if comments[1].Author != base || comments[0].Author != base+" 2" {
+		t.Errorf("Expected the handles to follow the comment order without participants, got %+v", comments)
+	}
+	if !comments[1].IsOP || comments[0].IsOP {
+		t.Errorf("Expected only the comment of the post author to be flagged as OP, got %+v", comments)
+	}
+
+	// Posts of anonymized accounts flag nobody, not even comments of the tombstone user
+	AnnotateCommentAuthors(secret, postID, 0, []int{first, second}, comments)
+	for _, comment := range comments {
+		if comment.IsOP {
+			t.Errorf("Expected no OP on a post of the tombstone user, got %+v", comment)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRecoveryPhrase is wrapped by every error returned when a recovery phrase cannot be decoded.
+// The wrapping error explains what is wrong with the phrase, e.g. which word is unknown.
+var ErrInvalidRecoveryPhrase = errors.New("invalid recovery phrase")
+
+//go:embed wordlist.txt
+var wordlistFile string
+
+// recoveryWords is the bundled list of 256 words, so every word encodes exactly one byte.
+// No two words share their first four letters.
+var recoveryWords = strings.Fields(wordlistFile)
+
+var recoveryWordIndex = func() map[string]byte {
+	index := make(map[string]byte, len(recoveryWords))
+	for i, word := range recoveryWords {
+		index[word] = byte(i)
+	}
+	return index
+}()
+
+// recoveryPhraseLength is the number of words in a recovery phrase:
+// seven words carry the account number and the last one is a checksum.
+const recoveryPhraseLength = 8
+
+// EncodeRecoveryPhrase encodes a 16-digit account number as a phrase of eight words from the bundled word list.
+// The phrase is just another spelling of the account number, so it changes whenever the account number is rotated.
+func EncodeRecoveryPhrase(accountNumber string) (string, error) {
+	n, err := strconv.ParseUint(accountNumber, 10, 64)
+	if err != nil || len(accountNumber) != 16 {
+		return "", errors.New("account number must be 16 digits")
+	}
+
+	data := recoveryPhraseData(n)
+	words := make([]string, 0, recoveryPhraseLength)
+	for _, b := range data[1:] {
+		words = append(words, recoveryWords[b])
+	}
+	words = append(words, recoveryWords[recoveryPhraseChecksum(data)])
+
+	return strings.Join(words, " "), nil
+}
+
+// DecodeRecoveryPhrase turns a recovery phrase back into the account number it encodes.
+// Words are matched case-insensitively. Unknown words, a wrong number of words and
+// checksum mismatches caused by a mistyped word are reported as distinct errors wrapping ErrInvalidRecoveryPhrase.
+func DecodeRecoveryPhrase(phrase string) (string, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) != recoveryPhraseLength {
+		return "", fmt.Errorf("%w: expected %d words, got %d", ErrInvalidRecoveryPhrase, recoveryPhraseLength, len(words))
+	}
+
+	var data [8]byte
+	for i, word := range words {
+		b, ok := recoveryWordIndex[word]
+		if !ok {
+			return "", fmt.Errorf("%w: word %d (%q) is not in the word list", ErrInvalidRecoveryPhrase, i+1, word)
+		}
+		if i < recoveryPhraseLength-1 {
+			data[i+1] = b
+		} else if b != recoveryPhraseChecksum(data) {
+			return "", fmt.Errorf("%w: checksum mismatch, one of the words is probably mistyped", ErrInvalidRecoveryPhrase)
+		}
+	}
+
+	n := binary.BigEndian.Uint64(data[:])
+	if n >= 10_000_000_000_000_000 {
+		return "", fmt.Errorf("%w: phrase does not encode an account number", ErrInvalidRecoveryPhrase)
+	}
+
+	return fmt.Sprintf("%016d", n), nil
+}
+
+// ParseAccountCredential accepts either an account number or a recovery phrase and returns the account number.
+// Anything containing letters is treated as a recovery phrase; everything else is returned unchanged.
+func ParseAccountCredential(credential string) (string, error) {
+	credential = strings.TrimSpace(credential)
+	if !strings.ContainsFunc(credential, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }) {
+		return credential, nil
+	}
+
+	return DecodeRecoveryPhrase(credential)
+}
+
+// recoveryPhraseData returns the seven low-order bytes of n that are encoded as words.
+// Account numbers are below 10^16 < 2^56, so the high-order byte is always zero.
+func recoveryPhraseData(n uint64) [8]byte {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], n)
+	return data
+}
+
+func recoveryPhraseChecksum(data [8]byte) byte {
+	sum := sha256.Sum256(data[1:])
+	return sum[0]
+}
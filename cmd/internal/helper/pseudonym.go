@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pseudonymAdjectives and pseudonymAnimals hold 128 words each, so a pseudonym is one of 16384 names.
+var pseudonymAdjectives = []string{
+	"agile", "amber", "ancient", "arctic", "autumn", "balmy", "bashful", "bold", "bouncy", "brassy", "brave",
+	"breezy", "bright", "brisk", "bubbly", "calm", "candid", "careful", "cheerful", "chilly", "chipper",
+	"clever", "cloudy", "cobalt", "cosmic", "cozy", "crafty", "crimson", "curious", "dapper", "daring",
+	"dashing", "dreamy", "dusky", "dusty", "eager", "earnest", "elegant", "emerald", "fancy", "fearless",
+	"fiery", "fluffy", "frosty", "fuzzy", "gentle", "giddy", "gleeful", "glossy", "golden", "graceful",
+	"grumpy", "hardy", "hasty", "hazy", "hidden", "humble", "icy", "indigo", "jazzy", "jolly", "jovial", "keen",
+	"kind", "lively", "lonely", "lucky", "lunar", "mellow", "merry", "mighty", "misty", "modest", "moody",
+	"mystic", "nimble", "noble", "nutty", "orange", "patient", "peaceful", "plucky", "polite", "proud", "quick",
+	"quiet", "radiant", "rapid", "restless", "rosy", "rusty", "scarlet", "secret", "serene", "shadowy", "shiny",
+	"shy", "silent", "silver", "sleepy", "sly", "snowy", "solar", "spicy", "spry", "stormy", "sunny", "swift",
+	"thirsty", "tidy", "timid", "tiny", "tranquil", "twilight", "velvet", "vivid", "wandering", "warm", "wary",
+	"whimsical", "wild", "windy", "wise", "witty", "woolly", "young", "zealous", "zesty",
+}
+
+var pseudonymAnimals = []string{
+	"aardvark", "albatross", "alpaca", "antelope", "armadillo", "badger", "barracuda", "bat", "bear", "beaver",
+	"bison", "boar", "butterfly", "camel", "capybara", "caribou", "cat", "chameleon", "cheetah", "chipmunk",
+	"cobra", "condor", "cougar", "coyote", "crab", "crane", "crow", "deer", "dingo", "dolphin", "donkey",
+	"dove", "duck", "eagle", "eel", "elk", "emu", "falcon", "ferret", "finch", "flamingo", "fox", "frog",
+	"gazelle", "gecko", "gerbil", "giraffe", "gnu", "goat", "goose", "gorilla", "grouse", "hamster", "hare",
+	"hawk", "hedgehog", "heron", "hippo", "hornet", "horse", "hyena", "ibis", "iguana", "impala", "jackal",
+	"jaguar", "kangaroo", "kiwi", "koala", "lemur", "leopard", "lion", "llama", "lobster", "lynx", "magpie",
+	"manatee", "marmot", "meerkat", "mink", "mole", "mongoose", "moose", "moth", "narwhal", "newt", "ocelot",
+	"octopus", "opossum", "orca", "ostrich", "otter", "owl", "panda", "panther", "parrot", "pelican", "penguin",
+	"pheasant", "pigeon", "platypus", "porcupine", "puffin", "puma", "quail", "rabbit", "raccoon", "raven",
+	"reindeer", "rhino", "robin", "seal", "shark", "sloth", "snail", "sparrow", "squid", "squirrel", "starling",
+	"stingray", "stork", "swan", "tapir", "tiger", "toad", "toucan", "turtle", "walrus",
+}
+
+// Pseudonym returns the stable, per-post handle of a user, e.g. "Crimson Otter".
+// It is derived from a keyed hash of the post and user IDs, so the same user gets the same handle
+// throughout one thread, while handles in different threads cannot be linked without the secret.
+func Pseudonym(secret string, postID, userID int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(postID) + ":" + strconv.Itoa(userID)))
+	sum := mac.Sum(nil)
+
+	adjective := pseudonymAdjectives[int(sum[0])%len(pseudonymAdjectives)]
+	animal := pseudonymAnimals[int(sum[1])%len(pseudonymAnimals)]
+
+	return capitalize(adjective) + " " + capitalize(animal)
+}
+
+// AnnotateCommentAuthors sets the pseudonym of every comment on a post and flags the comments written by the post's author.
+// A postAuthorID of 0 flags no comment, which is used for posts of anonymized accounts: they all belong to the tombstone user.
+// Two users of a thread can hash to the same handle; the later one, by first comment, then gets a numeric suffix such as
+// "Crimson Otter 2". participants lists the users of the thread in the order of their first comment, deleted comments
+// included, so the suffixes do not change when comments are deleted or restored.
+func AnnotateCommentAuthors(secret string, postID, postAuthorID int, participants []int, comments []models.Comment) {
+	// Users missing from participants, such as ones who commented since it was read, come last by first comment
+	order := make([]int, len(comments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return comments[order[a]].ID < comments[order[b]].ID })
+	users := slices.Clone(participants)
+	for _, i := range order {
+		if !slices.Contains(users, comments[i].UserID) {
+			users = append(users, comments[i].UserID)
+		}
+	}
+
+	handles := make(map[int]string)
+	taken := make(map[string]bool)
+	for _, userID := range users {
+		if _, ok := handles[userID]; ok {
+			continue
+		}
+		base := Pseudonym(secret, postID, userID)
+		handle := base
+		for n := 2; taken[handle]; n++ {
+			handle = base + " " + strconv.Itoa(n)
+		}
+		handles[userID] = handle
+		taken[handle] = true
+	}
+
+	for i := range comments {
+		comments[i].Author = handles[comments[i].UserID]
+		comments[i].IsOP = postAuthorID != 0 && comments[i].UserID == postAuthorID
+	}
+}
+
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
@@ -0,0 +1,25 @@
+package helper
+
+import (
+	"math"
+	"time"
+)
+
+// hotGravity is how quickly posts sink in the hot feed as they age, as in the Hacker News formula.
+const hotGravity = 1.8
+
+// CommentWeight is how many likes a comment is worth when ranking posts, since it takes more effort than a like.
+const CommentWeight = 2
+
+// HotScore ranks a post for the hot feed the way Hacker News does: its likes and comments divided by its
+// age in hours plus two, raised to the gravity. Newer posts need fewer reactions to reach the same score.
+func HotScore(likes, comments int, age time.Duration) float64 {
+	points := float64(likes + CommentWeight*comments)
+	return points / math.Pow(max(age.Hours(), 0)+2, hotGravity)
+}
+
+// TrendingWeight is how much a reaction from age ago counts towards the trending score of a post.
+// It halves every halfLife, so a post trends while it keeps getting reactions, however old it is.
+func TrendingWeight(age, halfLife time.Duration) float64 {
+	return math.Exp2(-max(age.Hours(), 0) / halfLife.Hours())
+}
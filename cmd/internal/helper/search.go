@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Markers the database wraps around the matching words of a snippet. Being control characters, they survive
+// HTML escaping unchanged and are only turned into tags afterwards.
+const (
+	SnippetMarkStart = "\x01"
+	SnippetMarkEnd   = "\x02"
+)
+
+// searchTermPattern matches the words of a search query, optionally followed by * for a prefix search.
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+\*?`)
+
+// BuildMatchQuery turns free text into an FTS MATCH expression that finds rows containing every word.
+// Each word is quoted, so operators and unbalanced quotes in user input cannot cause syntax errors;
+// a trailing * stays outside the quotes, where FTS5 reads it as a prefix search.
+// Only the first ten words are used. It returns an empty string when the text contains no searchable words.
+func BuildMatchQuery(query string) string {
+	terms := searchTermPattern.FindAllString(query, 10)
+
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		word := strings.TrimSuffix(term, "*")
+		quoted = append(quoted, `"`+word+`"`+term[len(word):])
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// HighlightSnippet HTML-escapes a snippet made with SnippetMarkStart and SnippetMarkEnd around the matching words
+// and wraps those words in <mark> tags instead. Markers that would leave a tag unbalanced, such as ones taken
+// from the content itself, are dropped.
+func HighlightSnippet(snippet string) string {
+	var b strings.Builder
+	open := false
+	for _, r := range html.EscapeString(snippet) {
+		switch {
+		case string(r) == SnippetMarkStart:
+			if !open {
+				b.WriteString("<mark>")
+				open = true
+			}
+		case string(r) == SnippetMarkEnd:
+			if open {
+				b.WriteString("</mark>")
+				open = false
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package helper
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxTagsPerPost caps how many hashtags are taken from a single post.
+const maxTagsPerPost = 10
+
+// hashtagPattern matches a # followed by up to 50 letters, digits or underscores. The # must not follow a word
+// character or &, so URL fragments and HTML entities such as &#39; are not taken for tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]{1,50})`)
+
+// ExtractTags returns the distinct hashtags of a post in the order they first appear, lowercased and without the #.
+// Tags without a letter, such as #1, are ignored. At most ten tags are returned.
+func ExtractTags(content string) []string {
+	tags := []string{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag := NormalizeTag(match[1])
+		if !strings.ContainsFunc(tag, unicode.IsLetter) || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+		if len(tags) == maxTagsPerPost {
+			break
+		}
+	}
+
+	return tags
+}
+
+// NormalizeTag lowercases a tag and strips a leading #, so tags match however they were written.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
@@ -1,6 +1,7 @@
 package testutils
 
 import (
+	appdb "anon-confessions/cmd/internal/db"
 	"anon-confessions/cmd/internal/models"
 	"bytes"
 	"log"
@@ -35,6 +36,9 @@ func SetupMockDB() *gorm.DB {
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(10)
 	sqlDB.SetConnMaxLifetime(time.Hour)
+	if err := appdb.CheckFTS5(db); err != nil {
+		log.Fatalf("Cannot set up the test database: %v", err)
+	}
 
 	// Dynamically determine the path to the migration files.
 	_, b, _, _ := runtime.Caller(0)
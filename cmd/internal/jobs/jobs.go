@@ -0,0 +1,59 @@
+// Package jobs runs maintenance tasks in the background while the server is up.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a task run at a fixed interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(context.Context) error
+}
+
+// Start runs every job with a positive interval in its own goroutine until the context is cancelled.
+// A job runs once right away and then every interval. Failures are logged and the job is retried at the next tick.
+// The returned function waits for the jobs to stop, letting a run in progress finish.
+func Start(ctx context.Context, jobs ...Job) (wait func()) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			slog.Info("Background job disabled", slog.String("job", job.Name))
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx, job)
+		}()
+	}
+
+	return wg.Wait
+}
+
+func run(ctx context.Context, job Job) {
+	slog.Info("Starting background job", slog.String("job", job.Name), slog.Duration("interval", job.Interval))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		started := time.Now()
+		if err := job.Run(ctx); err != nil {
+			slog.Error("Background job failed", slog.String("job", job.Name), slog.String("error", err.Error()))
+		} else {
+			slog.Debug("Background job finished", slog.String("job", job.Name), slog.Duration("took", time.Since(started)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs, disabledRuns atomic.Int32
+	wait := Start(ctx,
+		Job{Name: "failing", Interval: 10 * time.Millisecond, Run: func(context.Context) error {
+			runs.Add(1)
+			return errors.New("keeps failing")
+		}},
+		Job{Name: "disabled", Interval: 0, Run: func(context.Context) error {
+			disabledRuns.Add(1)
+			return nil
+		}},
+	)
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if runs.Load() < 3 {
+		t.Fatalf("Expected a failing job to be retried, it ran %d times", runs.Load())
+	}
+
+	cancel()
+	wait()
+	stopped := runs.Load()
+	time.Sleep(50 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Errorf("Expected the job to stop once the context is cancelled")
+	}
+	if disabledRuns.Load() != 0 {
+		t.Errorf("Expected a job without an interval not to run")
+	}
+}
@@ -0,0 +1,173 @@
+// Package lockout throttles credential guessing. Failed authentication attempts are counted per client IP
+// and per account number prefix, and once a counter passes its limit further attempts are locked out
+// for an exponentially growing period.
+//
+// A locked client IP is turned away before its credential is checked. A locked prefix never turns away a
+// valid credential, so nobody can lock the owners of a prefix out by failing against it on purpose; instead,
+// a wrong guess against a locked prefix locks the guessing client IP right away.
+package lockout
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Entry is the failure state of a single key.
+// Failures are forgotten once ExpiresAt has passed without further failures.
+type Entry struct {
+	Failures    int
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// Store persists lockout entries.
+// Update reads the entry of a key, the zero Entry for unknown or expired keys, lets fn change it and stores
+// the result as one atomic operation, so concurrent attempts never work on stale counters. It returns the stored entry.
+type Store interface {
+	Update(ctx context.Context, key string, fn func(entry *Entry)) (Entry, error)
+}
+
+// LockedError is returned while a client is locked out. RetryAfter is the time left until the lockout ends.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed authentication attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// Guard decides whether an authentication attempt may proceed and records failed attempts.
+type Guard struct {
+	store Store
+	cfg   config.LockoutConfig
+}
+
+func NewGuard(store Store, cfg config.LockoutConfig) *Guard {
+	return &Guard{store: store, cfg: cfg}
+}
+
+// Begin is called before a credential is checked. It returns a *LockedError while the client IP is locked out
+// and otherwise counts the attempt against the client IP up front, in the same atomic update, so parallel guesses
+// cannot get past the limit. Every attempt that was let through has to be settled with Succeed or Fail.
+func (g *Guard) Begin(ctx context.Context, clientIP string) error {
+	key, ok := g.ipKey(clientIP)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	var retryAfter time.Duration
+	_, err := g.store.Update(ctx, key.name, func(entry *Entry) {
+		if entry.LockedUntil.After(now) {
+			retryAfter = entry.LockedUntil.Sub(now)
+			return
+		}
+		g.countFailure(entry, key.maxFailures, now)
+	})
+	if err != nil {
+		return err
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Succeed settles an attempt with a valid credential by taking back the failure Begin counted in advance,
+// along with the lockout that failure may have started. The next failure locks the client IP again.
+func (g *Guard) Succeed(ctx context.Context, clientIP string) error {
+	key, ok := g.ipKey(clientIP)
+	if !ok {
+		return nil
+	}
+
+	_, err := g.store.Update(ctx, key.name, func(entry *Entry) {
+		if entry.Failures > 0 {
+			entry.Failures--
+		}
+		entry.LockedUntil = time.Time{}
+	})
+	return err
+}
+
+// Fail settles an attempt with a wrong credential by counting it against the prefix of the attempted account number;
+// the client IP was already charged by Begin. When the prefix was locked out already, the client IP is locked out
+// as well and a *LockedError is returned.
+func (g *Guard) Fail(ctx context.Context, clientIP, accountNumber string) error {
+	key, ok := g.prefixKey(accountNumber)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	var retryAfter time.Duration
+	_, err := g.store.Update(ctx, key.name, func(entry *Entry) {
+		if entry.LockedUntil.After(now) {
+			retryAfter = entry.LockedUntil.Sub(now)
+		}
+		g.countFailure(entry, key.maxFailures, now)
+	})
+	if err != nil || retryAfter == 0 {
+		return err
+	}
+
+	if ipKey, ok := g.ipKey(clientIP); ok {
+		_, err := g.store.Update(ctx, ipKey.name, func(entry *Entry) {
+			entry.Failures = max(entry.Failures, ipKey.maxFailures)
+			g.lock(entry, entry.Failures-ipKey.maxFailures, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return &LockedError{RetryAfter: retryAfter}
+}
+
+// countFailure counts one more failure. A key that reaches its limit is locked for BaseDelay,
+// doubling with every further failure up to MaxDelay.
+func (g *Guard) countFailure(entry *Entry, maxFailures int, now time.Time) {
+	entry.Failures++
+	entry.ExpiresAt = now.Add(g.cfg.Window)
+	if entry.Failures >= maxFailures {
+		g.lock(entry, entry.Failures-maxFailures, now)
+	}
+}
+
+func (g *Guard) lock(entry *Entry, excessFailures int, now time.Time) {
+	entry.LockedUntil = now.Add(g.lockDuration(excessFailures))
+	if entry.LockedUntil.After(entry.ExpiresAt) {
+		entry.ExpiresAt = entry.LockedUntil
+	}
+}
+
+func (g *Guard) lockDuration(excessFailures int) time.Duration {
+	delay := float64(g.cfg.BaseDelay) * math.Pow(2, float64(excessFailures))
+	if delay > float64(g.cfg.MaxDelay) {
+		return g.cfg.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+type key struct {
+	name        string
+	maxFailures int
+}
+
+// ipKey returns the counter of a client IP. A limit of zero disables it.
+func (g *Guard) ipKey(clientIP string) (key, bool) {
+	if g.cfg.MaxFailures <= 0 || clientIP == "" {
+		return key{}, false
+	}
+	return key{name: "ip:" + clientIP, maxFailures: g.cfg.MaxFailures}, true
+}
+
+// prefixKey returns the counter of the prefix of an account number. Limits of zero disable it.
+func (g *Guard) prefixKey(accountNumber string) (key, bool) {
+	if g.cfg.PrefixMaxFailures <= 0 || g.cfg.PrefixLength <= 0 || len(accountNumber) < g.cfg.PrefixLength {
+		return key{}, false
+	}
+	return key{name: "prefix:" + accountNumber[:g.cfg.PrefixLength], maxFailures: g.cfg.PrefixMaxFailures}, true
+}
@@ -0,0 +1,144 @@
+package lockout
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	cfg := config.LockoutConfig{
+		MaxFailures:       3,
+		PrefixLength:      4,
+		PrefixMaxFailures: 5,
+		BaseDelay:         time.Minute,
+		MaxDelay:          3 * time.Minute,
+		Window:            time.Hour,
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": NewSQLiteStore(testutils.SetupMockDB()),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			guard := NewGuard(store, cfg)
+			ip := "192.0.2." + name
+
+			retryAfter := func(err error) time.Duration {
+				t.Helper()
+				if err == nil {
+					return 0
+				}
+				var lockedErr *LockedError
+				if !errors.As(err, &lockedErr) {
+					t.Fatalf("Expected a LockedError, got %v", err)
+				}
+				return lockedErr.RetryAfter
+			}
+			// attempt makes an attempt with a wrong credential and returns how long the client has to wait before it.
+			attempt := func(clientIP, accountNumber string) time.Duration {
+				t.Helper()
+				if d := retryAfter(guard.Begin(ctx, clientIP)); d > 0 {
+					return d
+				}
+				return retryAfter(guard.Fail(ctx, clientIP, accountNumber))
+			}
+			// unlock lets the lockout of the client IP run out, keeping its failures.
+			unlock := func(clientIP string) {
+				store.Update(ctx, "ip:"+clientIP, func(entry *Entry) { entry.LockedUntil = time.Now() })
+			}
+
+			// The client IP is locked once it reaches its limit
+			for i := 0; i < cfg.MaxFailures; i++ {
+				if d := attempt(ip, ""); d != 0 {
+					t.Fatalf("Expected no lockout below the limit, got %s", d)
+				}
+			}
+			if d := attempt(ip, ""); d <= 0 || d > cfg.BaseDelay {
+				t.Fatalf("Expected a lockout of up to %s, got %s", cfg.BaseDelay, d)
+			}
+
+			// Every further failure doubles the lockout, up to the maximum
+			unlock(ip)
+			attempt(ip, "")
+			if d := attempt(ip, ""); d <= cfg.BaseDelay || d > 2*cfg.BaseDelay {
+				t.Fatalf("Expected the lockout to double, got %s", d)
+			}
+			unlock(ip)
+			attempt(ip, "")
+			unlock(ip)
+			attempt(ip, "")
+			if d := attempt(ip, ""); d <= 2*cfg.BaseDelay || d > cfg.MaxDelay {
+				t.Fatalf("Expected the lockout to be capped at %s, got %s", cfg.MaxDelay, d)
+			}
+
+			// A valid credential takes back its own attempt, including the lockout it started
+			validIP := "203.0.113." + name
+			for i := 0; i < cfg.MaxFailures-1; i++ {
+				attempt(validIP, "")
+			}
+			if err := guard.Begin(ctx, validIP); err != nil {
+				t.Fatalf("Expected the last attempt below the limit to go through, got %v", err)
+			}
+			if err := guard.Succeed(ctx, validIP); err != nil {
+				t.Fatalf("Failed to settle a successful attempt: %v", err)
+			}
+			if err := guard.Begin(ctx, validIP); err != nil {
+				t.Fatalf("Expected a successful attempt not to count as a failure, got %v", err)
+			}
+			guard.Succeed(ctx, validIP)
+
+			// Parallel attempts cannot get past the limit
+			parallelIP := "198.18.0." + name
+			var passed int
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if guard.Begin(ctx, parallelIP) == nil {
+						mu.Lock()
+						passed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if passed != cfg.MaxFailures {
+				t.Fatalf("Expected %d parallel attempts to go through, got %d", cfg.MaxFailures, passed)
+			}
+
+			// The prefix is locked once it reaches its limit, even if each client stays below its own
+			prefix := name + "00"
+			for i := 0; i < cfg.PrefixMaxFailures; i++ {
+				if d := attempt(fmt.Sprintf("198.51.100.%d", 10+i), fmt.Sprintf("%s%010d", prefix, i)); d != 0 {
+					t.Fatalf("Expected no prefix lockout below the limit, got %s", d)
+				}
+			}
+
+			// Owners of the prefix are not turned away, but a wrong guess against it locks the guessing client
+			ownerIP, guesserIP := "198.51.100.1", "198.51.100.2"
+			if err := guard.Begin(ctx, ownerIP); err != nil {
+				t.Fatalf("Expected a locked prefix not to turn away clients before their credential is checked, got %v", err)
+			}
+			guard.Succeed(ctx, ownerIP)
+			if d := attempt(guesserIP, prefix+"1111111111"); d <= 0 {
+				t.Fatalf("Expected a wrong guess against a locked prefix to be locked out")
+			}
+			if d := retryAfter(guard.Begin(ctx, guesserIP)); d <= 0 {
+				t.Fatalf("Expected the guessing client to be locked out")
+			}
+			if d := attempt("198.51.100.3", "1111111111111111"); d != 0 {
+				t.Fatalf("Expected other prefixes not to be locked out, got %s", d)
+			}
+		})
+	}
+}
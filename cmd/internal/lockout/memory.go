@@ -0,0 +1,45 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is the number of writes between two sweeps for expired entries.
+const pruneInterval = 1000
+
+// MemoryStore keeps lockout entries in memory. Entries are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	writes  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// Update applies fn under the lock of the store, which makes it atomic.
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(entry *Entry)) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.ExpiresAt) {
+		entry = Entry{}
+	}
+	fn(&entry)
+	s.entries[key] = entry
+
+	s.writes++
+	if s.writes%pruneInterval == 0 {
+		for k, e := range s.entries {
+			if now.After(e.ExpiresAt) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,63 @@
+package lockout
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQLiteStore keeps lockout entries in the auth_lockouts table, so lockouts survive restarts.
+type SQLiteStore struct {
+	db *gorm.DB
+}
+
+func NewSQLiteStore(db *gorm.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// Update runs in a transaction that starts by removing expired entries, which keeps the table bounded by the
+// recently failing keys. Being a write, that first statement takes the write lock of the database, so concurrent
+// updates, from this process or another one sharing the file, run one after the other.
+func (s *SQLiteStore) Update(ctx context.Context, key string, fn func(entry *Entry)) (Entry, error) {
+	var entry Entry
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where("expires_at <= ?", now).Delete(&models.LockoutDBModel{}).Error; err != nil {
+			slog.Error("Failed to remove expired lockout entries", slog.String("error", err.Error()))
+			return err
+		}
+
+		var row models.LockoutDBModel
+		err := tx.Where("key = ?", key).First(&row).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Error("Failed to retrieve lockout entry", slog.String("error", err.Error()))
+			return err
+		}
+		if err == nil {
+			entry = Entry{Failures: row.Failures, ExpiresAt: row.ExpiresAt}
+			if row.LockedUntil != nil {
+				entry.LockedUntil = *row.LockedUntil
+			}
+		}
+
+		fn(&entry)
+
+		row = models.LockoutDBModel{Key: key, Failures: entry.Failures, ExpiresAt: entry.ExpiresAt}
+		if !entry.LockedUntil.IsZero() {
+			row.LockedUntil = &entry.LockedUntil
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+			slog.Error("Failed to store lockout entry", slog.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
+
+	return entry, err
+}
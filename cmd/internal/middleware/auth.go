@@ -1,20 +1,82 @@
 package middleware
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper"
-	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/lockout"
+	"anon-confessions/cmd/internal/modules/user"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// Authentication is a middleware function that authenticates a user based on the account number provided in the request header.
-// It checks if the account number exists in the database and sets the user information in the context if authenticated.
-func Authentication(db *gorm.DB) gin.HandlerFunc {
+// Authentication is a middleware function that authenticates a user based on the credentials provided in the request headers.
+// Bots and integrations authenticate with an API key in X-API-Key, which limits the request to the scopes of the key.
+// Otherwise a session access token sent as `Authorization: Bearer <token>` is preferred. While the legacy header is enabled,
+// the raw account number, or its recovery phrase, in X-Account-Number is accepted as well. The user information is set in the context if authenticated.
+// Clients that keep sending wrong account numbers are locked out with 429 Too Many Requests.
+func Authentication(userService *user.UserService, cfg config.AuthConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slog.Info("Starting authentication process...")
+		ctx := c.Request.Context()
+
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			apiKey, err := userService.AuthenticateAPIKey(ctx, key)
+			if errors.Is(err, user.ErrInvalidAPIKey) {
+				slog.Warn("Authentication failed: Invalid API key.")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked API key"})
+				return
+			}
+			if err != nil {
+				slog.Warn("Authentication failed: Database error", slog.String("error", err.Error()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+
+			slog.Info("API key authenticated successfully.")
+			c.Set("userID", apiKey.UserId)
+			c.Set("apiKeyScopes", apiKey.Scopes)
+			c.Next()
+			return
+		}
+
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			token, found := strings.CutPrefix(authHeader, "Bearer ")
+			if !found || token == "" {
+				slog.Warn("Authentication failed: Malformed authorization header.")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Malformed authorization header"})
+				return
+			}
+
+			claims, err := userService.AuthenticateAccessToken(ctx, token)
+			if errors.Is(err, user.ErrInvalidAccessToken) {
+				slog.Warn("Authentication failed: Invalid access token.")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired access token"})
+				return
+			}
+			if err != nil {
+				slog.Warn("Authentication failed: Database error", slog.String("error", err.Error()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+
+			slog.Info("User authenticated successfully.")
+			c.Set("userID", claims.UserID)
+			c.Set("sessionID", claims.SessionID)
+			c.Next()
+			return
+		}
+
+		if !cfg.LegacyAccountHeader {
+			slog.Warn("Authentication failed: Access token missing.")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing access token"})
+			return
+		}
 
 		accNum := c.GetHeader("X-Account-Number")
 		if accNum == "" {
@@ -23,27 +85,29 @@ func Authentication(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		var users []models.Users
-		if err := db.Find(&users).Error; err != nil {
-			slog.Warn("Authentication failed: Database error", slog.String("error", err.Error()))
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		authenticatedUser, err := userService.AuthenticateClient(ctx, accNum, c.ClientIP())
+		var lockedErr *lockout.LockedError
+		if errors.As(err, &lockedErr) {
+			slog.Warn("Authentication failed: Too many failed attempts.")
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed attempts"})
 			return
 		}
-
-		var authenticatedUser *models.Users
-		for _, user := range users {
-			err := helper.CompareHashAndPassword([]byte(user.AccountNumber), []byte(accNum))
-			if err == nil {
-				authenticatedUser = &user
-				break
-			}
-		}
-
-		if authenticatedUser == nil {
+		if errors.Is(err, user.ErrInvalidAccountNumber) {
 			slog.Warn("Authentication failed: Invalid account number.")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid account number"})
 			return
 		}
+		if errors.Is(err, helper.ErrInvalidRecoveryPhrase) {
+			slog.Warn("Authentication failed: Invalid recovery phrase.")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			slog.Warn("Authentication failed: Database error", slog.String("error", err.Error()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
 
 		slog.Info("User authenticated successfully.")
 		c.Set("userID", authenticatedUser.ID)
@@ -1,15 +1,22 @@
 package middleware
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/lockout"
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/user"
+	"anon-confessions/cmd/internal/websocket"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -18,15 +25,28 @@ func TestAuthMiddleware(t *testing.T) {
 	// Set up the mock database
 	db := testutils.SetupMockDB()
 
-	// Insert mock users into the database
+	// Insert mock users into the database.
+	// User 2 predates lookup fingerprints and argon2id, and has to be matched through the legacy path.
+	cfg := config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret", LegacyAccountHeader: true}
+	lookupHash := helper.AccountLookupHash(cfg.AccountPepper, "3998442793406687")
+	hashedAccountNumber, err := helper.NewHasher(config.HashingConfig{}).Hash("3998442793406687")
+	if err != nil {
+		t.Fatalf("Failed to hash account number: %v", err)
+	}
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("1234567891234567"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash account number: %v", err)
+	}
 	mockUsers := []models.Users{
-		{ID: 1, AccountNumber: helper.HashAccountNumber("3998442793406687")},
-		{ID: 2, AccountNumber: helper.HashAccountNumber("1234567891234567")},
+		{ID: 1, AccountNumber: hashedAccountNumber, LookupHash: &lookupHash},
+		{ID: 2, AccountNumber: string(legacyHash)},
 	}
 	for _, user := range mockUsers {
 		db.Create(&user)
 	}
 
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{}), &config.Config{Auth: cfg}, websocket.NewHub())
+
 	// Define test cases
 	tests := []struct {
 		name           string
@@ -40,6 +60,12 @@ func TestAuthMiddleware(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedUserID: 1,
 		},
+		{
+			name:           "Valid legacy account number",
+			accountNumber:  "1234567891234567",
+			expectedStatus: http.StatusOK,
+			expectedUserID: 2,
+		},
 		{
 			name:           "Invalid account number",
 			accountNumber:  "1234567891234566",
@@ -58,7 +84,7 @@ func TestAuthMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			router := gin.New()
-			router.Use(Authentication(db))
+			router.Use(Authentication(userService, cfg))
 			router.GET("/test", func(c *gin.Context) {
 				userID, exists := c.Get("userID")
 				if exists {
@@ -93,4 +119,181 @@ func TestAuthMiddleware(t *testing.T) {
 			}
 		})
 	}
+
+	// The legacy user should have received a lookup fingerprint on the successful login.
+	var legacyUser models.Users
+	if err := db.First(&legacyUser, 2).Error; err != nil {
+		t.Fatalf("Failed to load legacy user: %v", err)
+	}
+	if legacyUser.LookupHash == nil || *legacyUser.LookupHash != helper.AccountLookupHash(cfg.AccountPepper, "1234567891234567") {
+		t.Errorf("Expected lookup hash to be backfilled for the legacy user")
+	}
+
+	// Its bcrypt hash should have been upgraded to argon2id as well.
+	if !strings.HasPrefix(legacyUser.AccountNumber, "$argon2id$") {
+		t.Errorf("Expected the legacy bcrypt hash to be replaced with an argon2id hash")
+	}
+}
+
+func TestAuthMiddlewareBearerToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	cfg := config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret", LegacyAccountHeader: false}
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{}), &config.Config{Auth: cfg}, websocket.NewHub())
+
+	// Insert an active and a revoked session for user 1
+	revokedAt := time.Now()
+	sessions := []models.SessionDBModel{
+		{ID: 1, UserId: 1, RefreshTokenHash: "active", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: 2, UserId: 1, RefreshTokenHash: "revoked", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+	}
+	for _, session := range sessions {
+		db.Create(&session)
+	}
+
+	signToken := func(secret string, sessionID int, ttl time.Duration) string {
+		token, err := helper.SignAccessToken(secret, helper.AccessTokenClaims{
+			UserID:    1,
+			SessionID: sessionID,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to sign access token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "Valid access token",
+			headers:        map[string]string{"Authorization": "Bearer " + signToken(cfg.TokenSecret, 1, time.Minute)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Expired access token",
+			headers:        map[string]string{"Authorization": "Bearer " + signToken(cfg.TokenSecret, 1, -time.Minute)},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Access token signed with another secret",
+			headers:        map[string]string{"Authorization": "Bearer " + signToken("other-secret", 1, time.Minute)},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Access token of a revoked session",
+			headers:        map[string]string{"Authorization": "Bearer " + signToken(cfg.TokenSecret, 2, time.Minute)},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Legacy header while disabled",
+			headers:        map[string]string{"X-Account-Number": "3998442793406687"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Authentication(userService, cfg))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"sessionID": helper.RetrieveSessionId(c)})
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	cfg := config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret", LegacyAccountHeader: true}
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour})
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), guard, &config.Config{Auth: cfg}, websocket.NewHub())
+
+	router := gin.New()
+	router.Use(Authentication(userService, cfg))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": helper.RetrieveLoggedInUserId(c)})
+	})
+
+	expectedStatuses := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, expectedStatus := range expectedStatuses {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Account-Number", "0000000000000000")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != expectedStatus {
+			t.Fatalf("Attempt %d: expected status %d, got %d", i+1, expectedStatus, w.Code)
+		}
+		if expectedStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("Expected Retry-After of 60 seconds, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestAuthMiddlewarePrefixLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	cfg := config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret", LegacyAccountHeader: true}
+	lookupHash := helper.AccountLookupHash(cfg.AccountPepper, "5550001112223334")
+	hashedAccountNumber, err := helper.NewHasher(config.HashingConfig{}).Hash("5550001112223334")
+	if err != nil {
+		t.Fatalf("Failed to hash account number: %v", err)
+	}
+	owner := models.Users{AccountNumber: hashedAccountNumber, LookupHash: &lookupHash}
+	db.Create(&owner)
+
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{
+		MaxFailures: 5, PrefixLength: 4, PrefixMaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour,
+	})
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), guard, &config.Config{Auth: cfg}, websocket.NewHub())
+
+	router := gin.New()
+	router.Use(Authentication(userService, cfg))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": helper.RetrieveLoggedInUserId(c)})
+	})
+
+	// Wrong guesses from different clients lock the prefix, then the owner and another guesser come along
+	attempts := []struct {
+		remoteAddr     string
+		accountNumber  string
+		expectedStatus int
+	}{
+		{"198.51.100.1:1234", "5550000000000001", http.StatusUnauthorized},
+		{"198.51.100.2:1234", "5550000000000002", http.StatusUnauthorized},
+		{"203.0.113.1:1234", "5550001112223334", http.StatusOK},
+		{"198.51.100.3:1234", "5550000000000003", http.StatusTooManyRequests},
+		{"198.51.100.3:1234", "5550001112223334", http.StatusTooManyRequests},
+	}
+	for i, attempt := range attempts {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = attempt.remoteAddr
+		req.Header.Set("X-Account-Number", attempt.accountNumber)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != attempt.expectedStatus {
+			t.Fatalf("Attempt %d: expected status %d, got %d", i+1, attempt.expectedStatus, w.Code)
+		}
+	}
 }
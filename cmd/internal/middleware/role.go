@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/modules/user"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole is a middleware function that only lets users with one of the given roles through.
+// It has to run after Authentication, since it looks up the role of the authenticated user.
+// The role is set in the context for the handlers further down the chain.
+func RequireRole(userService *user.UserService, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := helper.RetrieveLoggedInUserId(c)
+		if c.IsAborted() {
+			return
+		}
+
+		role, err := userService.GetUserRole(c.Request.Context(), userId)
+		if err != nil {
+			slog.Warn("Authorization failed: Database error", slog.String("error", err.Error()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		if !slices.Contains(roles, role) {
+			slog.Warn("Authorization failed: Insufficient role.", slog.Int("userId", userId), slog.String("role", role))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+
+		c.Set("role", role)
+		c.Next()
+	}
+}
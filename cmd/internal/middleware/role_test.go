@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/lockout"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/user"
+	"anon-confessions/cmd/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRoleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{}), &config.Config{}, websocket.NewHub())
+
+	// Insert one user per role
+	mockUsers := []models.Users{
+		{ID: 10, AccountNumber: "role-test-user"},
+		{ID: 11, AccountNumber: "role-test-moderator", Role: models.RoleModerator},
+		{ID: 12, AccountNumber: "role-test-admin", Role: models.RoleAdmin},
+	}
+	for _, user := range mockUsers {
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{
+			name:           "Regular user",
+			userID:         10,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Moderator",
+			userID:         11,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Admin",
+			userID:         12,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Unknown user",
+			userID:         999,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Next()
+			})
+			router.Use(RequireRole(userService, models.RoleModerator, models.RoleAdmin))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"role": c.GetString("role")})
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	// New users should get the regular role by default.
+	var regularUser models.Users
+	if err := db.First(&regularUser, 10).Error; err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	if regularUser.Role != models.RoleUser {
+		t.Errorf("Expected default role %q, got %q", models.RoleUser, regularUser.Role)
+	}
+}
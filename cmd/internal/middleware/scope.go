@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope is a middleware function that only lets requests authenticated with an API key through
+// when the key was granted the given scope. Requests authenticated with a session or an account number
+// have full access and always pass. It has to run after Authentication.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isAPIKey := helper.RetrieveAPIKeyScopes(c)
+		if isAPIKey && !slices.Contains(scopes, scope) {
+			slog.Warn("Authorization failed: API key lacks scope.", slog.String("scope", scope))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireFullAccess is a middleware function that rejects requests authenticated with an API key.
+// It guards the routes that manage the account itself, which no scope grants access to.
+func RequireFullAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := helper.RetrieveAPIKeyScopes(c); isAPIKey {
+			slog.Warn("Authorization failed: API keys cannot manage the account.")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys cannot access this route"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/lockout"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/user"
+	"anon-confessions/cmd/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	cfg := config.AuthConfig{AccountPepper: "test-pepper", TokenSecret: "test-secret", LegacyAccountHeader: false}
+	userService := user.NewUserService(user.NewSQLiteUserRepository(db), lockout.NewGuard(lockout.NewMemoryStore(), config.LockoutConfig{}), &config.Config{Auth: cfg}, websocket.NewHub())
+
+	// Insert a read-only key, plus a revoked and an expired key with every scope
+	past := time.Now().Add(-time.Hour)
+	allScopes := []string{models.ScopePostsRead, models.ScopePostsWrite}
+	apiKeys := []models.APIKeyDBModel{
+		{UserId: 1, Name: "reader", Prefix: "ack_read", KeyHash: helper.HashToken("ack_read-only"), Scopes: []string{models.ScopePostsRead}},
+		{UserId: 1, Name: "revoked", Prefix: "ack_revo", KeyHash: helper.HashToken("ack_revoked"), Scopes: allScopes, RevokedAt: &past},
+		{UserId: 1, Name: "expired", Prefix: "ack_expi", KeyHash: helper.HashToken("ack_expired"), Scopes: allScopes, ExpiresAt: &past},
+	}
+	for _, apiKey := range apiKeys {
+		if err := db.Create(&apiKey).Error; err != nil {
+			t.Fatalf("Failed to create API key: %v", err)
+		}
+	}
+
+	session := models.SessionDBModel{UserId: 1, RefreshTokenHash: "scope-test-session", ExpiresAt: time.Now().Add(time.Hour)}
+	db.Create(&session)
+	accessToken, err := helper.SignAccessToken(cfg.TokenSecret, helper.AccessTokenClaims{
+		UserID:    1,
+		SessionID: session.ID,
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to sign access token: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "Key with the scope",
+			method:         http.MethodGet,
+			path:           "/posts",
+			headers:        map[string]string{"X-API-Key": "ack_read-only"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Key without the scope",
+			method:         http.MethodPost,
+			path:           "/posts",
+			headers:        map[string]string{"X-API-Key": "ack_read-only"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Key on an account route",
+			method:         http.MethodGet,
+			path:           "/account",
+			headers:        map[string]string{"X-API-Key": "ack_read-only"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Revoked key",
+			method:         http.MethodGet,
+			path:           "/posts",
+			headers:        map[string]string{"X-API-Key": "ack_revoked"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Expired key",
+			method:         http.MethodGet,
+			path:           "/posts",
+			headers:        map[string]string{"X-API-Key": "ack_expired"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unknown key",
+			method:         http.MethodGet,
+			path:           "/posts",
+			headers:        map[string]string{"X-API-Key": "ack_unknown"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Session has full access",
+			method:         http.MethodPost,
+			path:           "/posts",
+			headers:        map[string]string{"Authorization": "Bearer " + accessToken},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Session on an account route",
+			method:         http.MethodGet,
+			path:           "/account",
+			headers:        map[string]string{"Authorization": "Bearer " + accessToken},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok := func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"userID": helper.RetrieveLoggedInUserId(c)})
+			}
+
+			router := gin.New()
+			router.Use(Authentication(userService, cfg))
+			router.GET("/posts", RequireScope(models.ScopePostsRead), ok)
+			router.POST("/posts", RequireScope(models.ScopePostsWrite), ok)
+			router.GET("/account", RequireFullAccess(), ok)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Scopes that can be granted to an API key. Requests authenticated with an API key
+// can only reach the routes covered by its scopes.
+const (
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+	ScopeLikesWrite    = "likes:write"
+	ScopeModerate      = "moderate"
+)
+
+// APIKeyPrefix is prepended to every API key so leaked keys are easy to recognise.
+const APIKeyPrefix = "ack_"
+
+// APIKeyDBModel is used by GORM to represent an API key in the database.
+// Only a hash of the key is stored, never the key itself.
+type APIKeyDBModel struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId    int        `json:"user_id" gorm:"not null"`
+	Name      string     `json:"name" gorm:"not null"`
+	Prefix    string     `json:"prefix" gorm:"not null"`
+	KeyHash   string     `json:"key_hash" gorm:"not null;unique"`
+	Scopes    []string   `json:"scopes" gorm:"serializer:json;not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// CreateAPIKeyRequest is used to mint a new API key. Keys without an expiry stay valid until they are revoked.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=64"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=posts:read posts:write comments:read comments:write likes:write moderate"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKey is the public view of an API key used when listing the keys of a user.
+// Prefix holds the first characters of the key to tell keys apart.
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// CreateAPIKeyResponse is returned once when an API key is created. The key cannot be retrieved again.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// TableName overrides the default table name for GORM for APIKeyDBModel.
+func (APIKeyDBModel) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// DefaultBoardID and DefaultBoardSlug identify the board posts go to when none is picked.
+// It is created by the migrations and cannot be deleted.
+const (
+	DefaultBoardID   = 1
+	DefaultBoardSlug = "general"
+)
+
+// BoardDBModel is used by GORM to represent a board in the database.
+type BoardDBModel struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Slug        string    `json:"slug" gorm:"not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"not null"`
+	Rules       []string  `json:"rules" gorm:"serializer:json;not null"`
+	CreatedBy   *int      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// CreateBoardRequest is used by admins to create a board.
+// Slugs are lowercase letters, digits and single dashes, such as campus-x, and cannot be changed later.
+type CreateBoardRequest struct {
+	Slug        string   `json:"slug" binding:"required,min=2,max=50"`
+	Description string   `json:"description" binding:"max=500"`
+	Rules       []string `json:"rules" binding:"max=20,dive,min=1,max=200"`
+}
+
+// UpdateBoardRequest replaces the description and rules of a board.
+type UpdateBoardRequest struct {
+	Description string   `json:"description" binding:"max=500"`
+	Rules       []string `json:"rules" binding:"max=20,dive,min=1,max=200"`
+}
+
+// Board is the public view of a board.
+type Board struct {
+	ID          int       `json:"id"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Rules       []string  `json:"rules"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// TableName overrides the default table name for GORM for BoardDBModel.
+func (BoardDBModel) TableName() string {
+	return "boards"
+}
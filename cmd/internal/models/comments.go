@@ -3,12 +3,15 @@ package models
 import "time"
 
 // CommentsDbModel is used by GORM to represent a comment in the database.
+// DeletedAt marks a comment deleted by its author; it is hidden everywhere until it is restored or purged.
 type CommentsDbModel struct {
-	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UserId    int       `json:"user_id" gorm:"not null"`
-	PostId    int       `json:"post_id" gorm:"not null"`
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Content   string     `json:"content" gorm:"type:text;not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UserId    int        `json:"user_id" gorm:"not null"`
+	PostId    int        `json:"post_id" gorm:"not null"`
+	EditedAt  *time.Time `json:"edited_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
 }
 
 // CreateCommentRequest is used to validate incoming requests for creating a comment.
@@ -24,12 +27,19 @@ type Comments struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
-// Comment is used for single comment responses
+// Comment is used for single comment responses.
+// The author is only exposed through a per-post pseudonym and the IsOP flag, never by user ID.
+// Edited is selected as edited_at IS NOT NULL, EditedAt is the time of the latest edit.
 type Comment struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	Content   string    `json:"content"`
-	PostID    int       `json:"postId" gorm:"column:post_id;not null"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        int        `json:"id" gorm:"primaryKey"`
+	Content   string     `json:"content"`
+	PostID    int        `json:"postId" gorm:"column:post_id;not null"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UserID    int        `json:"-" gorm:"column:user_id"`
+	Author    string     `json:"author" gorm:"-"`
+	IsOP      bool       `json:"isOP" gorm:"-"`
+	Edited    bool       `json:"edited"`
+	EditedAt  *time.Time `json:"editedAt"`
 }
 
 type GetCommentsCollection []Comment
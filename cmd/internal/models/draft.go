@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// DraftDBModel is used by GORM to represent a draft in the database.
+// Board is the slug of the board the draft is published to. ExpiresAt is pushed back on every update.
+type DraftDBModel struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId    int       `json:"user_id" gorm:"not null"`
+	Content   string    `json:"content" gorm:"type:text;not null"`
+	Board     string    `json:"board" gorm:"not null;default:general"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+}
+
+// DraftRequest is used for creating or replacing a draft.
+// Board is the slug of the board the draft will be published to, the general board when left out.
+type DraftRequest struct {
+	Content string `json:"content" binding:"required,min=2"`
+	Board   string `json:"board,omitempty" binding:"omitempty,max=50"`
+}
+
+// Draft is the view of a draft returned to its author.
+type Draft struct {
+	ID        int       `json:"id"`
+	Content   string    `json:"content"`
+	Board     string    `json:"board"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// TableName overrides the default table name for GORM for DraftDBModel.
+func (DraftDBModel) TableName() string {
+	return "drafts"
+}
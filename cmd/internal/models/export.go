@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// AccountExport is the archive of everything tied to an account, returned by the personal data export.
+// It never contains identifiers of other users: comments by others on the exported posts carry no user ID.
+type AccountExport struct {
+	ExportedAt time.Time       `json:"exportedAt"`
+	Account    ExportAccount   `json:"account"`
+	Posts      []ExportPost    `json:"posts"`
+	Comments   []ExportComment `json:"comments"`
+	Likes      []ExportLike    `json:"likes"`
+	Drafts     []ExportDraft   `json:"drafts"`
+}
+
+// ExportAccount holds the account metadata included in an export.
+type ExportAccount struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ExportPost is a post written by the exporting user, with every comment on it.
+type ExportPost struct {
+	ID         int                 `json:"id"`
+	Content    string              `json:"content"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	TotalLikes int                 `json:"totalLikes"`
+	Comments   []ExportPostComment `json:"comments" gorm:"-"`
+}
+
+// ExportPostComment is a comment on one of the exported posts. Own tells whether the exporting user wrote it.
+type ExportPostComment struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"-"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+	Own       bool      `json:"own"`
+}
+
+// ExportComment is a comment written by the exporting user.
+type ExportComment struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"postId"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ExportLike is a like given by the exporting user. LikedAt is empty for likes given before likes were timestamped.
+type ExportLike struct {
+	PostID  int        `json:"postId"`
+	LikedAt *time.Time `json:"likedAt"`
+}
+
+// ExportDraft is a draft the exporting user has not published yet.
+type ExportDraft struct {
+	ID        int       `json:"id"`
+	Content   string    `json:"content"`
+	Board     string    `json:"board"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExportQueryParams defines query parameters for the personal data export.
+type ExportQueryParams struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"`
+}
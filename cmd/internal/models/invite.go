@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// InviteDBModel is used by GORM to represent an invite code in the database.
+// Only a hash of the code is stored, never the code itself.
+type InviteDBModel struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatorId *int       `json:"creator_id"`
+	CodeHash  string     `json:"code_hash" gorm:"not null;unique"`
+	MaxUses   int        `json:"max_uses" gorm:"not null"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// CreateInviteRequest is used to generate an invite code. Codes without an expiry stay valid until they are used up or revoked.
+type CreateInviteRequest struct {
+	MaxUses   int        `json:"maxUses" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Invite is the public view of an invite code used when listing the invites of a user.
+type Invite struct {
+	ID        int        `json:"id"`
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Revoked   bool       `json:"revoked"`
+}
+
+// CreateInviteResponse is returned once when an invite is created. The code cannot be retrieved again.
+type CreateInviteResponse struct {
+	Invite
+	Code string `json:"code"`
+}
+
+// InviteEdge links an account to the invite it was created with and the account that created that invite.
+// Accounts created without an invite have neither.
+type InviteEdge struct {
+	UserId    int
+	InviteId  *int
+	CreatorId *int
+	CreatedAt time.Time
+}
+
+// InvitedAccount is a node of an invite tree. It only carries internal IDs, never account numbers.
+type InvitedAccount struct {
+	UserID    int              `json:"userId"`
+	InviteID  *int             `json:"inviteId"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Invited   []InvitedAccount `json:"invited"`
+}
+
+// InviteTrace shows where an account came from and whom it brought in.
+// InvitedBy lists the accounts whose invites led to it, starting at the root. Account holds the accounts created
+// with its invites, recursively.
+type InviteTrace struct {
+	InvitedBy []InvitedAccount `json:"invitedBy"`
+	Account   InvitedAccount   `json:"account"`
+}
+
+// TableName overrides the default table name for GORM for InviteDBModel.
+func (InviteDBModel) TableName() string {
+	return "invites"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LockoutDBModel is used by GORM to represent the failed authentication attempts tracked under one key,
+// such as a client IP or an account number prefix.
+type LockoutDBModel struct {
+	Key         string     `json:"key" gorm:"column:key;primaryKey"`
+	Failures    int        `json:"failures" gorm:"not null"`
+	LockedUntil *time.Time `json:"locked_until"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+}
+
+// TableName overrides the default table name for GORM for LockoutDBModel.
+func (LockoutDBModel) TableName() string {
+	return "auth_lockouts"
+}
@@ -3,50 +3,197 @@ package models
 import "time"
 
 // PostDBModel is used by GORM to represent a post in the database.
+// HotScore and TrendingScore rank the post in the hot and trending feeds and are refreshed by a background job.
+// DeletedAt marks a post deleted by its author; it is hidden everywhere until it is restored or purged.
+// ExpiresAt is set for ephemeral posts, which are hidden once it has passed and then removed by the reaper.
+// PublishAt is set while a scheduled post waits to be published. Publishing clears it and moves CreatedAt to that time.
+// CreatedAt and PublishAt are written in UTC, since the feed compares creation times as text.
 type PostDBModel struct {
-	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Content    string    `json:"content" gorm:"type:text;not null"`
-	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UserId     int       `json:"user_id" gorm:"not null"`
-	TotalLikes int       `json:"total_likes" gorm:"default:0"`
+	ID            int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Content       string     `json:"content" gorm:"type:text;not null"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UserId        int        `json:"user_id" gorm:"not null"`
+	TotalLikes    int        `json:"total_likes" gorm:"default:0"`
+	BoardId       int        `json:"board_id" gorm:"not null;default:1"`
+	HotScore      float64    `json:"hot_score" gorm:"not null;default:0"`
+	TrendingScore float64    `json:"trending_score" gorm:"not null;default:0"`
+	EditedAt      *time.Time `json:"edited_at"`
+	DeletedAt     *time.Time `json:"deleted_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	PublishAt     *time.Time `json:"publish_at"`
 }
 
 // GetPostWithComments represents a post along with its associated comments.
 // Used in API responses to fetch posts and their related comments.
+// Edited is computed from EditedAt by the query, see GetPost. Anonymized tells the post belongs to the tombstone user.
 type GetPostWithComments struct {
-	ID         int       `json:"id" gorm:"primaryKey"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"createdAt"`
-	TotalLikes int       `json:"totalLikes"`
-	UserId     int       `json:"userId"`
-	Comments   []Comment `json:"comments" gorm:"foreignKey:PostID;references:ID"`
+	ID         int        `json:"id" gorm:"primaryKey"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	TotalLikes int        `json:"totalLikes"`
+	UserId     int        `json:"userId"`
+	BoardId    int        `json:"boardId"`
+	Edited     bool       `json:"edited"`
+	EditedAt   *time.Time `json:"editedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	Anonymized bool       `json:"-"`
+	Comments   []Comment  `json:"comments" gorm:"foreignKey:PostID;references:ID"`
 }
 
 // PostRequest is used for creating or updating a post.
 // This is validated in POST or PATCH requests to ensure valid content.
+// Board is the slug of the board a new post goes to, the general board when left out. Posts cannot change boards.
+// ExpiresIn makes a new post disappear after that many seconds, up to the configured maximum.
+// PublishAt schedules a new post, up to the configured maximum ahead, keeping it hidden until then; its lifetime starts
+// when it is published.
+// Both are ignored on updates.
 type PostRequest struct {
-	Content string `json:"content" binding:"required,min=2"`
+	Content   string     `json:"content" binding:"required,min=2"`
+	Board     string     `json:"board,omitempty" binding:"omitempty,max=50"`
+	ExpiresIn int        `json:"expiresIn,omitempty" binding:"omitempty,min=60"`
+	PublishAt *time.Time `json:"publishAt,omitempty"`
 }
 
 // GetPost represents a minimal view of a post with metadata and user interaction details.
+// Edited is selected as edited_at IS NOT NULL, EditedAt is the time of the latest edit.
+// The scores are only loaded to build cursors for the hot and trending feeds.
 type GetPost struct {
-	ID         int       `json:"id"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"createdAt"`
-	TotalLikes int       `json:"totalLikes"`
-	IsLiked    int       `json:"isLiked"`
+	ID            int        `json:"id"`
+	Content       string     `json:"content"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	TotalLikes    int        `json:"totalLikes"`
+	IsLiked       int        `json:"isLiked"`
+	BoardId       int        `json:"boardId"`
+	Edited        bool       `json:"edited"`
+	EditedAt      *time.Time `json:"editedAt"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	HotScore      float64    `json:"-"`
+	TrendingScore float64    `json:"-"`
 }
 
 // GetPostsCollection is a slice of GetPost, used for paginated responses or post collections.
 type GetPostsCollection []GetPost
 
+// States of a post in the listing of its author.
+const (
+	PostStatePublished = "published"
+	PostStateScheduled = "scheduled"
+)
+
+// OwnPost is a post in the listing of its author, which also holds the posts that are not published yet.
+// PublishAt is when a scheduled post will be published.
+type OwnPost struct {
+	ID         int        `json:"id"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	TotalLikes int        `json:"totalLikes"`
+	BoardId    int        `json:"boardId"`
+	Edited     bool       `json:"edited"`
+	EditedAt   *time.Time `json:"editedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	PublishAt  *time.Time `json:"publishAt"`
+	State      string     `json:"state"`
+}
+
+// OwnPostsQueryParams defines the pagination of the listing of the own posts.
+type OwnPostsQueryParams struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// BoardContent is a post or comment with the slug of the board it belongs to,
+// so websocket events about it also reach the clients following that board.
+type BoardContent struct {
+	ID    int
+	Board string
+}
+
+// Rankings of the posts feed besides the creation date and likes, sorted by scores refreshed in the background.
+const (
+	PostSortHot      = "hot"
+	PostSortTrending = "trending"
+)
+
+// PostRanking holds the freshly computed scores of a post.
+type PostRanking struct {
+	PostId        int
+	HotScore      float64
+	TrendingScore float64
+}
+
+// RankingCandidate is a post recent enough for the hot feed, with the reactions it has received.
+type RankingCandidate struct {
+	ID         int
+	CreatedAt  time.Time
+	TotalLikes int
+	Comments   int
+}
+
+// PostReaction is a like or comment given to a post at some point in time.
+type PostReaction struct {
+	PostId    int
+	CreatedAt time.Time
+}
+
+// PostCursor is a position in the posts feed: the sort order it was taken in and the sort key and ID of a post.
+// Backward cursors lead to the posts before that post rather than after it.
+type PostCursor struct {
+	SortBy    string    `json:"s"`
+	Order     string    `json:"o"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	Likes     int       `json:"l,omitempty"`
+	Score     float64   `json:"sc,omitempty"`
+	ID        int       `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// CursorPagination holds the opaque cursors of the neighbouring pages, null at either end of the feed.
+type CursorPagination struct {
+	NextCursor *string `json:"nextCursor"`
+	PrevCursor *string `json:"prevCursor"`
+}
+
+// PostsPage is a page of the posts feed returned when paginating with cursors.
+type PostsPage struct {
+	Posts      GetPostsCollection `json:"posts"`
+	Pagination CursorPagination   `json:"pagination"`
+}
+
+// SearchPostsQueryParams defines query parameters for searching posts.
+type SearchPostsQueryParams struct {
+	Query string `form:"q" binding:"required,max=200"`
+	Page  int    `form:"page" binding:"omitempty,min=1"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SearchPost is a post matching a search query. Snippet is an HTML-escaped excerpt of the content with the matching
+// words wrapped in <mark> tags. Higher scores are better matches.
+type SearchPost struct {
+	GetPost
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// SearchPostsCollection is a page of search results, best matches first.
+type SearchPostsCollection []SearchPost
+
 // PostQueryParams defines query parameters for fetching posts.
 // Includes pagination, sorting, and filtering options.
+// Tags filters by hashtags, with TagMatch deciding whether posts need any (the default) or all of them.
+// Board filters by the slug of a board; BoardId is the board it resolved to.
+// Cursor switches to keyset pagination, see PostsPage. It is empty for the first page and replaces Page and the sort order.
+// Sort picks the hot or trending ranking, always best first, over SortByCreationDate and SortByLikes.
 type PostQueryParams struct {
-	Page               int    `form:"page" binding:"omitempty,min=1"`
-	Limit              int    `form:"limit" binding:"omitempty,min=1"`
-	SortByCreationDate string `form:"creation_date" binding:"omitempty,oneof=asc desc"`
-	SortByLikes        string `form:"sort_by_likes" binding:"omitempty,oneof=asc desc"`
+	Page               int      `form:"page" binding:"omitempty,min=1"`
+	Limit              int      `form:"limit" binding:"omitempty,min=1"`
+	SortByCreationDate string   `form:"creation_date" binding:"omitempty,oneof=asc desc"`
+	SortByLikes        string   `form:"sort_by_likes" binding:"omitempty,oneof=asc desc"`
+	Sort               string   `form:"sort" binding:"omitempty,oneof=hot trending"`
+	Tags               []string `form:"tag" binding:"omitempty,max=10,dive,min=1,max=51"`
+	TagMatch           string   `form:"tag_match" binding:"omitempty,oneof=any all"`
+	Board              string   `form:"board" binding:"omitempty,max=50"`
+	BoardId            int      `form:"-"`
+	Cursor             string   `form:"cursor" binding:"omitempty,max=512"`
 }
 
 // UpdateLikesRequest is used for updating likes on a post.
@@ -57,8 +204,9 @@ type UpdateLikesRequest struct {
 // PostsLikesDBModel represents a many-to-many relationship between users and liked posts.
 // Used by GORM for likes functionality.
 type PostsLikesDBModel struct {
-	PostId int `json:"post_id"`
-	UserId int `json:"user_id"`
+	PostId    int        `json:"post_id"`
+	UserId    int        `json:"user_id"`
+	CreatedAt *time.Time `json:"created_at"`
 }
 
 // TableName overrides the default table name for GORM for various models.
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Sort fields a user can pick as the default order of the posts feed.
+const (
+	PreferenceSortByCreationDate = "creation_date"
+	PreferenceSortByLikes        = "likes"
+)
+
+// UserPreferencesDBModel is used by GORM to represent the stored preferences of a user.
+type UserPreferencesDBModel struct {
+	UserId     int       `json:"user_id" gorm:"primaryKey"`
+	SortBy     string    `json:"sort_by" gorm:"not null"`
+	SortOrder  string    `json:"sort_order" gorm:"not null"`
+	PageSize   int       `json:"page_size" gorm:"not null"`
+	MutedWords []string  `json:"muted_words" gorm:"serializer:json;not null"`
+	ShowNSFW   bool      `json:"show_nsfw" gorm:"column:show_nsfw;not null"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// UserPreferences holds the settings that follow a user between devices.
+// SortBy, SortOrder and PageSize are applied to the posts feed when a request leaves them out.
+// MutedWords and ShowNSFW are stored for clients to apply.
+type UserPreferences struct {
+	SortBy     string   `json:"sortBy" binding:"required,oneof=creation_date likes"`
+	SortOrder  string   `json:"sortOrder" binding:"required,oneof=asc desc"`
+	PageSize   int      `json:"pageSize" binding:"required,min=1,max=100"`
+	MutedWords []string `json:"mutedWords" binding:"max=100,dive,min=1,max=64"`
+	ShowNSFW   bool     `json:"showNsfw"`
+}
+
+// DefaultUserPreferences returns the preferences of users who never stored any.
+// They match the defaults the posts feed applies on its own.
+func DefaultUserPreferences() UserPreferences {
+	return UserPreferences{
+		SortBy:     PreferenceSortByCreationDate,
+		SortOrder:  "asc",
+		PageSize:   10,
+		MutedWords: []string{},
+	}
+}
+
+// TableName overrides the default table name for GORM for UserPreferencesDBModel.
+func (UserPreferencesDBModel) TableName() string {
+	return "user_preferences"
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// PostRevisionDBModel is used by GORM to represent a previous version of a post.
+// CreatedAt is when the content was replaced, ByModerator whether a moderator replaced it.
+type PostRevisionDBModel struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PostId      int       `json:"post_id" gorm:"not null"`
+	Content     string    `json:"content" gorm:"type:text;not null"`
+	ByModerator bool      `json:"by_moderator" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// CommentRevisionDBModel is used by GORM to represent a previous version of a comment.
+type CommentRevisionDBModel struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	CommentId   int       `json:"comment_id" gorm:"not null"`
+	Content     string    `json:"content" gorm:"type:text;not null"`
+	ByModerator bool      `json:"by_moderator" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Revision is a previous version of a post or comment, newest first in responses.
+// ReplacedAt is when an edit replaced the content, ByModerator whether that edit was made by a moderator.
+type Revision struct {
+	ID          int       `json:"id"`
+	Content     string    `json:"content"`
+	ReplacedAt  time.Time `json:"replacedAt" gorm:"column:created_at"`
+	ByModerator bool      `json:"byModerator"`
+}
+
+// TableName overrides the default table name for GORM for the revision models.
+func (PostRevisionDBModel) TableName() string    { return "post_revisions" }
+func (CommentRevisionDBModel) TableName() string { return "comment_revisions" }
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SessionDBModel is used by GORM to represent a login session in the database.
+// Only a hash of the refresh token is stored, never the token itself.
+type SessionDBModel struct {
+	ID               int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId           int        `json:"user_id" gorm:"not null"`
+	RefreshTokenHash string     `json:"refresh_token_hash" gorm:"not null;unique"`
+	UserAgent        string     `json:"user_agent"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+}
+
+// CreateSessionRequest is used to exchange an account number, or its recovery phrase, for a session.
+type CreateSessionRequest struct {
+	AccountNumber string `json:"accountNumber" binding:"required"`
+}
+
+// RefreshSessionRequest is used to exchange a refresh token for a new pair of tokens.
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// SessionTokensResponse is returned when a session is created or refreshed.
+// ExpiresIn is the lifetime of the access token in seconds.
+type SessionTokensResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// Session is the public view of a session used when listing the sessions of a user.
+type Session struct {
+	ID         int        `json:"id"`
+	UserAgent  string     `json:"userAgent"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	Current    bool       `json:"current"`
+}
+
+// TableName overrides the default table name for GORM for SessionDBModel.
+func (SessionDBModel) TableName() string {
+	return "sessions"
+}
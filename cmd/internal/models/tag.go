@@ -0,0 +1,42 @@
+package models
+
+// TagDBModel is used by GORM to represent a hashtag in the database. Names are stored lowercase without the #.
+type TagDBModel struct {
+	ID   int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name string `json:"name" gorm:"not null;unique"`
+}
+
+// PostTagDBModel is used by GORM to link a post to one of its hashtags.
+type PostTagDBModel struct {
+	PostId int `json:"post_id" gorm:"primaryKey"`
+	TagId  int `json:"tag_id" gorm:"primaryKey"`
+}
+
+// Tag is a hashtag together with the number of posts using it.
+type Tag struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// TagQueryParams defines query parameters for listing tags. Prefix narrows the list down, e.g. for autocompletion.
+type TagQueryParams struct {
+	Prefix string `form:"prefix" binding:"omitempty,max=50"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// Tag match modes for filtering posts by several tags.
+// TagMatchAny returns posts carrying at least one of the tags, TagMatchAll only posts carrying every tag.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// TableName overrides the default table name for GORM for TagDBModel.
+func (TagDBModel) TableName() string {
+	return "tags"
+}
+
+// TableName overrides the default table name for GORM for PostTagDBModel.
+func (PostTagDBModel) TableName() string {
+	return "post_tags"
+}
@@ -6,10 +6,99 @@ import "time"
 type Users struct {
 	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
 	AccountNumber string    `json:"account_number" gorm:"type:varchar(255);not null;unique"`
+	LookupHash    *string   `json:"lookup_hash" gorm:"type:varchar(64);unique"`
+	Role          string    `json:"role" gorm:"type:text;not null;default:user"`
+	InviteId      *int      `json:"invite_id"`
 	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
+// User roles. Moderators can edit and delete any post or comment, admins can additionally manage the platform.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Trust levels, derived from the age and reception of an account.
+const (
+	TrustLevelNew     = "new"
+	TrustLevelMember  = "member"
+	TrustLevelTrusted = "trusted"
+)
+
+// UserStats holds the activity counters of an account. Likes received only count likes by other users.
+type UserStats struct {
+	Posts         int64 `json:"posts"`
+	Comments      int64 `json:"comments"`
+	LikesGiven    int64 `json:"likesGiven"`
+	LikesReceived int64 `json:"likesReceived"`
+}
+
+// UserProfile is the profile of the authenticated user.
+// It deliberately carries neither the account number nor its hash.
+type UserProfile struct {
+	CreatedAt      time.Time `json:"createdAt"`
+	AccountAgeDays int       `json:"accountAgeDays"`
+	Role           string    `json:"role"`
+	TrustLevel     string    `json:"trustLevel"`
+	Stats          UserStats `json:"stats"`
+}
+
 // UserResponse is a minimal representation of a user used in API responses.
+// RecoveryPhrase is only set when it was requested and spells the same account number as words.
 type UserResponse struct {
-	AccountNumber string `json:"accountNumber"`
+	AccountNumber  string `json:"accountNumber"`
+	RecoveryPhrase string `json:"recoveryPhrase,omitempty"`
+}
+
+// AccountNumberQueryParams defines query parameters for endpoints that reveal a new account number.
+type AccountNumberQueryParams struct {
+	RecoveryPhrase bool `form:"recovery_phrase"`
+}
+
+// CreateAccountQueryParams defines query parameters for creating an account.
+// Challenge and Solution carry a solved proof-of-work challenge obtained from GET /users/challenge.
+// Invite is required while the server runs in invite-only mode.
+type CreateAccountQueryParams struct {
+	AccountNumberQueryParams
+	Challenge string `form:"challenge"`
+	Solution  string `form:"solution"`
+	Invite    string `form:"invite"`
+}
+
+// SignupChallengeResponse is a proof-of-work challenge that has to be solved before creating an account.
+// A solution is any string for which SHA-256("<challenge>:<solution>") starts with Difficulty zero bits.
+// InviteRequired tells clients that an invite code has to be sent along as well.
+type SignupChallengeResponse struct {
+	Challenge      string    `json:"challenge"`
+	Difficulty     int       `json:"difficulty"`
+	Algorithm      string    `json:"algorithm"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	InviteRequired bool      `json:"inviteRequired"`
+}
+
+// Account deletion modes.
+// AccountDeletionDelete removes all content of the user, AccountDeletionAnonymize hands it over to the tombstone user.
+const (
+	AccountDeletionDelete    = "delete"
+	AccountDeletionAnonymize = "anonymize"
+)
+
+// TombstoneLookupHash identifies the tombstone user that owns the content of anonymized accounts.
+// Real fingerprints are hex encoded digests, so no account number can ever resolve to it.
+const TombstoneLookupHash = "tombstone"
+
+// DeleteAccountRequest is used to delete the account of the authenticated user.
+// The account number, or its recovery phrase, has to be re-sent to confirm the deletion.
+type DeleteAccountRequest struct {
+	AccountNumber string `json:"accountNumber" binding:"required"`
+	Mode          string `json:"mode" binding:"required,oneof=delete anonymize"`
+}
+
+// DeletedAccountContent describes what changed when an account was deleted,
+// so connected clients can be told which content to drop.
+type DeletedAccountContent struct {
+	Posts      []BoardContent
+	Comments   []BoardContent
+	LikedPosts []BoardContent
 }
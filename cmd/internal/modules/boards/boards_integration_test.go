@@ -0,0 +1,143 @@
+// Package boards_test contains integration tests for managing and browsing boards.
+package boards_test
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupBoardsTest registers the board routes behind a mock authentication middleware.
+// Role checks are covered by the middleware tests, the admin routes are exercised directly here.
+func setupBoardsTest() (*gin.Engine, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthMiddleware := func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	}
+
+	db := testutils.SetupMockDB()
+
+	repo := boards.NewSQLiteBoardsRepository(db)
+	handler := boards.NewBoardsHandler(boards.NewBoardsService(repo))
+
+	router := gin.Default()
+	apiGroup := router.Group("/api/v1")
+	authenticated := apiGroup.Group("/")
+	authenticated.Use(mockAuthMiddleware)
+	boards.RegisterBoardsRoutes(authenticated, handler)
+
+	admin := apiGroup.Group("/admin")
+	admin.Use(mockAuthMiddleware)
+	boards.RegisterAdminBoardsRoutes(admin, handler)
+
+	return router, db
+}
+
+func TestBoardsIntegration(t *testing.T) {
+	router, db := setupBoardsTest()
+
+	// Create a board, the slug is lowercased and the rules trimmed
+	reqBodyBytes, _ := json.Marshal(models.CreateBoardRequest{
+		Slug:        "Campus-X",
+		Description: "Confessions from campus",
+		Rules:       []string{" Be kind ", "No names"},
+	})
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/admin/boards", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var board models.Board
+	if err := json.Unmarshal(w.Body.Bytes(), &board); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if board.Slug != "campus-x" || !slices.Equal(board.Rules, []string{"Be kind", "No names"}) {
+		t.Errorf("Unexpected board %+v", board)
+	}
+
+	// Duplicate and malformed slugs
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/admin/boards", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d for a taken slug, got %d", http.StatusConflict, w.Code)
+	}
+	for _, slug := range []string{"two words", "double--dash", "-leading"} {
+		body, _ := json.Marshal(models.CreateBoardRequest{Slug: slug})
+		w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/admin/boards", body)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for slug %q, got %d", http.StatusBadRequest, slug, w.Code)
+		}
+	}
+
+	// Listing includes the general board
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/boards", nil)
+	router.ServeHTTP(w, req)
+	var list []models.Board
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	slugs := []string{}
+	for _, b := range list {
+		slugs = append(slugs, b.Slug)
+	}
+	if !slices.Contains(slugs, models.DefaultBoardSlug) || !slices.Contains(slugs, "campus-x") {
+		t.Errorf("Expected the general and the new board, got %v", slugs)
+	}
+
+	// Update replaces description and rules
+	reqBodyBytes, _ = json.Marshal(models.UpdateBoardRequest{Rules: []string{"Only campus stories"}})
+	w, req = testutils.HTTPTestRequest(http.MethodPatch, "/api/v1/admin/boards/campus-x", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/boards/campus-x", nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &board); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if board.Description != "" || !slices.Equal(board.Rules, []string{"Only campus stories"}) {
+		t.Errorf("Expected the board to be updated, got %+v", board)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodPatch, "/api/v1/admin/boards/missing", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a missing board, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// Boards with posts and the general board cannot be deleted
+	db.Create(&models.PostDBModel{Content: "On campus", UserId: 1, BoardId: board.ID})
+	for _, slug := range []string{"campus-x", models.DefaultBoardSlug} {
+		w, req = testutils.HTTPTestRequest(http.MethodDelete, "/api/v1/admin/boards/"+slug, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d when deleting %s, got %d", http.StatusConflict, slug, w.Code)
+		}
+	}
+
+	db.Where("board_id = ?", board.ID).Delete(&models.PostDBModel{})
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, "/api/v1/admin/boards/campus-x", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/boards/campus-x", nil)
+	router.ServeHTTP(w, req)
+	var errorMessage helper.ErrorMessage
+	if w.Code != http.StatusNotFound || json.Unmarshal(w.Body.Bytes(), &errorMessage) != nil {
+		t.Errorf("Expected status code %d after deleting, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package boards
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/models"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BoardsHandler struct {
+	boardsService *BoardsService
+}
+
+func NewBoardsHandler(boardsService *BoardsService) *BoardsHandler {
+	return &BoardsHandler{boardsService: boardsService}
+}
+
+func (h *BoardsHandler) GetBoardsHandler(c *gin.Context) {
+	boards, err := h.boardsService.GetBoards(c.Request.Context())
+	if err != nil {
+		slog.Error("Failed to retrieve boards", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve boards."})
+		return
+	}
+
+	c.JSON(http.StatusOK, boards)
+}
+
+func (h *BoardsHandler) GetBoardHandler(c *gin.Context) {
+	board, err := h.boardsService.GetBoard(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, ErrBoardNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Board does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve board", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve board."})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+func (h *BoardsHandler) CreateBoardHandler(c *gin.Context) {
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.CreateBoardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for creating a board", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	board, err := h.boardsService.CreateBoard(c.Request.Context(), userId, req)
+	if errors.Is(err, ErrInvalidBoardSlug) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Board slugs may only contain lowercase letters, digits and single dashes."})
+		return
+	}
+	if errors.Is(err, ErrBoardExists) {
+		c.JSON(http.StatusConflict, helper.ErrorMessage{Message: "A board with this slug already exists."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to create board", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to create board."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, board)
+}
+
+func (h *BoardsHandler) UpdateBoardHandler(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req models.UpdateBoardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for updating a board", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	rowsAffected, err := h.boardsService.UpdateBoard(c.Request.Context(), slug, req)
+	if err != nil {
+		slog.Error("Failed to update board", slog.String("slug", slug), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update board."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Board does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Updated successfully"})
+}
+
+func (h *BoardsHandler) DeleteBoardHandler(c *gin.Context) {
+	userId := helper.RetrieveLoggedInUserId(c)
+	slug := c.Param("slug")
+
+	err := h.boardsService.DeleteBoard(c.Request.Context(), slug, userId)
+	if errors.Is(err, ErrBoardNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Board does not exist."})
+		return
+	}
+	if errors.Is(err, ErrBoardNotEmpty) {
+		c.JSON(http.StatusConflict, helper.ErrorMessage{Message: "Only boards without posts can be deleted, and never the general board."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to delete board", slog.String("slug", slug), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to delete board."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Board deleted successfully."})
+}
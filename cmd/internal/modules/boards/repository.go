@@ -0,0 +1,87 @@
+package boards
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type BoardsRepository interface {
+	CreateBoard(context.Context, *models.BoardDBModel) error
+	GetBoards(context.Context) ([]models.BoardDBModel, error)
+	GetBoardBySlug(context.Context, string) (*models.BoardDBModel, error)
+	UpdateBoard(context.Context, string, models.UpdateBoardRequest) (int64, error)
+	DeleteEmptyBoard(context.Context, string) (int64, error)
+}
+
+type SQLiteBoardsRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLiteBoardsRepository(db *gorm.DB) *SQLiteBoardsRepository {
+	return &SQLiteBoardsRepository{db: db}
+}
+
+func (repo *SQLiteBoardsRepository) CreateBoard(ctx context.Context, board *models.BoardDBModel) error {
+	if err := repo.db.WithContext(ctx).Create(board).Error; err != nil {
+		slog.Error("Failed to create board", slog.String("slug", board.Slug), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// GetBoards returns every board, ordered by slug.
+func (repo *SQLiteBoardsRepository) GetBoards(ctx context.Context) ([]models.BoardDBModel, error) {
+	var boards []models.BoardDBModel
+	if err := repo.db.WithContext(ctx).Order("slug").Find(&boards).Error; err != nil {
+		slog.Error("Failed to retrieve boards", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return boards, nil
+}
+
+// GetBoardBySlug returns nil without an error when no board has the slug.
+func (repo *SQLiteBoardsRepository) GetBoardBySlug(ctx context.Context, slug string) (*models.BoardDBModel, error) {
+	var board models.BoardDBModel
+	err := repo.db.WithContext(ctx).Where("slug = ?", slug).First(&board).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve board", slog.String("slug", slug), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &board, nil
+}
+
+func (repo *SQLiteBoardsRepository) UpdateBoard(ctx context.Context, slug string, req models.UpdateBoardRequest) (int64, error) {
+	// Updating through the struct keeps the JSON serializer of the rules, Select lets an empty description through.
+	result := repo.db.WithContext(ctx).Model(&models.BoardDBModel{}).Where("slug = ?", slug).
+		Select("description", "rules").
+		Updates(models.BoardDBModel{Description: req.Description, Rules: req.Rules})
+	if result.Error != nil {
+		slog.Error("Failed to update board", slog.String("slug", slug), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteEmptyBoard deletes a board as long as no post belongs to it, so posts never point to a missing board.
+func (repo *SQLiteBoardsRepository) DeleteEmptyBoard(ctx context.Context, slug string) (int64, error) {
+	result := repo.db.WithContext(ctx).
+		Where("slug = ? AND NOT EXISTS (SELECT 1 FROM posts WHERE posts.board_id = boards.id)", slug).
+		Delete(&models.BoardDBModel{})
+	if result.Error != nil {
+		slog.Error("Failed to delete board", slog.String("slug", slug), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
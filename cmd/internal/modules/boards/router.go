@@ -0,0 +1,120 @@
+package boards
+
+import (
+	"anon-confessions/cmd/internal/middleware"
+	"anon-confessions/cmd/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBoardsRoutes registers the routes for browsing boards.
+// Requests authenticated with an API key need the posts:read scope.
+func RegisterBoardsRoutes(router *gin.RouterGroup, h *BoardsHandler) {
+	boardGroup := router.Group("/boards")
+	boardGroup.Use(middleware.RequireScope(models.ScopePostsRead))
+	{
+		boardGroup.GET("", h.GetBoardsHandler)
+		boardGroup.GET("/:slug", h.GetBoardHandler)
+	}
+}
+
+// RegisterAdminBoardsRoutes registers the routes for managing boards.
+// The router group is expected to only let admins through.
+func RegisterAdminBoardsRoutes(router *gin.RouterGroup, h *BoardsHandler) {
+	boardGroup := router.Group("/boards")
+	boardGroup.Use(middleware.RequireScope(models.ScopeModerate))
+	{
+		boardGroup.POST("", h.CreateBoardHandler)
+		boardGroup.PATCH("/:slug", h.UpdateBoardHandler)
+		boardGroup.DELETE("/:slug", h.DeleteBoardHandler)
+	}
+}
+
+// Swagger documentation.
+
+// GetBoardsHandler handles listing boards.
+// @Summary List boards
+// @Description Lists every board posts can go to, ordered by slug.
+// @Tags boards
+// @Produce json
+// @Success 200 {array} models.Board "Boards retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve boards"
+// @Router /boards [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func getBoardsHandler(c *gin.Context) {}
+
+// GetBoardHandler handles retrieving a board.
+// @Summary Retrieve a board
+// @Description Retrieves the description and rules of a board.
+// @Tags boards
+// @Produce json
+// @Param slug path string true "Board slug"
+// @Success 200 {object} models.Board "Board retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Board not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve board"
+// @Router /boards/{slug} [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func getBoardHandler(c *gin.Context) {}
+
+// CreateBoardHandler handles an admin creating a board.
+// @Summary Create a board
+// @Description Creates a board with a slug, description and rules. Slugs are lowercase letters, digits and single dashes and cannot be changed later. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param board body models.CreateBoardRequest true "Board"
+// @Success 201 {object} models.Board "Board created successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body or slug"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 409 {object} helper.ErrorMessage "Slug already taken"
+// @Failure 500 {object} helper.ErrorMessage "Failed to create board"
+// @Router /admin/boards [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func createBoardHandler(c *gin.Context) {}
+
+// UpdateBoardHandler handles an admin changing a board.
+// @Summary Update a board
+// @Description Replaces the description and rules of a board. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Board slug"
+// @Param board body models.UpdateBoardRequest true "Description and rules"
+// @Success 200 {object} helper.SuccessMessage "Board updated successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "Board not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to update board"
+// @Router /admin/boards/{slug} [patch]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func updateBoardHandler(c *gin.Context) {}
+
+// DeleteBoardHandler handles an admin deleting a board.
+// @Summary Delete a board
+// @Description Deletes a board that has no posts. The general board cannot be deleted. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Param slug path string true "Board slug"
+// @Success 200 {object} helper.SuccessMessage "Board deleted successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "Board not found"
+// @Failure 409 {object} helper.ErrorMessage "Board still has posts or is the general board"
+// @Failure 500 {object} helper.ErrorMessage "Failed to delete board"
+// @Router /admin/boards/{slug} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func deleteBoardHandler(c *gin.Context) {}
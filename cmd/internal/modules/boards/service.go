@@ -0,0 +1,152 @@
+package boards
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBoardNotFound is returned when no board has the given slug.
+	ErrBoardNotFound = errors.New("board not found")
+	// ErrInvalidBoardSlug is returned when a slug is not made of lowercase letters, digits and single dashes.
+	ErrInvalidBoardSlug = errors.New("invalid board slug")
+	// ErrBoardExists is returned when a board is created with a slug that is already taken.
+	ErrBoardExists = errors.New("board already exists")
+	// ErrBoardNotEmpty is returned when deleting a board that still has posts, or the default board.
+	ErrBoardNotEmpty = errors.New("board still has posts")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+type BoardsService struct {
+	boardsRepo BoardsRepository
+}
+
+func NewBoardsService(boardsRepo BoardsRepository) *BoardsService {
+	return &BoardsService{boardsRepo: boardsRepo}
+}
+
+func (s *BoardsService) CreateBoard(ctx context.Context, userId int, req models.CreateBoardRequest) (*models.Board, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidBoardSlug
+	}
+
+	existing, err := s.boardsRepo.GetBoardBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve board: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrBoardExists
+	}
+
+	board := models.BoardDBModel{
+		Slug:        slug,
+		Description: strings.TrimSpace(req.Description),
+		Rules:       normalizeRules(req.Rules),
+		CreatedBy:   &userId,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.boardsRepo.CreateBoard(ctx, &board); err != nil {
+		return nil, fmt.Errorf("failed to create board: %w", err)
+	}
+
+	slog.Info("Board created", slog.String("slug", slug), slog.Int("userId", userId))
+	result := toBoard(board)
+	return &result, nil
+}
+
+func (s *BoardsService) GetBoards(ctx context.Context) ([]models.Board, error) {
+	boards, err := s.boardsRepo.GetBoards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve boards: %w", err)
+	}
+
+	result := make([]models.Board, 0, len(boards))
+	for _, board := range boards {
+		result = append(result, toBoard(board))
+	}
+
+	return result, nil
+}
+
+// GetBoard returns the board with the given slug, or ErrBoardNotFound.
+func (s *BoardsService) GetBoard(ctx context.Context, slug string) (*models.Board, error) {
+	board, err := s.boardsRepo.GetBoardBySlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve board: %w", err)
+	}
+	if board == nil {
+		return nil, ErrBoardNotFound
+	}
+
+	result := toBoard(*board)
+	return &result, nil
+}
+
+func (s *BoardsService) UpdateBoard(ctx context.Context, slug string, req models.UpdateBoardRequest) (int64, error) {
+	req.Description = strings.TrimSpace(req.Description)
+	req.Rules = normalizeRules(req.Rules)
+
+	rowsAffected, err := s.boardsRepo.UpdateBoard(ctx, strings.ToLower(slug), req)
+	if err != nil {
+		return -1, fmt.Errorf("failed to update board: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteBoard deletes a board without posts. The default board is never deleted, since new posts fall back to it.
+func (s *BoardsService) DeleteBoard(ctx context.Context, slug string, userId int) error {
+	board, err := s.GetBoard(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if board.ID == models.DefaultBoardID {
+		return ErrBoardNotEmpty
+	}
+
+	rowsAffected, err := s.boardsRepo.DeleteEmptyBoard(ctx, board.Slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete board: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrBoardNotEmpty
+	}
+
+	slog.Info("Board deleted", slog.String("slug", board.Slug), slog.Int("userId", userId))
+	return nil
+}
+
+// normalizeRules trims the rules and drops the empty ones.
+func normalizeRules(rules []string) []string {
+	result := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
+
+func toBoard(board models.BoardDBModel) models.Board {
+	rules := board.Rules
+	if rules == nil {
+		rules = []string{}
+	}
+
+	return models.Board{
+		ID:          board.ID,
+		Slug:        board.Slug,
+		Description: board.Description,
+		Rules:       rules,
+		CreatedAt:   board.CreatedAt,
+	}
+}
@@ -1,20 +1,41 @@
 package comments_test
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/helper/testutils"
 	"anon-confessions/cmd/internal/models"
 	"anon-confessions/cmd/internal/modules/comments"
-	"anon-confessions/cmd/internal/modules/posts"
 	"anon-confessions/cmd/internal/websocket"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var testConfig = &config.Config{
+	Content: config.ContentConfig{PseudonymSecret: "test-pseudonym-secret", RestoreWindow: 72 * time.Hour},
+}
+
+// stubRole gives every user the same role.
+type stubRole string
+
+func (s stubRole) GetUserRole(ctx context.Context, userId int) (string, error) {
+	return string(s), nil
+}
+
 func setupCommentsTest() *gin.Engine {
+	return setupCommentsTestWithRole(models.RoleUser, testConfig)
+}
+
+// setupCommentsTestWithRole is setupCommentsTest with the given role for the logged-in user and configuration.
+func setupCommentsTestWithRole(role string, cfg *config.Config) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	mockAuthMiddleware := func(c *gin.Context) {
@@ -28,12 +49,10 @@ func setupCommentsTest() *gin.Engine {
 	go hub.Run()
 
 	// Initialize repositories, services, and handlers
-	postsRepo := posts.NewSQLitePostsRepository(db)
 	commentsRepo := comments.NewSQLiteCommentsRepository(db)
-	postsService := posts.NewPostsService(postsRepo, hub)
-	commentsService := comments.NewCommentsService(commentsRepo, hub)
+	commentsService := comments.NewCommentsService(commentsRepo, cfg, hub)
 
-	handler := comments.NewCommentsHandler(commentsService, postsService)
+	handler := comments.NewCommentsHandler(commentsService, stubRole(role))
 
 	// Set up router and register routes
 	router := gin.Default()
@@ -42,6 +61,11 @@ func setupCommentsTest() *gin.Engine {
 	authenticated.Use(mockAuthMiddleware)
 	comments.RegisterCommentsRoutes(authenticated, handler)
 
+	// Role checks are covered by the middleware tests, the moderation routes are exercised directly here.
+	admin := apiGroup.Group("/admin")
+	admin.Use(mockAuthMiddleware)
+	comments.RegisterAdminCommentsRoutes(admin, handler)
+
 	return router
 }
 
@@ -105,6 +129,77 @@ func TestGetCommentsCollection(t *testing.T) {
 	}
 }
 
+func TestGetCommentsCollectionPseudonyms(t *testing.T) {
+	router := setupCommentsTest()
+
+	// Seed a post by user 1 with comments from its author and from another user
+	db := testutils.SetupMockDB()
+	post := models.PostDBModel{Content: "Seeded Post", UserId: 1}
+	db.Create(&post)
+	db.Create(&models.CommentsDbModel{Content: "Reply from the author", UserId: 1, PostId: post.ID})
+	db.Create(&models.CommentsDbModel{Content: "Reply from someone else", UserId: 2, PostId: post.ID})
+	db.Create(&models.CommentsDbModel{Content: "Another reply from the author", UserId: 1, PostId: post.ID})
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var comments models.GetCommentsCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &comments); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(comments) != 3 {
+		t.Fatalf("Expected 3 comments, got %d", len(comments))
+	}
+
+	for _, comment := range comments {
+		if comment.Author == "" {
+			t.Errorf("Expected comment %d to carry a pseudonym", comment.ID)
+		}
+	}
+	if !comments[0].IsOP || comments[1].IsOP || !comments[2].IsOP {
+		t.Errorf("Expected only the post author's comments to be flagged as OP, got %+v", comments)
+	}
+	if comments[0].Author != comments[2].Author {
+		t.Errorf("Expected the same author to keep one pseudonym, got %q and %q", comments[0].Author, comments[2].Author)
+	}
+	if comments[0].Author == comments[1].Author {
+		t.Errorf("Expected different authors to get different pseudonyms, both got %q", comments[0].Author)
+	}
+	if strings.Contains(w.Body.String(), "user_id") || strings.Contains(w.Body.String(), "userId") {
+		t.Errorf("Expected the response not to expose user IDs, got %s", w.Body.String())
+	}
+}
+
+// TestTombstonePostHasNoOP tests if comments of anonymized accounts are not flagged as OP on their anonymized posts.
+func TestTombstonePostHasNoOP(t *testing.T) {
+	router := setupCommentsTest()
+
+	db := testutils.SetupMockDB()
+	lookupHash := models.TombstoneLookupHash
+	tombstone := models.Users{AccountNumber: "!", LookupHash: &lookupHash, CreatedAt: time.Now()}
+	db.Where("lookup_hash = ?", lookupHash).FirstOrCreate(&tombstone)
+	post := models.PostDBModel{Content: "Post of a deleted account", UserId: tombstone.ID}
+	db.Create(&post)
+	db.Create(&models.CommentsDbModel{Content: "Reply of another deleted account", UserId: tombstone.ID, PostId: post.ID})
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var comments models.GetCommentsCollection
+	json.Unmarshal(w.Body.Bytes(), &comments)
+	if len(comments) != 1 || comments[0].IsOP {
+		t.Errorf("Expected the tombstone user not to be flagged as OP, got %+v", comments)
+	}
+}
+
 func TestUpdateCommentHandler(t *testing.T) {
 	router := setupCommentsTest()
 
@@ -164,3 +259,232 @@ func TestDeleteCommentHandler(t *testing.T) {
 		t.Errorf("Expected message 'Comment Deleted Successfully', got '%s'", resp.Message)
 	}
 }
+
+func TestCommentRevisions(t *testing.T) {
+	router := setupCommentsTest()
+
+	db := testutils.SetupMockDB()
+	post := models.PostDBModel{Content: "Post with edited comments", UserId: 2}
+	db.Create(&post)
+	own := models.CommentsDbModel{Content: "First version", UserId: 1, PostId: post.ID}
+	db.Create(&own)
+	other := models.CommentsDbModel{Content: "Someone else's first version", UserId: 2, PostId: post.ID}
+	db.Create(&other)
+
+	edit := func(url, content string) {
+		reqBody, _ := json.Marshal(models.CreateCommentRequest{Content: content})
+		w, req := testutils.HTTPTestRequest(http.MethodPatch, url, reqBody)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for editing %s, got %d", http.StatusOK, url, w.Code)
+		}
+	}
+	revisions := func(router *gin.Engine, commentId, expectedCode int) []models.Revision {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments/%d/revisions", post.ID, commentId), nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for the revisions of comment %d, got %d", expectedCode, commentId, w.Code)
+		}
+		var revisions []models.Revision
+		json.Unmarshal(w.Body.Bytes(), &revisions)
+		return revisions
+	}
+
+	edit(fmt.Sprintf("/api/v1/posts/%d/comments/%d", post.ID, own.ID), "Second version")
+	edit(fmt.Sprintf("/api/v1/posts/%d/comments/%d", post.ID, own.ID), "Second version")
+	edit(fmt.Sprintf("/api/v1/admin/posts/%d/comments/%d", post.ID, own.ID), "Third version")
+	edit(fmt.Sprintf("/api/v1/admin/posts/%d/comments/%d", post.ID, other.ID), "Someone else's second version")
+
+	// The author sees the content a moderator removed as well
+	history := revisions(router, own.ID, http.StatusOK)
+	if len(history) != 2 || history[0].Content != "Second version" || !history[0].ByModerator || history[1].Content != "First version" {
+		t.Errorf("Expected the moderated 'Second version' and the author's 'First version', saving unchanged content must not add one, got %+v", history)
+	}
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), nil)
+	router.ServeHTTP(w, req)
+	var collection models.GetCommentsCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	for _, comment := range collection {
+		if !comment.Edited || comment.EditedAt == nil {
+			t.Errorf("Expected comment %d to carry edited and editedAt", comment.ID)
+		}
+	}
+
+	// The history of someone else's comment is hidden unless it is public or the viewer is a moderator
+	revisions(router, other.ID, http.StatusForbidden)
+	revisions(router, 999999, http.StatusNotFound)
+
+	moderator := setupCommentsTestWithRole(models.RoleModerator, testConfig)
+	if history := revisions(moderator, other.ID, http.StatusOK); len(history) != 1 || !history[0].ByModerator {
+		t.Errorf("Expected moderators to see the moderated revision, got %+v", history)
+	}
+
+	publicConfig := *testConfig
+	publicConfig.Content.PublicEditHistory = true
+	public := setupCommentsTestWithRole(models.RoleUser, &publicConfig)
+	if history := revisions(public, other.ID, http.StatusOK); len(history) != 0 {
+		t.Errorf("Expected the public history to leave out the moderated content, got %+v", history)
+	}
+	db.Create(&models.CommentRevisionDBModel{CommentId: other.ID, Content: "Someone else's own edit"})
+	if history := revisions(public, other.ID, http.StatusOK); len(history) != 1 || history[0].Content != "Someone else's own edit" {
+		t.Errorf("Expected the public history of the author's edits, got %+v", history)
+	}
+}
+
+func TestCommentSoftDelete(t *testing.T) {
+	router := setupCommentsTest()
+
+	db := testutils.SetupMockDB()
+	post := models.PostDBModel{Content: "Post with deleted comments", UserId: 2}
+	db.Create(&post)
+	comment := models.CommentsDbModel{Content: "Regretting this comment", UserId: 1, PostId: post.ID}
+	db.Create(&comment)
+	expired := models.CommentsDbModel{Content: "Deleted long ago", UserId: 1, PostId: post.ID}
+	db.Create(&expired)
+
+	request := func(method, url string, expectedCode int) string {
+		w, req := testutils.HTTPTestRequest(method, url, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for %s %s, got %d", expectedCode, method, url, w.Code)
+		}
+		return w.Body.String()
+	}
+	commentURL := func(commentId int) string {
+		return fmt.Sprintf("/api/v1/posts/%d/comments/%d", post.ID, commentId)
+	}
+	listed := func() bool {
+		return strings.Contains(request(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), http.StatusOK), "Regretting this comment")
+	}
+
+	request(http.MethodDelete, commentURL(expired.ID), http.StatusOK)
+	db.Model(&models.CommentsDbModel{}).Where("id = ?", expired.ID).Update("deleted_at", time.Now().Add(-73*time.Hour))
+
+	request(http.MethodDelete, commentURL(comment.ID), http.StatusOK)
+	request(http.MethodDelete, commentURL(comment.ID), http.StatusNotFound)
+	request(http.MethodGet, commentURL(comment.ID)+"/revisions", http.StatusNotFound)
+	if listed() {
+		t.Errorf("Expected the deleted comment to be hidden")
+	}
+
+	request(http.MethodPost, commentURL(comment.ID)+"/restore", http.StatusOK)
+	request(http.MethodPost, commentURL(comment.ID)+"/restore", http.StatusNotFound)
+	request(http.MethodPost, commentURL(expired.ID)+"/restore", http.StatusNotFound)
+	if !listed() {
+		t.Errorf("Expected the restored comment to be listed again")
+	}
+
+	commentsService := comments.NewCommentsService(comments.NewSQLiteCommentsRepository(db), testConfig, nil)
+	if err := commentsService.PurgeDeletedComments(context.Background()); err != nil {
+		t.Fatalf("Failed to purge deleted comments: %v", err)
+	}
+	var remaining int64
+	db.Model(&models.CommentsDbModel{}).Where("id IN ?", []int{comment.ID, expired.ID}).Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("Expected only the restored comment to survive the purge, got %d comments", remaining)
+	}
+}
+
+// TestNewCommentReachesBoardFollowers tests if new comments are announced to every client and to the followers of the post's board.
+func TestNewCommentReachesBoardFollowers(t *testing.T) {
+	db := testutils.SetupMockDB()
+	post := models.PostDBModel{Content: "Post on the default board", UserId: 1, BoardId: models.DefaultBoardID}
+	db.Create(&post)
+
+	hub := websocket.NewHub()
+	service := comments.NewCommentsService(comments.NewSQLiteCommentsRepository(db), testConfig, hub)
+	created := make(chan error, 1)
+	go func() {
+		created <- service.CreateComments(context.Background(), post.ID, 1, models.CreateCommentRequest{Content: "Board comment"})
+	}()
+
+	select {
+	case <-hub.Broadcast:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the comment to be broadcast to every client")
+	}
+	select {
+	case boardMessage := <-hub.BoardBroadcast:
+		var message models.WebSocketMessage
+		json.Unmarshal(boardMessage.Message, &message)
+		if boardMessage.Board != models.DefaultBoardSlug || message.Type != "newComment" || !strings.Contains(fmt.Sprint(message.Content), models.DefaultBoardSlug) {
+			t.Errorf("Expected a newComment message for board %q, got %q: %+v", models.DefaultBoardSlug, boardMessage.Board, message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the comment to be broadcast to the followers of the board")
+	}
+	if err := <-created; err != nil {
+		t.Fatalf("Failed to create comment: %v", err)
+	}
+}
+
+// TestCommentsOnHiddenPosts tests that the comments of posts hidden from everyone can neither be listed nor added to.
+func TestCommentsOnHiddenPosts(t *testing.T) {
+	router := setupCommentsTest()
+	db := testutils.SetupMockDB()
+	service := comments.NewCommentsService(comments.NewSQLiteCommentsRepository(db), testConfig, nil)
+
+	deletedAt, expiredAt, publishAt := time.Now(), time.Now().Add(-time.Minute), time.Now().Add(time.Hour).UTC()
+	hidden := map[string]models.PostDBModel{
+		"deleted":   {Content: "Deleted post", UserId: 1, DeletedAt: &deletedAt},
+		"expired":   {Content: "Expired post", UserId: 1, ExpiresAt: &expiredAt},
+		"scheduled": {Content: "Scheduled post", UserId: 1, PublishAt: &publishAt},
+	}
+
+	for name, post := range hidden {
+		db.Create(&post)
+		comment := models.CommentsDbModel{Content: "Comment on a hidden post", UserId: 1, PostId: post.ID}
+		db.Create(&comment)
+		deleted := models.CommentsDbModel{Content: "Deleted comment on a hidden post", UserId: 1, PostId: post.ID, DeletedAt: &deletedAt}
+		db.Create(&deleted)
+
+		w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for the comments of a %s post, got %d", http.StatusNotFound, name, w.Code)
+		}
+
+		reqBody, _ := json.Marshal(models.CreateCommentRequest{Content: "Too late"})
+		w, req = testutils.HTTPTestRequest(http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/comments", post.ID), reqBody)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for commenting on a %s post, got %d", http.StatusNotFound, name, w.Code)
+		}
+
+		if _, err := service.GetCommentsCollection(context.Background(), post.ID); !errors.Is(err, comments.ErrPostNotFound) {
+			t.Errorf("Expected ErrPostNotFound for the comments of a %s post, got %v", name, err)
+		}
+
+		// The comments already there cannot be changed or looked into either
+		commentURL := fmt.Sprintf("/api/v1/posts/%d/comments/%d", post.ID, comment.ID)
+		requests := map[string]struct {
+			method, url string
+			body        []byte
+		}{
+			"editing":                     {http.MethodPatch, commentURL, reqBody},
+			"deleting":                    {http.MethodDelete, commentURL, nil},
+			"restoring":                   {http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/comments/%d/restore", post.ID, deleted.ID), nil},
+			"reading the edit history of": {http.MethodGet, commentURL + "/revisions", nil},
+		}
+		for action, r := range requests {
+			w, req = testutils.HTTPTestRequest(r.method, r.url, r.body)
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status code %d for %s a comment on a %s post, got %d", http.StatusNotFound, action, name, w.Code)
+			}
+		}
+
+		var count int64
+		db.Model(&models.CommentsDbModel{}).Where("post_id = ? AND deleted_at IS NULL AND content = ?", post.ID, comment.Content).Count(&count)
+		if count != 1 {
+			t.Errorf("Expected the comment on the %s post to be left as it was, got %d matching comments", name, count)
+		}
+		db.Model(&models.CommentsDbModel{}).Where("post_id = ?", post.ID).Count(&count)
+		if count != 2 {
+			t.Errorf("Expected no comment to be added to the %s post, got %d comments", name, count)
+		}
+	}
+}
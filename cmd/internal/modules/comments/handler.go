@@ -4,6 +4,7 @@ import (
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
 	"anon-confessions/cmd/internal/modules/posts"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -12,11 +13,11 @@ import (
 
 type CommentsHandler struct {
 	commentsService *CommentsService
-	postsService    *posts.PostsService
+	roles           posts.RoleReader
 }
 
-func NewCommentsHandler(commentsService *CommentsService, postsService *posts.PostsService) *CommentsHandler {
-	return &CommentsHandler{commentsService: commentsService, postsService: postsService}
+func NewCommentsHandler(commentsService *CommentsService, roles posts.RoleReader) *CommentsHandler {
+	return &CommentsHandler{commentsService: commentsService, roles: roles}
 }
 
 func (h *CommentsHandler) CreateCommentsHandler(c *gin.Context) {
@@ -31,13 +32,11 @@ func (h *CommentsHandler) CreateCommentsHandler(c *gin.Context) {
 		return
 	}
 
-	_, err := h.postsService.GetPost(ctx, postId)
-	if err != nil {
+	err := h.commentsService.CreateComments(ctx, postId, userId, comment)
+	if errors.Is(err, ErrPostNotFound) {
 		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
 		return
 	}
-
-	err = h.commentsService.CreateComments(ctx, postId, userId, comment)
 	if err != nil {
 		slog.Error("Failed to create comment", slog.String("error", err.Error()), slog.Int("postId", postId), slog.Int("userId", userId))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to create comment on post."})
@@ -51,13 +50,11 @@ func (h *CommentsHandler) GetCommentsCollection(c *gin.Context) {
 	ctx := c.Request.Context()
 	postId := helper.ParseIDParam(c, "id")
 
-	_, err := h.postsService.GetPost(ctx, postId)
-	if err != nil {
+	comments, err := h.commentsService.GetCommentsCollection(ctx, postId)
+	if errors.Is(err, ErrPostNotFound) {
 		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
 		return
 	}
-
-	comments, err := h.commentsService.GetCommentsCollection(ctx, postId)
 	if err != nil {
 		slog.Error("Failed to retrieve comments", slog.String("error", err.Error()), slog.Int("postId", postId))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve comments on post."})
@@ -81,13 +78,11 @@ func (h *CommentsHandler) UpdateCommentHandler(c *gin.Context) {
 		return
 	}
 
-	_, err := h.postsService.GetPost(ctx, postId)
-	if err != nil {
+	rowsAffected, err := h.commentsService.UpdateComments(ctx, commentId, postId, userId, comment)
+	if errors.Is(err, ErrPostNotFound) {
 		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
 		return
 	}
-
-	rowsAffected, err := h.commentsService.UpdateComments(ctx, commentId, postId, userId, comment)
 	if err != nil {
 		slog.Error("Failed to update comment", slog.String("error", err.Error()), slog.Int("commentId", commentId))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update comment."})
@@ -101,19 +96,56 @@ func (h *CommentsHandler) UpdateCommentHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Comment updated successfully."})
 }
 
-func (h *CommentsHandler) DeleteCommentHandler(c *gin.Context) {
+func (h *CommentsHandler) GetCommentRevisionsHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	userId := helper.RetrieveLoggedInUserId(c)
 	postId := helper.ParseIDParam(c, "id")
 	commentId := helper.ParseIDParam(c, "commentId")
+	if c.IsAborted() {
+		return
+	}
 
-	_, err := h.postsService.GetPost(ctx, postId)
+	role, err := h.roles.GetUserRole(ctx, userId)
 	if err != nil {
+		slog.Error("Failed to retrieve role for comment revisions", slog.String("error", err.Error()), slog.Int("userId", userId))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve revisions."})
+		return
+	}
+	isModerator := role == models.RoleModerator || role == models.RoleAdmin
+
+	revisions, err := h.commentsService.GetCommentRevisions(ctx, commentId, postId, userId, isModerator)
+	if errors.Is(err, ErrPostNotFound) {
 		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
 		return
 	}
+	if errors.Is(err, ErrCommentNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Comment does not exist."})
+		return
+	}
+	if errors.Is(err, ErrHistoryNotVisible) {
+		c.JSON(http.StatusForbidden, helper.ErrorMessage{Message: "Only the author and moderators can see the edit history."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve comment revisions", slog.String("error", err.Error()), slog.Int("commentId", commentId))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve revisions."})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *CommentsHandler) DeleteCommentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+	commentId := helper.ParseIDParam(c, "commentId")
 
 	comments, err := h.commentsService.DeleteComments(ctx, postId, userId, commentId)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to delete comment", slog.String("error", err.Error()), slog.Int("commentId", commentId))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to delete comment."})
@@ -126,3 +158,78 @@ func (h *CommentsHandler) DeleteCommentHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Comment Deleted Successfully"})
 }
+
+func (h *CommentsHandler) RestoreCommentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+	commentId := helper.ParseIDParam(c, "commentId")
+
+	rowsAffected, err := h.commentsService.RestoreComment(ctx, commentId, postId, userId)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to restore comment", slog.String("error", err.Error()), slog.Int("commentId", commentId))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to restore comment."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "No deleted comment to restore."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Comment restored successfully."})
+}
+
+func (h *CommentsHandler) ModerateUpdateCommentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	moderatorId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+	commentId := helper.ParseIDParam(c, "commentId")
+
+	var comment models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&comment); err != nil {
+		slog.Warn("Invalid request body", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	rowsAffected, err := h.commentsService.ModerateCommentUpdate(ctx, commentId, postId, moderatorId, comment)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to moderate comment update", slog.String("error", err.Error()), slog.Int("commentId", commentId))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update comment."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Comment does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Comment updated successfully."})
+}
+
+func (h *CommentsHandler) ModerateDeleteCommentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	moderatorId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+	commentId := helper.ParseIDParam(c, "commentId")
+
+	rowsAffected, err := h.commentsService.ModerateCommentDeletion(ctx, postId, commentId, moderatorId)
+	if err != nil {
+		slog.Error("Failed to moderate comment deletion", slog.String("error", err.Error()), slog.Int("commentId", commentId))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to delete comment."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Comment does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Comment Deleted Successfully"})
+}
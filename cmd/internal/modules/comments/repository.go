@@ -2,8 +2,11 @@ package comments
 
 import (
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/posts"
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +16,15 @@ type CommentsRepository interface {
 	GetCommentsCollection(context.Context, int) (*models.GetCommentsCollection, error)
 	UpdateComments(context.Context, int, int, int, models.CreateCommentRequest) (int64, error)
 	DeleteComments(context.Context, int, int, int) (int64, error)
+	RestoreComment(context.Context, int, int, int, time.Time) (int64, error)
+	PurgeDeletedComments(context.Context, time.Time) (int64, error)
+	GetPostAuthorId(context.Context, int) (int, error)
+	GetPostBoard(context.Context, int) (string, error)
+	GetThreadParticipants(context.Context, int) ([]int, error)
+	ModerateUpdateComment(context.Context, int, int, models.CreateCommentRequest) (int64, error)
+	ModerateDeleteComment(context.Context, int, int) (int64, error)
+	GetCommentAuthorId(context.Context, int, int) (int, error)
+	GetCommentRevisions(context.Context, int, bool) ([]models.Revision, error)
 }
 
 type SQLiteCommentsRepository struct {
@@ -23,13 +35,24 @@ func NewSQLiteCommentsRepository(db *gorm.DB) *SQLiteCommentsRepository {
 	return &SQLiteCommentsRepository{db: db}
 }
 
+// CreateComments adds a comment to a post, or returns ErrPostNotFound when the post was deleted, expired or is not published yet.
+// The post is checked in the same transaction, so it cannot be deleted in between.
 func (repo *SQLiteCommentsRepository) CreateComments(ctx context.Context, commentsDbModel models.CommentsDbModel) error {
 	slog.Debug("Creating a new comment in the database", slog.Int("postId", commentsDbModel.PostId), slog.Int("userId", commentsDbModel.UserId))
 
-	result := repo.db.WithContext(ctx).Create(&commentsDbModel)
-	if result.Error != nil {
-		slog.Error("Failed to create comment", slog.String("error", result.Error.Error()), slog.Int("postId", commentsDbModel.PostId), slog.Int("userId", commentsDbModel.UserId))
-		return result.Error
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkPostVisible(tx, commentsDbModel.PostId); err != nil {
+			return err
+		}
+
+		return tx.Create(&commentsDbModel).Error
+	})
+	if errors.Is(err, ErrPostNotFound) {
+		return err
+	}
+	if err != nil {
+		slog.Error("Failed to create comment", slog.String("error", err.Error()), slog.Int("postId", commentsDbModel.PostId), slog.Int("userId", commentsDbModel.UserId))
+		return err
 	}
 
 	slog.Info("Comment created successfully", slog.Int("commentId", commentsDbModel.ID))
@@ -40,7 +63,10 @@ func (repo *SQLiteCommentsRepository) GetCommentsCollection(ctx context.Context,
 	slog.Debug("Retrieving comments collection for post", slog.Int("postId", postId))
 
 	var commentsCollection models.GetCommentsCollection
-	result := repo.db.WithContext(ctx).Where("post_id = ?", postId).Find(&commentsCollection)
+	result := repo.db.WithContext(ctx).
+		Select("comments.*, comments.edited_at IS NOT NULL AS edited").
+		Where("post_id = ? AND deleted_at IS NULL", postId).
+		Find(&commentsCollection)
 
 	if result.Error != nil {
 		slog.Error("Failed to retrieve comments collection", slog.String("error", result.Error.Error()), slog.Int("postId", postId))
@@ -55,30 +81,274 @@ func (repo *SQLiteCommentsRepository) GetCommentsCollection(ctx context.Context,
 	return &commentsCollection, nil
 }
 
+// UpdateComments replaces the content of a comment of the given user, or returns ErrPostNotFound when its post is not visible.
 func (repo *SQLiteCommentsRepository) UpdateComments(ctx context.Context, commentId, postId, userId int, comment models.CreateCommentRequest) (int64, error) {
 	slog.Debug("Updating comment in the database", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
 
-	result := repo.db.WithContext(ctx).Model(&models.CommentsDbModel{}).
-		Where("id = ? AND post_id = ? AND user_id = ?", commentId, postId, userId).
-		Update("content", comment.Content)
+	var rowsAffected int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rowsAffected, err = reviseComment(tx, postId, comment.Content, false, "id = ? AND post_id = ? AND user_id = ?", commentId, postId, userId)
+		return err
+	})
+
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		slog.Error("Failed to update comment", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteComments marks a comment of the given user as deleted. It is only removed for good by PurgeDeletedComments.
+// ErrPostNotFound is returned when the post is not visible.
+func (repo *SQLiteCommentsRepository) DeleteComments(ctx context.Context, postId, userId, commentId int) (int64, error) {
+	slog.Debug("Deleting comment from the database", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+
+	var rowsAffected int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkPostVisible(tx, postId); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.CommentsDbModel{}).
+			Where("post_id = ? AND user_id = ? AND id = ? AND deleted_at IS NULL", postId, userId, commentId).
+			Update("deleted_at", time.Now())
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		slog.Error("Failed to delete comment", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// RestoreComment brings back a comment of the given user that was deleted since the given time.
+// ErrPostNotFound is returned when the post is not visible.
+func (repo *SQLiteCommentsRepository) RestoreComment(ctx context.Context, commentId, postId, userId int, since time.Time) (int64, error) {
+	slog.Debug("Restoring comment in the database", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+
+	var rowsAffected int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkPostVisible(tx, postId); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.CommentsDbModel{}).
+			Where("id = ? AND post_id = ? AND user_id = ? AND deleted_at IS NOT NULL", commentId, postId, userId).
+			Where("julianday(deleted_at) >= julianday(?)", since.Format(time.RFC3339Nano)).
+			Update("deleted_at", nil)
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		slog.Error("Failed to restore comment", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// PurgeDeletedComments removes the comments deleted before the given time for good, along with their revisions.
+func (repo *SQLiteCommentsRepository) PurgeDeletedComments(ctx context.Context, before time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).
+		Where("deleted_at IS NOT NULL AND julianday(deleted_at) < julianday(?)", before.Format(time.RFC3339Nano)).
+		Delete(&models.CommentsDbModel{})
 
 	if result.Error != nil {
-		slog.Error("Failed to update comment", slog.String("error", result.Error.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+		slog.Error("Failed to purge deleted comments", slog.String("error", result.Error.Error()))
 		return -1, result.Error
 	}
 
 	return result.RowsAffected, nil
 }
 
-func (repo *SQLiteCommentsRepository) DeleteComments(ctx context.Context, postId, userId, commentId int) (int64, error) {
-	slog.Debug("Deleting comment from the database", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+// ModerateUpdateComment updates the content of any comment on a post, regardless of who wrote it.
+// It must only be reachable by moderators. ErrPostNotFound is returned when the post is not visible.
+func (repo *SQLiteCommentsRepository) ModerateUpdateComment(ctx context.Context, commentId, postId int, comment models.CreateCommentRequest) (int64, error) {
+	slog.Debug("Moderating comment update in the database", slog.Int("commentId", commentId), slog.Int("postId", postId))
+
+	var rowsAffected int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rowsAffected, err = reviseComment(tx, postId, comment.Content, true, "id = ? AND post_id = ?", commentId, postId)
+		return err
+	})
+
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		slog.Error("Failed to moderate comment update", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId))
+		return -1, err
+	}
 
-	result := repo.db.WithContext(ctx).Where("post_id = ? AND user_id = ? AND id = ?", postId, userId, commentId).Delete(&models.CommentsDbModel{})
+	return rowsAffected, nil
+}
+
+// ModerateDeleteComment deletes any comment on a post for good, regardless of who wrote it or whether it was
+// already deleted, so removals by moderators cannot be restored. It must only be reachable by moderators.
+func (repo *SQLiteCommentsRepository) ModerateDeleteComment(ctx context.Context, postId, commentId int) (int64, error) {
+	slog.Debug("Moderating comment deletion in the database", slog.Int("commentId", commentId), slog.Int("postId", postId))
+
+	result := repo.db.WithContext(ctx).Where("post_id = ? AND id = ?", postId, commentId).Delete(&models.CommentsDbModel{})
 
 	if result.Error != nil {
-		slog.Error("Failed to delete comment", slog.String("error", result.Error.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+		slog.Error("Failed to moderate comment deletion", slog.String("error", result.Error.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId))
 		return -1, result.Error
 	}
 
 	return result.RowsAffected, nil
 }
+
+// GetPostAuthorId retrieves the ID of the user who wrote a post, used to flag their comments as OP.
+// It is 0 for posts handed over to the tombstone user, whose comments are never flagged as OP.
+// ErrPostNotFound is returned when the post was deleted, expired or is not published yet.
+func (repo *SQLiteCommentsRepository) GetPostAuthorId(ctx context.Context, postId int) (int, error) {
+	var post models.PostDBModel
+	err := repo.db.WithContext(ctx).
+		Select("CASE WHEN user_id IN (SELECT id FROM users WHERE lookup_hash = ?) THEN 0 ELSE user_id END AS user_id", models.TombstoneLookupHash).
+		Where(posts.VisiblePost, posts.VisibleAt(time.Now())).
+		First(&post, postId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrPostNotFound
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve post author", slog.String("error", err.Error()), slog.Int("postId", postId))
+		return 0, err
+	}
+
+	return post.UserId, nil
+}
+
+// GetPostBoard returns the slug of the board of a post, or an empty string without an error when the post does not exist.
+func (repo *SQLiteCommentsRepository) GetPostBoard(ctx context.Context, postId int) (string, error) {
+	var slugs []string
+	err := repo.db.WithContext(ctx).
+		Model(&models.PostDBModel{}).
+		Joins("JOIN boards ON boards.id = posts.board_id").
+		Where("posts.id = ?", postId).
+		Pluck("boards.slug", &slugs).Error
+	if err != nil {
+		slog.Error("Failed to retrieve post board", slog.String("error", err.Error()), slog.Int("postId", postId))
+		return "", err
+	}
+	if len(slugs) == 0 {
+		return "", nil
+	}
+
+	return slugs[0], nil
+}
+
+// GetThreadParticipants returns the IDs of the users who commented on a post, deleted comments included,
+// in the order of their first comment. It keeps the pseudonyms of the thread stable, see helper.AnnotateCommentAuthors.
+func (repo *SQLiteCommentsRepository) GetThreadParticipants(ctx context.Context, postId int) ([]int, error) {
+	var participants []int
+	err := repo.db.WithContext(ctx).Model(&models.CommentsDbModel{}).
+		Where("post_id = ?", postId).
+		Group("user_id").
+		Order("MIN(id)").
+		Pluck("user_id", &participants).Error
+	if err != nil {
+		slog.Error("Failed to retrieve thread participants", slog.String("error", err.Error()), slog.Int("postId", postId))
+		return nil, err
+	}
+
+	return participants, nil
+}
+
+// GetCommentAuthorId returns the ID of the user who wrote a comment on a post,
+// or 0 without an error when the comment does not exist or was deleted. ErrPostNotFound is returned when the post is not visible.
+func (repo *SQLiteCommentsRepository) GetCommentAuthorId(ctx context.Context, commentId, postId int) (int, error) {
+	if err := checkPostVisible(repo.db.WithContext(ctx), postId); err != nil {
+		return 0, err
+	}
+
+	var comment models.CommentsDbModel
+	err := repo.db.WithContext(ctx).Select("user_id").Where("id = ? AND post_id = ? AND deleted_at IS NULL", commentId, postId).First(&comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve comment author", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId))
+		return 0, err
+	}
+
+	return comment.UserId, nil
+}
+
+// GetCommentRevisions returns the previous versions of a comment, newest first.
+// The content replaced by moderators is only included with includeModerated.
+func (repo *SQLiteCommentsRepository) GetCommentRevisions(ctx context.Context, commentId int, includeModerated bool) ([]models.Revision, error) {
+	revisions := []models.Revision{}
+	query := repo.db.WithContext(ctx).Model(&models.CommentRevisionDBModel{}).Where("comment_id = ?", commentId)
+	if !includeModerated {
+		query = query.Where("by_moderator = ?", false)
+	}
+	err := query.Order("id DESC").Scan(&revisions).Error
+	if err != nil {
+		slog.Error("Failed to retrieve comment revisions", slog.String("error", err.Error()), slog.Int("commentId", commentId))
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// reviseComment replaces the content of the comment matching the condition and keeps the replaced content
+// as a revision. Saving unchanged content leaves the comment untouched but still counts as a match.
+// Deleted comments never match, and ErrPostNotFound is returned when the post is not visible. It has to run inside a transaction.
+func reviseComment(tx *gorm.DB, postId int, content string, byModerator bool, condition string, args ...interface{}) (int64, error) {
+	if err := checkPostVisible(tx, postId); err != nil {
+		return -1, err
+	}
+
+	var current models.CommentsDbModel
+	err := tx.Select("id", "content").Where(condition, args...).Where("deleted_at IS NULL").First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	if current.Content == content {
+		return 1, nil
+	}
+
+	revision := models.CommentRevisionDBModel{CommentId: current.ID, Content: current.Content, ByModerator: byModerator, CreatedAt: time.Now()}
+	if err := tx.Create(&revision).Error; err != nil {
+		return -1, err
+	}
+
+	result := tx.Model(&models.CommentsDbModel{}).Where("id = ?", current.ID).
+		Updates(map[string]interface{}{"content": content, "edited_at": revision.CreatedAt})
+
+	return result.RowsAffected, result.Error
+}
+
+// checkPostVisible returns ErrPostNotFound when the post was deleted, expired or is not published yet, see posts.VisiblePost.
+// Run inside a transaction, the post cannot be deleted before the change is made.
+func checkPostVisible(tx *gorm.DB, postId int) error {
+	var visible int64
+	err := tx.Model(&models.PostDBModel{}).Where("posts.id = ?", postId).Where(posts.VisiblePost, posts.VisibleAt(time.Now())).Count(&visible).Error
+	if err != nil {
+		return err
+	}
+	if visible == 0 {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
@@ -1,17 +1,34 @@
 package comments
 
 import (
+	"anon-confessions/cmd/internal/middleware"
+	"anon-confessions/cmd/internal/models"
+
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterCommentsRoutes registers all routes related to posts.
+// Requests authenticated with an API key need the scope matching each route.
 func RegisterCommentsRoutes(router *gin.RouterGroup, h *CommentsHandler) {
 	commentGroup := router.Group("/posts/:id/comments")
 	{
-		commentGroup.POST("", h.CreateCommentsHandler)
-		commentGroup.GET("", h.GetCommentsCollection)
-		commentGroup.PATCH("/:commentId", h.UpdateCommentHandler)
-		commentGroup.DELETE("/:commentId", h.DeleteCommentHandler)
+		commentGroup.POST("", middleware.RequireScope(models.ScopeCommentsWrite), h.CreateCommentsHandler)
+		commentGroup.GET("", middleware.RequireScope(models.ScopeCommentsRead), h.GetCommentsCollection)
+		commentGroup.PATCH("/:commentId", middleware.RequireScope(models.ScopeCommentsWrite), h.UpdateCommentHandler)
+		commentGroup.GET("/:commentId/revisions", middleware.RequireScope(models.ScopeCommentsRead), h.GetCommentRevisionsHandler)
+		commentGroup.DELETE("/:commentId", middleware.RequireScope(models.ScopeCommentsWrite), h.DeleteCommentHandler)
+		commentGroup.POST("/:commentId/restore", middleware.RequireScope(models.ScopeCommentsWrite), h.RestoreCommentHandler)
+	}
+}
+
+// RegisterAdminCommentsRoutes registers the moderation routes for comments.
+// The router group is expected to only let moderators through.
+func RegisterAdminCommentsRoutes(router *gin.RouterGroup, h *CommentsHandler) {
+	commentGroup := router.Group("/posts/:id/comments")
+	commentGroup.Use(middleware.RequireScope(models.ScopeModerate))
+	{
+		commentGroup.PATCH("/:commentId", h.ModerateUpdateCommentHandler)
+		commentGroup.DELETE("/:commentId", h.ModerateDeleteCommentHandler)
 	}
 }
 
@@ -32,19 +49,22 @@ func RegisterCommentsRoutes(router *gin.RouterGroup, h *CommentsHandler) {
 // @Failure 500 {object} helper.ErrorMessage "Internal server error"
 // @Router /posts/{id}/comments [post]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *CommentsHandler) createCommentsHandler(c *gin.Context) {}
 
 // GetCommentsCollection retrieves a collection of comments for a specific post.
 // @Summary Retrieve comments for a post
-// @Description Fetches all comments associated with a specific post ID. Requires authentication using X-Account-Number.
+// @Description Fetches all comments associated with a specific post ID. Each comment carries a per-post pseudonym of its author and an isOP flag. Requires authentication using X-Account-Number.
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} models.GetCommentsCollection "Comments retrieved successfully"
+// @Failure 404 {object} helper.ErrorMessage "Post not found"
 // @Failure 500 {object} helper.ErrorMessage "Failed to retrieve comments"
 // @Router /posts/{id}/comments [get]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *CommentsHandler) getCommentsCollection(c *gin.Context) {}
 
 // @Summary Update a comment
@@ -62,10 +82,28 @@ func (h *CommentsHandler) getCommentsCollection(c *gin.Context) {}
 // @Failure 500 {object} helper.ErrorMessage "Failed to update comment"
 // @Router /posts/{id}/comments/{commentId} [patch]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *CommentsHandler) updateCommentsHandler(c *gin.Context) {}
 
+// @Summary Retrieve the edit history of a comment
+// @Description Lists the previous versions of a comment, newest first. Only the author and moderators can see them unless PUBLIC_EDIT_HISTORY is enabled. Content removed by a moderator edit is only listed for the author and moderators.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param commentId path int true "Comment ID"
+// @Success 200 {array} models.Revision "Revisions retrieved successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid post ID or comment ID"
+// @Failure 401 {object} helper.ErrorMessage "Invalid or missing X-Account-Number"
+// @Failure 403 {object} helper.ErrorMessage "Edit history not visible"
+// @Failure 404 {object} helper.ErrorMessage "Post or comment not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve revisions"
+// @Router /posts/{id}/comments/{commentId}/revisions [get]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *CommentsHandler) getCommentRevisionsHandler(c *gin.Context) {}
+
 // @Summary      Delete a comment
-// @Description  Deletes a specific comment from a post. The user must be authenticated and authorized to delete the comment.
+// @Description  Deletes a specific comment from a post. The user must be authenticated and authorized to delete the comment. The author can restore it within RESTORE_WINDOW, after which it is removed for good.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
@@ -77,4 +115,58 @@ func (h *CommentsHandler) updateCommentsHandler(c *gin.Context) {}
 // @Failure      500 {object} helper.ErrorMessage   "Failed to delete comment"
 // @Router       /posts/{id}/comments/{commentId} [delete]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *CommentsHandler) deleteComment(c *gin.Context) {}
+
+// @Summary      Restore a deleted comment
+// @Description  Brings back a comment the logged-in user deleted, as long as RESTORE_WINDOW has not passed since.
+// @Tags         comments
+// @Produce      json
+// @Param        id          path      int  true  "Post ID"
+// @Param        commentId   path      int  true  "Comment ID"
+// @Success      200 {object} helper.SuccessMessage "Comment restored successfully"
+// @Failure      400 {object} helper.ErrorMessage   "Invalid post ID or comment ID"
+// @Failure      404 {object} helper.ErrorMessage   "Post not found or no deleted comment to restore"
+// @Failure      500 {object} helper.ErrorMessage   "Failed to restore comment"
+// @Router       /posts/{id}/comments/{commentId}/restore [post]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *CommentsHandler) restoreComment(c *gin.Context) {}
+
+// @Summary Moderate a comment
+// @Description Replaces the content of any comment, regardless of its author. Requires the moderator or admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param commentId path int true "Comment ID"
+// @Param body body models.CreateCommentRequest true "Updated comment content"
+// @Success 200 {object} helper.SuccessMessage "Comment updated successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body or input"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "Post or comment not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to update comment"
+// @Router /admin/posts/{id}/comments/{commentId} [patch]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *CommentsHandler) moderateUpdateCommentHandler(c *gin.Context) {}
+
+// @Summary      Remove a comment
+// @Description  Deletes any comment from a post, regardless of its author. Requires the moderator or admin role.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int  true  "Post ID"
+// @Param        commentId   path      int  true  "Comment ID"
+// @Success      200 {object} helper.SuccessMessage "Comment deleted successfully"
+// @Failure      401 {object} helper.ErrorMessage   "Missing or invalid credentials"
+// @Failure      403 {object} helper.ErrorMessage   "Insufficient permissions"
+// @Failure      404 {object} helper.ErrorMessage   "Comment not found"
+// @Failure      500 {object} helper.ErrorMessage   "Failed to delete comment"
+// @Router       /admin/posts/{id}/comments/{commentId} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *CommentsHandler) moderateDeleteComment(c *gin.Context) {}
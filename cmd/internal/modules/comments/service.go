@@ -1,22 +1,36 @@
 package comments
 
 import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
 	"anon-confessions/cmd/internal/websocket"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 )
 
+var (
+	// ErrCommentNotFound is returned when a comment does not exist on the given post.
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrPostNotFound is returned when the post of a comment does not exist or is not visible.
+	ErrPostNotFound = errors.New("post not found")
+	// ErrHistoryNotVisible is returned when someone other than the author or a moderator asks for the edit history
+	// while it is not public.
+	ErrHistoryNotVisible = errors.New("edit history not visible")
+)
+
 type CommentsService struct {
 	CommentsRepo CommentsRepository
+	cfg          *config.Config
 	hub          *websocket.Hub
 }
 
-func NewCommentsService(CommentsRepo CommentsRepository, hub *websocket.Hub) *CommentsService {
-	return &CommentsService{CommentsRepo: CommentsRepo, hub: hub}
+func NewCommentsService(CommentsRepo CommentsRepository, cfg *config.Config, hub *websocket.Hub) *CommentsService {
+	return &CommentsService{CommentsRepo: CommentsRepo, cfg: cfg, hub: hub}
 }
 
 func (s *CommentsService) CreateComments(ctx context.Context, postId, userId int, comment models.CreateCommentRequest) error {
@@ -30,31 +44,43 @@ func (s *CommentsService) CreateComments(ctx context.Context, postId, userId int
 	}
 
 	err := s.CommentsRepo.CreateComments(ctx, commentsDbModel)
+	if errors.Is(err, ErrPostNotFound) {
+		return err
+	}
 	if err != nil {
 		slog.Error("Failed to create comment in repository", slog.String("error", err.Error()), slog.Int("postId", postId), slog.Int("userId", userId))
 		return err
 	}
 
-	// Broadcast to all connected clients
-	wsMsg := models.WebSocketMessage{
+	board, err := s.CommentsRepo.GetPostBoard(ctx, postId)
+	if err != nil {
+		slog.Warn("Failed to retrieve post board for the comment broadcast", slog.String("error", err.Error()), slog.Int("postId", postId))
+	}
+
+	// Broadcast to the clients following every board and those following the board of the post
+	s.broadcast(models.WebSocketMessage{
 		Type:    "newComment",
 		Message: "New comment created.",
 		Content: map[string]interface{}{
 			"postId": postId,
+			"board":  board,
 		},
-	}
-
-	marshalledWSMsg, err := json.Marshal(wsMsg)
-	if err != nil {
-		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()), slog.Any("message", wsMsg))
-		return nil
-	}
-	s.hub.Broadcast <- marshalledWSMsg
+	}, board)
 
 	return nil
 }
 
+// GetCommentsCollection retrieves the comments of a post, each carrying the per-post pseudonym of its author.
+// It returns ErrPostNotFound when the post is not visible.
 func (s *CommentsService) GetCommentsCollection(ctx context.Context, postId int) (*models.GetCommentsCollection, error) {
+	postAuthorId, err := s.CommentsRepo.GetPostAuthorId(ctx, postId)
+	if errors.Is(err, ErrPostNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve post author", slog.String("error", err.Error()), slog.Int("postId", postId))
+		return nil, fmt.Errorf("failed to retrieve comments: %w", err)
+	}
 
 	commentsCollection, err := s.CommentsRepo.GetCommentsCollection(ctx, postId)
 	if err != nil {
@@ -62,13 +88,28 @@ func (s *CommentsService) GetCommentsCollection(ctx context.Context, postId int)
 		return nil, fmt.Errorf("failed to retrieve comments: %w", err)
 	}
 
+	if commentsCollection == nil {
+		return nil, nil
+	}
+
+	participants, err := s.CommentsRepo.GetThreadParticipants(ctx, postId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve comments: %w", err)
+	}
+
+	helper.AnnotateCommentAuthors(s.cfg.Content.PseudonymSecret, postId, postAuthorId, participants, *commentsCollection)
+
 	return commentsCollection, nil
 }
 
+// UpdateComments replaces the content of a comment of the user. It returns ErrPostNotFound when the post is not visible.
 func (s *CommentsService) UpdateComments(ctx context.Context, commentId, postId, userId int, comment models.CreateCommentRequest) (int64, error) {
 	slog.Debug("Updating comment", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
 
 	rowsAffected, err := s.CommentsRepo.UpdateComments(ctx, commentId, postId, userId, comment)
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
 	if err != nil {
 		slog.Error("Failed to update comment", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
 		return -1, fmt.Errorf("failed to update comment: %w", err)
@@ -77,10 +118,41 @@ func (s *CommentsService) UpdateComments(ctx context.Context, commentId, postId,
 	return rowsAffected, nil
 }
 
+// GetCommentRevisions returns the previous versions of a comment, newest first. Only the author and moderators
+// can see them, unless the edit history is public. Content removed by a moderator edit is only shown to them as well.
+// It returns ErrPostNotFound when the post is not visible.
+func (s *CommentsService) GetCommentRevisions(ctx context.Context, commentId, postId, userId int, isModerator bool) ([]models.Revision, error) {
+	authorId, err := s.CommentsRepo.GetCommentAuthorId(ctx, commentId, postId)
+	if errors.Is(err, ErrPostNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve comment: %w", err)
+	}
+	if authorId == 0 {
+		return nil, ErrCommentNotFound
+	}
+	if authorId != userId && !isModerator && !s.cfg.Content.PublicEditHistory {
+		return nil, ErrHistoryNotVisible
+	}
+
+	revisions, err := s.CommentsRepo.GetCommentRevisions(ctx, commentId, isModerator || authorId == userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// DeleteComments marks a comment of the user as deleted. It can be restored until the restore window has passed.
+// It returns ErrPostNotFound when the post is not visible.
 func (s *CommentsService) DeleteComments(ctx context.Context, postId, userId, commentId int) (int64, error) {
 	slog.Debug("Deleting comment", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
 
 	rowsAffected, err := s.CommentsRepo.DeleteComments(ctx, postId, userId, commentId)
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
 	if err != nil {
 		slog.Error("Failed to delete comment", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
 		return -1, fmt.Errorf("failed to delete comment: %w", err)
@@ -88,3 +160,95 @@ func (s *CommentsService) DeleteComments(ctx context.Context, postId, userId, co
 
 	return rowsAffected, nil
 }
+
+// RestoreComment brings back a comment the user deleted within the restore window.
+// It returns ErrPostNotFound when the post is not visible.
+func (s *CommentsService) RestoreComment(ctx context.Context, commentId, postId, userId int) (int64, error) {
+	slog.Debug("Restoring comment", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("userId", userId))
+
+	rowsAffected, err := s.CommentsRepo.RestoreComment(ctx, commentId, postId, userId, time.Now().Add(-s.cfg.Content.RestoreWindow))
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to restore comment: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// PurgeDeletedComments removes the comments deleted longer ago than the restore window for good.
+// It runs as a background job.
+func (s *CommentsService) PurgeDeletedComments(ctx context.Context) error {
+	rowsAffected, err := s.CommentsRepo.PurgeDeletedComments(ctx, time.Now().Add(-s.cfg.Content.RestoreWindow))
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted comments: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("Purged deleted comments", slog.Int64("count", rowsAffected))
+	}
+	return nil
+}
+
+// ModerateCommentUpdate lets a moderator replace the content of any comment on a visible post.
+// It returns ErrPostNotFound when the post is not visible.
+func (s *CommentsService) ModerateCommentUpdate(ctx context.Context, commentId, postId, moderatorId int, comment models.CreateCommentRequest) (int64, error) {
+	slog.Info("Moderator updating comment", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("moderatorId", moderatorId))
+
+	rowsAffected, err := s.CommentsRepo.ModerateUpdateComment(ctx, commentId, postId, comment)
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
+	if err != nil {
+		slog.Error("Failed to moderate comment update", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId))
+		return -1, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ModerateCommentDeletion lets a moderator delete any comment and tells connected clients to drop it.
+func (s *CommentsService) ModerateCommentDeletion(ctx context.Context, postId, commentId, moderatorId int) (int64, error) {
+	slog.Info("Moderator deleting comment", slog.Int("commentId", commentId), slog.Int("postId", postId), slog.Int("moderatorId", moderatorId))
+
+	board, err := s.CommentsRepo.GetPostBoard(ctx, postId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve post board: %w", err)
+	}
+
+	rowsAffected, err := s.CommentsRepo.ModerateDeleteComment(ctx, postId, commentId)
+	if err != nil {
+		slog.Error("Failed to moderate comment deletion", slog.String("error", err.Error()), slog.Int("commentId", commentId), slog.Int("postId", postId))
+		return -1, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	s.broadcast(models.WebSocketMessage{
+		Type:    "contentRemoved",
+		Message: "Content was removed",
+		Content: map[string]interface{}{
+			"board":      board,
+			"postIds":    []int{},
+			"commentIds": []int{commentId},
+		},
+	}, board)
+
+	return rowsAffected, nil
+}
+
+// broadcast sends a message to the clients following every board and, unless board is empty,
+// to the clients following that board.
+func (s *CommentsService) broadcast(wsMsg models.WebSocketMessage, board string) {
+	marshalledWSMsg, err := json.Marshal(wsMsg)
+	if err != nil {
+		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()), slog.Any("message", wsMsg))
+		return
+	}
+	s.hub.Broadcast <- marshalledWSMsg
+	if board != "" {
+		s.hub.BoardBroadcast <- websocket.BoardMessage{Board: board, Message: marshalledWSMsg}
+	}
+}
@@ -0,0 +1,207 @@
+// Package drafts_test contains integration tests for saving, editing and publishing private drafts.
+package drafts_test
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
+	"anon-confessions/cmd/internal/modules/drafts"
+	"anon-confessions/cmd/internal/modules/posts"
+	"anon-confessions/cmd/internal/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var testConfig = &config.Config{
+	Content: config.ContentConfig{PseudonymSecret: "test-pseudonym-secret", MaxDrafts: 2, DraftTTL: time.Hour},
+}
+
+// draftsTest serves the draft routes as the user in userID, so tests can switch between users.
+type draftsTest struct {
+	t      *testing.T
+	router *gin.Engine
+	db     *gorm.DB
+	userID int
+}
+
+// setupDraftsTest registers the draft routes behind a mock authentication middleware.
+func setupDraftsTest(t *testing.T, userID int) *draftsTest {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	test := &draftsTest{t: t, db: db, userID: userID}
+
+	hub := websocket.NewHub()
+	go hub.Run()
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	postsService := posts.NewPostsService(posts.NewSQLitePostsRepository(db), testConfig, hub)
+	draftsService := drafts.NewDraftsService(drafts.NewSQLiteDraftsRepository(db), testConfig)
+	handler := drafts.NewDraftsHandler(draftsService, postsService, boardsService)
+
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", test.userID)
+		c.Next()
+	})
+	drafts.RegisterDraftsRoutes(authenticated, handler)
+	test.router = router
+
+	return test
+}
+
+// request sends a request to the draft routes and checks its status code.
+func (d *draftsTest) request(method, url string, body interface{}, expectedCode int) *httptest.ResponseRecorder {
+	d.t.Helper()
+
+	var bodyBytes []byte
+	if body != nil {
+		bodyBytes, _ = json.Marshal(body)
+	}
+	w, req := testutils.HTTPTestRequest(method, url, bodyBytes)
+	d.router.ServeHTTP(w, req)
+	if w.Code != expectedCode {
+		d.t.Fatalf("Expected status code %d for %s %s, got %d: %s", expectedCode, method, url, w.Code, w.Body.String())
+	}
+	return w
+}
+
+// create saves a draft and returns it.
+func (d *draftsTest) create(req models.DraftRequest) models.Draft {
+	d.t.Helper()
+
+	var draft models.Draft
+	json.Unmarshal(d.request(http.MethodPost, "/api/v1/users/me/drafts", req, http.StatusCreated).Body.Bytes(), &draft)
+	return draft
+}
+
+// list returns the drafts of the current user.
+func (d *draftsTest) list() []models.Draft {
+	d.t.Helper()
+
+	var list []models.Draft
+	json.Unmarshal(d.request(http.MethodGet, "/api/v1/users/me/drafts", nil, http.StatusOK).Body.Bytes(), &list)
+	return list
+}
+
+// TestDraftsCRUD tests if drafts can be saved, listed, edited and deleted by their owner only.
+func TestDraftsCRUD(t *testing.T) {
+	d := setupDraftsTest(t, 101)
+
+	d.request(http.MethodPost, "/api/v1/users/me/drafts", models.DraftRequest{Content: "x"}, http.StatusBadRequest)
+	d.request(http.MethodPost, "/api/v1/users/me/drafts", models.DraftRequest{Content: "Lost board", Board: "no-such-board"}, http.StatusNotFound)
+
+	draft := d.create(models.DraftRequest{Content: "Dear diary"})
+	if draft.Board != models.DefaultBoardSlug {
+		t.Errorf("Expected the draft to go to the %q board, got %q", models.DefaultBoardSlug, draft.Board)
+	}
+	if lifetime := draft.ExpiresAt.Sub(draft.UpdatedAt); lifetime != time.Hour {
+		t.Errorf("Expected the draft to expire an hour after its last update, got %v", lifetime)
+	}
+
+	url := fmt.Sprintf("/api/v1/users/me/drafts/%d", draft.ID)
+	var updated models.Draft
+	json.Unmarshal(d.request(http.MethodPut, url, models.DraftRequest{Content: "Dear diary, today"}, http.StatusOK).Body.Bytes(), &updated)
+	if updated.Content != "Dear diary, today" || !updated.UpdatedAt.After(draft.UpdatedAt) || !updated.ExpiresAt.After(draft.ExpiresAt) {
+		t.Errorf("Expected the update to replace the content and push back the expiry, got %+v", updated)
+	}
+	if list := d.list(); len(list) != 1 || list[0].ID != draft.ID {
+		t.Errorf("Expected the draft to be listed, got %+v", list)
+	}
+
+	// Other users cannot see or touch the draft
+	d.userID = 102
+	if list := d.list(); len(list) != 0 {
+		t.Errorf("Expected no drafts for another user, got %+v", list)
+	}
+	d.request(http.MethodGet, url, nil, http.StatusNotFound)
+	d.request(http.MethodPut, url, models.DraftRequest{Content: "Hijacked"}, http.StatusNotFound)
+	d.request(http.MethodDelete, url, nil, http.StatusNotFound)
+	d.request(http.MethodPost, url+"/publish", nil, http.StatusNotFound)
+
+	d.userID = 101
+	var fetched models.Draft
+	json.Unmarshal(d.request(http.MethodGet, url, nil, http.StatusOK).Body.Bytes(), &fetched)
+	if fetched.Content != "Dear diary, today" {
+		t.Errorf("Expected the draft to be left untouched by another user, got %+v", fetched)
+	}
+	d.request(http.MethodDelete, url, nil, http.StatusOK)
+	d.request(http.MethodGet, url, nil, http.StatusNotFound)
+}
+
+// TestDraftLimitAndExpiry tests if the number of drafts is capped and expired drafts are hidden, not counted and purged.
+func TestDraftLimitAndExpiry(t *testing.T) {
+	d := setupDraftsTest(t, 201)
+
+	first := d.create(models.DraftRequest{Content: "First thoughts"})
+	d.create(models.DraftRequest{Content: "Second thoughts"})
+	d.request(http.MethodPost, "/api/v1/users/me/drafts", models.DraftRequest{Content: "Third thoughts"}, http.StatusConflict)
+
+	// Expired drafts are hidden right away and make room for new ones
+	d.db.Model(&models.DraftDBModel{}).Where("id = ?", first.ID).Update("expires_at", time.Now().Add(-time.Second))
+	if list := d.list(); len(list) != 1 {
+		t.Errorf("Expected the expired draft to be hidden, got %+v", list)
+	}
+	d.request(http.MethodGet, fmt.Sprintf("/api/v1/users/me/drafts/%d", first.ID), nil, http.StatusNotFound)
+	d.create(models.DraftRequest{Content: "Third thoughts"})
+
+	service := drafts.NewDraftsService(drafts.NewSQLiteDraftsRepository(d.db), testConfig)
+	if err := service.PurgeExpiredDrafts(context.Background()); err != nil {
+		t.Fatalf("Failed to purge expired drafts: %v", err)
+	}
+	var count int64
+	d.db.Model(&models.DraftDBModel{}).Where("user_id = ?", d.userID).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected the purge to leave 2 drafts, got %d", count)
+	}
+}
+
+// TestPublishDraft tests if publishing a draft creates a post on its board and removes the draft, only once.
+func TestPublishDraft(t *testing.T) {
+	d := setupDraftsTest(t, 301)
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(d.db))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "drafts-test"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	draft := d.create(models.DraftRequest{Content: "Finally ready to say it", Board: board.Slug})
+	url := fmt.Sprintf("/api/v1/users/me/drafts/%d", draft.ID)
+	d.request(http.MethodPost, url+"/publish", nil, http.StatusCreated)
+
+	var post models.PostDBModel
+	if err := d.db.Where("board_id = ?", board.ID).Last(&post).Error; err != nil {
+		t.Fatalf("Expected a post on board %q: %v", board.Slug, err)
+	}
+	if post.Content != draft.Content || post.UserId != d.userID {
+		t.Errorf("Expected the post to carry the draft content and author, got %+v", post)
+	}
+	d.request(http.MethodGet, url, nil, http.StatusNotFound)
+	d.request(http.MethodPost, url+"/publish", nil, http.StatusNotFound)
+
+	// A request that read the draft before another one published it cannot publish it again
+	draft = d.create(models.DraftRequest{Content: "Only once please", Board: board.Slug})
+	postsService := posts.NewPostsService(posts.NewSQLitePostsRepository(d.db), testConfig, nil)
+	request := models.PostRequest{Content: draft.Content, Board: board.Slug}
+	d.request(http.MethodPost, fmt.Sprintf("/api/v1/users/me/drafts/%d/publish", draft.ID), nil, http.StatusCreated)
+	if err := postsService.CreatePosts(context.Background(), request, *board, d.userID, draft.ID); !errors.Is(err, posts.ErrDraftNotFound) {
+		t.Errorf("Expected ErrDraftNotFound for publishing a draft twice, got %v", err)
+	}
+	var count int64
+	d.db.Model(&models.PostDBModel{}).Where("board_id = ? AND content = ?", board.ID, draft.Content).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the draft to be published once, got %d posts", count)
+	}
+}
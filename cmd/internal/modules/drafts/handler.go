@@ -0,0 +1,189 @@
+package drafts
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
+	"anon-confessions/cmd/internal/modules/posts"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DraftsHandler struct {
+	draftsService *DraftsService
+	postsService  *posts.PostsService
+	boardsService *boards.BoardsService
+}
+
+func NewDraftsHandler(draftsService *DraftsService, postsService *posts.PostsService, boardsService *boards.BoardsService) *DraftsHandler {
+	return &DraftsHandler{draftsService: draftsService, postsService: postsService, boardsService: boardsService}
+}
+
+func (h *DraftsHandler) CreateDraftHandler(c *gin.Context) {
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.DraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for creating a draft", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+	board, ok := h.resolveBoard(c, req.Board)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftsService.CreateDraft(c.Request.Context(), userId, req, *board)
+	if errors.Is(err, ErrDraftLimitReached) {
+		c.JSON(http.StatusConflict, helper.ErrorMessage{Message: "Draft limit reached. Publish or delete a draft first."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to create draft", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to create draft."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, draft)
+}
+
+func (h *DraftsHandler) GetDraftsHandler(c *gin.Context) {
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	drafts, err := h.draftsService.GetDrafts(c.Request.Context(), userId)
+	if err != nil {
+		slog.Error("Failed to retrieve drafts", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve drafts."})
+		return
+	}
+
+	c.JSON(http.StatusOK, drafts)
+}
+
+func (h *DraftsHandler) GetDraftHandler(c *gin.Context) {
+	id := helper.ParseIDParam(c, "id")
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	draft, err := h.draftsService.GetDraft(c.Request.Context(), id, userId)
+	if errors.Is(err, ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Draft does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve draft."})
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *DraftsHandler) UpdateDraftHandler(c *gin.Context) {
+	id := helper.ParseIDParam(c, "id")
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.DraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for updating a draft", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+	board, ok := h.resolveBoard(c, req.Board)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftsService.UpdateDraft(c.Request.Context(), id, userId, req, *board)
+	if errors.Is(err, ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Draft does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to update draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update draft."})
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *DraftsHandler) DeleteDraftHandler(c *gin.Context) {
+	id := helper.ParseIDParam(c, "id")
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	err := h.draftsService.DeleteDraft(c.Request.Context(), id, userId)
+	if errors.Is(err, ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Draft does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to delete draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to delete draft."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Draft deleted successfully."})
+}
+
+// PublishDraftHandler turns a draft into a post on its board, announced like any new post, and removes the draft.
+// The draft is removed in the same transaction as the post is created, so a failed publish never loses its content
+// and a draft published twice at once only becomes one post; the other request gets a 404.
+func (h *DraftsHandler) PublishDraftHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := helper.ParseIDParam(c, "id")
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	draft, err := h.draftsService.GetDraft(ctx, id, userId)
+	if errors.Is(err, ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Draft does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve draft."})
+		return
+	}
+	board, ok := h.resolveBoard(c, draft.Board)
+	if !ok {
+		return
+	}
+
+	post := models.PostRequest{Content: draft.Content, Board: board.Slug}
+	err = h.postsService.CreatePosts(ctx, post, *board, userId, id)
+	if errors.Is(err, posts.ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Draft does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to publish draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Post Creation Failed"})
+		return
+	}
+
+	slog.Info("Draft published", slog.Int("draftId", id), slog.Int("userId", userId))
+	c.JSON(http.StatusCreated, helper.SuccessMessage{Message: "Post Created Successfully"})
+}
+
+// resolveBoard looks up the board of a draft by its slug, the general board when it is empty. When it does not exist
+// or the lookup fails, the error response is written and false is returned.
+func (h *DraftsHandler) resolveBoard(c *gin.Context, slug string) (*models.Board, bool) {
+	if slug == "" {
+		slug = models.DefaultBoardSlug
+	}
+
+	board, err := h.boardsService.GetBoard(c.Request.Context(), slug)
+	if errors.Is(err, boards.ErrBoardNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Board does not exist."})
+		return nil, false
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve board", slog.String("slug", slug), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve board."})
+		return nil, false
+	}
+
+	return board, true
+}
@@ -0,0 +1,136 @@
+package drafts
+
+import (
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// liveDraft is the condition for drafts that have not expired yet, given the current time.
+const liveDraft = "julianday(expires_at) > julianday(?)"
+
+type DraftsRepository interface {
+	CreateDraft(context.Context, *models.DraftDBModel, int) (int64, error)
+	GetDrafts(context.Context, int, time.Time) ([]models.DraftDBModel, error)
+	GetDraft(context.Context, int, int, time.Time) (*models.DraftDBModel, error)
+	UpdateDraft(context.Context, *models.DraftDBModel) (int64, error)
+	DeleteDraft(context.Context, int, int) (int64, error)
+	PurgeExpiredDrafts(context.Context, time.Time) (int64, error)
+}
+
+type SQLiteDraftsRepository struct {
+	db *gorm.DB
+}
+
+func NewSQLiteDraftsRepository(db *gorm.DB) *SQLiteDraftsRepository {
+	return &SQLiteDraftsRepository{db: db}
+}
+
+// CreateDraft inserts a draft unless its author already has limit drafts that have not expired.
+// The count and the insert share a transaction, so concurrent requests cannot go past the limit.
+// It returns 0 rows affected when the limit is reached.
+func (repo *SQLiteDraftsRepository) CreateDraft(ctx context.Context, draft *models.DraftDBModel, limit int) (int64, error) {
+	var rowsAffected int64
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&models.DraftDBModel{}).
+			Where("user_id = ? AND "+liveDraft, draft.UserId, draft.CreatedAt.Format(time.RFC3339Nano)).
+			Count(&count).Error
+		if err != nil || count >= int64(limit) {
+			return err
+		}
+
+		result := tx.Create(draft)
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+
+	if err != nil {
+		slog.Error("Failed to create draft", slog.Int("userId", draft.UserId), slog.String("error", err.Error()))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// GetDrafts returns the drafts of a user that have not expired by the given time, most recently updated first.
+func (repo *SQLiteDraftsRepository) GetDrafts(ctx context.Context, userId int, now time.Time) ([]models.DraftDBModel, error) {
+	var drafts []models.DraftDBModel
+	err := repo.db.WithContext(ctx).
+		Where("user_id = ? AND "+liveDraft, userId, now.Format(time.RFC3339Nano)).
+		Order("julianday(updated_at) DESC, id DESC").
+		Find(&drafts).Error
+	if err != nil {
+		slog.Error("Failed to retrieve drafts", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return drafts, nil
+}
+
+// GetDraft returns nil without an error when the user has no such draft or it has expired by the given time.
+func (repo *SQLiteDraftsRepository) GetDraft(ctx context.Context, id, userId int, now time.Time) (*models.DraftDBModel, error) {
+	var draft models.DraftDBModel
+	err := repo.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND "+liveDraft, id, userId, now.Format(time.RFC3339Nano)).
+		First(&draft).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve draft", slog.Int("draftId", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+// UpdateDraft replaces the content and board of a draft of its author, as long as it has not expired by the new update time.
+func (repo *SQLiteDraftsRepository) UpdateDraft(ctx context.Context, draft *models.DraftDBModel) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.DraftDBModel{}).
+		Where("id = ? AND user_id = ? AND "+liveDraft, draft.ID, draft.UserId, draft.UpdatedAt.Format(time.RFC3339Nano)).
+		Updates(map[string]interface{}{
+			"content":    draft.Content,
+			"board":      draft.Board,
+			"updated_at": draft.UpdatedAt,
+			"expires_at": draft.ExpiresAt,
+		})
+
+	if result.Error != nil {
+		slog.Error("Failed to update draft", slog.Int("draftId", draft.ID), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteDraft removes a draft of its author. Expired drafts that are not purged yet can still be deleted.
+func (repo *SQLiteDraftsRepository) DeleteDraft(ctx context.Context, id, userId int) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&models.DraftDBModel{})
+
+	if result.Error != nil {
+		slog.Error("Failed to delete draft", slog.Int("draftId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// PurgeExpiredDrafts removes the drafts that expired by the given time.
+func (repo *SQLiteDraftsRepository) PurgeExpiredDrafts(ctx context.Context, now time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).
+		Where("julianday(expires_at) <= julianday(?)", now.Format(time.RFC3339Nano)).
+		Delete(&models.DraftDBModel{})
+
+	if result.Error != nil {
+		slog.Error("Failed to purge expired drafts", slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
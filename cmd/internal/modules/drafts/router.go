@@ -0,0 +1,113 @@
+package drafts
+
+import "github.com/gin-gonic/gin"
+
+// RegisterDraftsRoutes registers the routes for managing the drafts of the logged-in user.
+// Drafts are private to the account, so the router group is expected to turn away API keys.
+func RegisterDraftsRoutes(router *gin.RouterGroup, h *DraftsHandler) {
+	draftGroup := router.Group("/users/me/drafts")
+	{
+		draftGroup.POST("", h.CreateDraftHandler)
+		draftGroup.GET("", h.GetDraftsHandler)
+		draftGroup.GET("/:id", h.GetDraftHandler)
+		draftGroup.PUT("/:id", h.UpdateDraftHandler)
+		draftGroup.DELETE("/:id", h.DeleteDraftHandler)
+		draftGroup.POST("/:id/publish", h.PublishDraftHandler)
+	}
+}
+
+// Swagger documentation.
+
+// CreateDraftHandler handles saving a new draft.
+// @Summary Create a draft
+// @Description Saves a draft only its author can see. A user keeps at most MAX_DRAFTS drafts, each expiring DRAFT_TTL after its last update.
+// @Tags drafts
+// @Accept json
+// @Produce json
+// @Param draft body models.DraftRequest true "Draft content"
+// @Success 201 {object} models.Draft "Draft created successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Board not found"
+// @Failure 409 {object} helper.ErrorMessage "Draft limit reached"
+// @Failure 500 {object} helper.ErrorMessage "Failed to create draft"
+// @Router /users/me/drafts [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+func createDraftHandler(c *gin.Context) {}
+
+// GetDraftsHandler handles listing the drafts of the logged-in user.
+// @Summary List drafts
+// @Description Lists the drafts of the logged-in user that have not expired, most recently updated first.
+// @Tags drafts
+// @Produce json
+// @Success 200 {array} models.Draft "Drafts retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve drafts"
+// @Router /users/me/drafts [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func getDraftsHandler(c *gin.Context) {}
+
+// GetDraftHandler handles retrieving a draft.
+// @Summary Retrieve a draft
+// @Description Retrieves a draft of the logged-in user. Drafts of other users are reported as not found.
+// @Tags drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Success 200 {object} models.Draft "Draft retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Draft not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve draft"
+// @Router /users/me/drafts/{id} [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func getDraftHandler(c *gin.Context) {}
+
+// UpdateDraftHandler handles replacing a draft.
+// @Summary Update a draft
+// @Description Replaces the content and board of a draft of the logged-in user and pushes its expiry back to DRAFT_TTL from now.
+// @Tags drafts
+// @Accept json
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Param draft body models.DraftRequest true "Draft content"
+// @Success 200 {object} models.Draft "Draft updated successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Draft or board not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to update draft"
+// @Router /users/me/drafts/{id} [put]
+// @security BearerAuth
+// @security AccountNumberAuth
+func updateDraftHandler(c *gin.Context) {}
+
+// DeleteDraftHandler handles deleting a draft.
+// @Summary Delete a draft
+// @Description Deletes a draft of the logged-in user for good.
+// @Tags drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Success 200 {object} helper.SuccessMessage "Draft deleted successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Draft not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to delete draft"
+// @Router /users/me/drafts/{id} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+func deleteDraftHandler(c *gin.Context) {}
+
+// PublishDraftHandler handles publishing a draft.
+// @Summary Publish a draft
+// @Description Turns a draft of the logged-in user into a post on its board, announced like any new post. The draft is removed as the post is created, so it is only published once.
+// @Tags drafts
+// @Produce json
+// @Param id path int true "Draft ID"
+// @Success 201 {object} helper.SuccessMessage "Post created successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Draft or board not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to create post"
+// @Router /users/me/drafts/{id}/publish [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+func publishDraftHandler(c *gin.Context) {}
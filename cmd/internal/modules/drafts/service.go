@@ -0,0 +1,138 @@
+package drafts
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrDraftNotFound is returned when the user has no draft with the given ID, or it has expired.
+	ErrDraftNotFound = errors.New("draft not found")
+	// ErrDraftLimitReached is returned when creating a draft while the user already keeps the maximum number of drafts.
+	ErrDraftLimitReached = errors.New("draft limit reached")
+)
+
+type DraftsService struct {
+	draftsRepo DraftsRepository
+	cfg        *config.Config
+}
+
+func NewDraftsService(draftsRepo DraftsRepository, cfg *config.Config) *DraftsService {
+	return &DraftsService{draftsRepo: draftsRepo, cfg: cfg}
+}
+
+// CreateDraft saves a new draft for the given board. It expires DraftTTL from now unless it is updated.
+func (s *DraftsService) CreateDraft(ctx context.Context, userId int, req models.DraftRequest, board models.Board) (*models.Draft, error) {
+	now := time.Now()
+	draft := models.DraftDBModel{
+		UserId:    userId,
+		Content:   req.Content,
+		Board:     board.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.cfg.Content.DraftTTL),
+	}
+
+	rowsAffected, err := s.draftsRepo.CreateDraft(ctx, &draft, s.cfg.Content.MaxDrafts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create draft: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, ErrDraftLimitReached
+	}
+
+	slog.Info("Draft created", slog.Int("draftId", draft.ID), slog.Int("userId", userId))
+	return toDraft(draft), nil
+}
+
+// GetDrafts returns the drafts of the user, most recently updated first.
+func (s *DraftsService) GetDrafts(ctx context.Context, userId int) ([]models.Draft, error) {
+	rows, err := s.draftsRepo.GetDrafts(ctx, userId, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve drafts: %w", err)
+	}
+
+	drafts := make([]models.Draft, 0, len(rows))
+	for _, row := range rows {
+		drafts = append(drafts, *toDraft(row))
+	}
+	return drafts, nil
+}
+
+// GetDraft returns a draft of the user. Drafts of other users are reported as not found.
+func (s *DraftsService) GetDraft(ctx context.Context, id, userId int) (*models.Draft, error) {
+	draft, err := s.draftsRepo.GetDraft(ctx, id, userId, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve draft: %w", err)
+	}
+	if draft == nil {
+		return nil, ErrDraftNotFound
+	}
+
+	return toDraft(*draft), nil
+}
+
+// UpdateDraft replaces the content and board of a draft and pushes its expiry back to DraftTTL from now.
+func (s *DraftsService) UpdateDraft(ctx context.Context, id, userId int, req models.DraftRequest, board models.Board) (*models.Draft, error) {
+	now := time.Now()
+	draft := models.DraftDBModel{
+		ID:        id,
+		UserId:    userId,
+		Content:   req.Content,
+		Board:     board.Slug,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(s.cfg.Content.DraftTTL),
+	}
+
+	rowsAffected, err := s.draftsRepo.UpdateDraft(ctx, &draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update draft: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, ErrDraftNotFound
+	}
+
+	return s.GetDraft(ctx, id, userId)
+}
+
+// DeleteDraft removes a draft of the user.
+func (s *DraftsService) DeleteDraft(ctx context.Context, id, userId int) error {
+	rowsAffected, err := s.draftsRepo.DeleteDraft(ctx, id, userId)
+	if err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrDraftNotFound
+	}
+
+	return nil
+}
+
+// PurgeExpiredDrafts removes the drafts that have expired. It runs as a background job.
+func (s *DraftsService) PurgeExpiredDrafts(ctx context.Context) error {
+	rowsAffected, err := s.draftsRepo.PurgeExpiredDrafts(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to purge expired drafts: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("Purged expired drafts", slog.Int64("count", rowsAffected))
+	}
+	return nil
+}
+
+func toDraft(draft models.DraftDBModel) *models.Draft {
+	return &models.Draft{
+		ID:        draft.ID,
+		Content:   draft.Content,
+		Board:     draft.Board,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+		ExpiresAt: draft.ExpiresAt,
+	}
+}
@@ -3,18 +3,39 @@ package posts
 import (
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// PreferencesReader provides the stored preferences of a user, used as defaults for the posts feed.
+type PreferencesReader interface {
+	GetPreferences(ctx context.Context, userId int) (*models.UserPreferences, error)
+}
+
+// RoleReader provides the role of a user, used to let moderators see the edit history of any post.
+type RoleReader interface {
+	GetUserRole(ctx context.Context, userId int) (string, error)
+}
+
+// UserReader is what the posts handlers need to know about the user making a request.
+type UserReader interface {
+	PreferencesReader
+	RoleReader
+}
+
 type PostsHandler struct {
-	postsService *PostsService
+	postsService  *PostsService
+	users         UserReader
+	boardsService *boards.BoardsService
 }
 
-func NewPostsHandler(postsService *PostsService) *PostsHandler {
-	return &PostsHandler{postsService: postsService}
+func NewPostsHandler(postsService *PostsService, users UserReader, boardsService *boards.BoardsService) *PostsHandler {
+	return &PostsHandler{postsService: postsService, users: users, boardsService: boardsService}
 }
 
 func (h *PostsHandler) CreatePostHandler(c *gin.Context) {
@@ -29,7 +50,27 @@ func (h *PostsHandler) CreatePostHandler(c *gin.Context) {
 	}
 	ctx := c.Request.Context()
 
-	err := h.postsService.CreatePosts(ctx, post, userId)
+	if post.Board == "" {
+		post.Board = models.DefaultBoardSlug
+	}
+	board, ok := h.resolveBoard(c, post.Board)
+	if !ok {
+		return
+	}
+
+	err := h.postsService.CreatePosts(ctx, post, *board, userId, 0)
+	if errors.Is(err, ErrExpiryTooLong) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "expiresIn exceeds the maximum lifetime of a post."})
+		return
+	}
+	if errors.Is(err, ErrPublishAtInPast) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "publishAt must be in the future."})
+		return
+	}
+	if errors.Is(err, ErrPublishAtTooLate) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "publishAt exceeds the maximum scheduling horizon."})
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to create post", slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Post Creation Failed"})
@@ -45,6 +86,10 @@ func (h *PostsHandler) GetPostHandler(c *gin.Context) {
 	id := helper.ParseIDParam(c, "id")
 
 	post, err := h.postsService.GetPost(ctx, id)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post does not exist."})
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to retrieve post", slog.Int("postId", id), slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve post."})
@@ -66,15 +111,54 @@ func (h *PostsHandler) GetPostsCollectionHandler(c *gin.Context) {
 		return
 	}
 
-	// Set default values if not provided.
+	// Set default values if not provided, taken from the preferences of the user.
 	if postQueryParam.Page == 0 {
 		postQueryParam.Page = 1
 	}
-	if postQueryParam.Limit == 0 {
-		postQueryParam.Limit = 10
+	noSort := postQueryParam.SortByLikes == "" && postQueryParam.SortByCreationDate == "" && postQueryParam.Sort == ""
+	if postQueryParam.Limit == 0 || noSort {
+		preferences, err := h.users.GetPreferences(ctx, userId)
+		if err != nil {
+			slog.Error("Failed to retrieve preferences for posts", slog.Int("userId", userId), slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve posts."})
+			return
+		}
+
+		if postQueryParam.Limit == 0 {
+			postQueryParam.Limit = preferences.PageSize
+		}
+		if noSort {
+			if preferences.SortBy == models.PreferenceSortByLikes {
+				postQueryParam.SortByLikes = preferences.SortOrder
+			} else {
+				postQueryParam.SortByCreationDate = preferences.SortOrder
+			}
+		}
+	}
+
+	if postQueryParam.Board != "" {
+		board, ok := h.resolveBoard(c, postQueryParam.Board)
+		if !ok {
+			return
+		}
+		postQueryParam.BoardId = board.ID
 	}
-	if postQueryParam.SortByLikes == "" && postQueryParam.SortByCreationDate == "" {
-		postQueryParam.SortByCreationDate = "asc"
+
+	// Sending a cursor, even an empty one, opts into keyset pagination.
+	if _, ok := c.GetQuery("cursor"); ok {
+		page, err := h.postsService.GetPostsPage(ctx, userId, postQueryParam)
+		if errors.Is(err, helper.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid cursor."})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to retrieve posts page", slog.String("error", err.Error()))
+			c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve posts."})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+		return
 	}
 
 	post, err := h.postsService.GetPostsCollection(ctx, userId, postQueryParam)
@@ -92,6 +176,141 @@ func (h *PostsHandler) GetPostsCollectionHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, post)
 }
 
+// resolveBoard looks up a board by its slug. When it does not exist or the lookup fails,
+// the error response is written and false is returned.
+func (h *PostsHandler) resolveBoard(c *gin.Context, slug string) (*models.Board, bool) {
+	board, err := h.boardsService.GetBoard(c.Request.Context(), slug)
+	if errors.Is(err, boards.ErrBoardNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Board does not exist."})
+		return nil, false
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve board", slog.String("slug", slug), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve board."})
+		return nil, false
+	}
+
+	return board, true
+}
+
+func (h *PostsHandler) GetTagsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var queryParams models.TagQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for retrieving tags", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 50
+	}
+
+	tags, err := h.postsService.GetTags(ctx, queryParams)
+	if err != nil {
+		slog.Error("Failed to retrieve tags", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve tags."})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *PostsHandler) SearchPostsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var queryParams models.SearchPostsQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for searching posts", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 10
+	}
+
+	posts, err := h.postsService.SearchPosts(ctx, userId, queryParams)
+	if errors.Is(err, ErrEmptySearchQuery) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Search query contains no searchable words."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to search posts", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to search posts."})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+func (h *PostsHandler) GetOwnPostsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var queryParams models.OwnPostsQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for own posts", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	if queryParams.Page == 0 {
+		queryParams.Page = 1
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 10
+	}
+
+	posts, err := h.postsService.GetOwnPosts(ctx, userId, queryParams)
+	if err != nil {
+		slog.Error("Failed to retrieve own posts", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve posts."})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+func (h *PostsHandler) GetPostRevisionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+	if c.IsAborted() {
+		return
+	}
+
+	role, err := h.users.GetUserRole(ctx, userId)
+	if err != nil {
+		slog.Error("Failed to retrieve role for post revisions", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve revisions."})
+		return
+	}
+	isModerator := role == models.RoleModerator || role == models.RoleAdmin
+
+	revisions, err := h.postsService.GetPostRevisions(ctx, postId, userId, isModerator)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post does not exist."})
+		return
+	}
+	if errors.Is(err, ErrHistoryNotVisible) {
+		c.JSON(http.StatusForbidden, helper.ErrorMessage{Message: "Only the author and moderators can see the edit history."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve post revisions", slog.Int("postId", postId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve revisions."})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
 func (h *PostsHandler) DeletePostsHandler(c *gin.Context) {
 	id := helper.ParseIDParam(c, "id")
 	userID := helper.RetrieveLoggedInUserId(c)
@@ -111,6 +330,25 @@ func (h *PostsHandler) DeletePostsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Post deleted successfully."})
 }
 
+func (h *PostsHandler) RestorePostHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := helper.ParseIDParam(c, "id")
+	userID := helper.RetrieveLoggedInUserId(c)
+
+	rowsAffected, err := h.postsService.RestorePost(ctx, id, userID)
+	if err != nil {
+		slog.Error("Failed to restore post", slog.Int("postId", id), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to restore post."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "No deleted post to restore."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Post restored successfully."})
+}
+
 func (h *PostsHandler) UpdatePostsHandler(c *gin.Context) {
 	userId := helper.RetrieveLoggedInUserId(c)
 	postId := helper.ParseIDParam(c, "id")
@@ -139,6 +377,51 @@ func (h *PostsHandler) UpdatePostsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Updated successfully"})
 }
 
+func (h *PostsHandler) ModerateUpdatePostHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	moderatorId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+
+	var post models.PostRequest
+	if err := c.ShouldBindJSON(&post); err != nil {
+		slog.Warn("Invalid request body for moderating post", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	rowsAffected, err := h.postsService.ModeratePostUpdate(ctx, postId, moderatorId, post)
+	if err != nil {
+		slog.Error("Failed to moderate post update", slog.Int("postId", postId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update post."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Updated successfully"})
+}
+
+func (h *PostsHandler) ModerateDeletePostHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	moderatorId := helper.RetrieveLoggedInUserId(c)
+	postId := helper.ParseIDParam(c, "id")
+
+	rowsAffected, err := h.postsService.ModeratePostDeletion(ctx, postId, moderatorId)
+	if err != nil {
+		slog.Error("Failed to moderate post deletion", slog.Int("postId", postId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to delete post."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Post deleted successfully."})
+}
+
 func (h *PostsHandler) UpdateLikesHandler(c *gin.Context) {
 	postId := helper.ParseIDParam(c, "id")
 	userId := helper.RetrieveLoggedInUserId(c)
@@ -153,6 +436,10 @@ func (h *PostsHandler) UpdateLikesHandler(c *gin.Context) {
 	}
 
 	rowsAffected, err := h.postsService.UpdateLikes(ctx, postId, userId, postsLikes)
+	if errors.Is(err, ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Post not found"})
+		return
+	}
 	if err != nil {
 		slog.Error("Error updating likes", slog.Int("postId", postId), slog.String("error", err.Error()))
 		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Updating likes failed."})
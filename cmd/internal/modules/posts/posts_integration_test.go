@@ -6,20 +6,58 @@
 package posts_test
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper/testutils"
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/modules/boards"
 	"anon-confessions/cmd/internal/modules/posts"
 	"anon-confessions/cmd/internal/websocket"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var testConfig = &config.Config{
+	Content: config.ContentConfig{PseudonymSecret: "test-pseudonym-secret", RestoreWindow: 72 * time.Hour, MaxPostExpiry: 24 * time.Hour, MaxScheduleAhead: 24 * time.Hour},
+	Ranking: config.RankingConfig{HotWindow: 7 * 24 * time.Hour, TrendingWindow: 24 * time.Hour},
+}
+
+// stubUser returns the same preferences and role for every user.
+type stubUser struct {
+	preferences models.UserPreferences
+	role        string
+}
+
+func (s stubUser) GetPreferences(ctx context.Context, userId int) (*models.UserPreferences, error) {
+	preferences := s.preferences
+	return &preferences, nil
+}
+
+func (s stubUser) GetUserRole(ctx context.Context, userId int) (string, error) {
+	return s.role, nil
+}
+
 // setupPostsTest initializes the test environment for posts-related endpoints, including
 // setting up the router, mock database, and required middleware.
 func setupPostsTest() *gin.Engine {
+	return setupPostsTestWithPreferences(models.DefaultUserPreferences())
+}
+
+// setupPostsTestWithPreferences is setupPostsTest with the given preferences for the logged-in user.
+func setupPostsTestWithPreferences(preferences models.UserPreferences) *gin.Engine {
+	return setupPostsTestWithUser(stubUser{preferences: preferences, role: models.RoleUser}, testConfig)
+}
+
+// setupPostsTestWithUser is setupPostsTest with the given logged-in user and configuration.
+func setupPostsTestWithUser(user stubUser, cfg *config.Config) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 
 	// Mock authentication middleware
@@ -36,8 +74,9 @@ func setupPostsTest() *gin.Engine {
 
 	// Initialize repository, service, and handler
 	repo := posts.NewSQLitePostsRepository(db)
-	service := posts.NewPostsService(repo, hub)
-	handler := posts.NewPostsHandler(service)
+	service := posts.NewPostsService(repo, cfg, hub)
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	handler := posts.NewPostsHandler(service, user, boardsService)
 
 	// Set up router
 	router := gin.Default()
@@ -46,6 +85,11 @@ func setupPostsTest() *gin.Engine {
 	authenticated.Use(mockAuthMiddleware)
 	posts.RegisterPostRoutes(authenticated, handler)
 
+	// Role checks are covered by the middleware tests, the moderation routes are exercised directly here.
+	admin := apiGroup.Group("/admin")
+	admin.Use(mockAuthMiddleware)
+	posts.RegisterAdminPostRoutes(admin, handler)
+
 	return router
 }
 
@@ -121,6 +165,50 @@ func TestGetPostsCollectionHandler(t *testing.T) {
 	}
 }
 
+// TestGetPostsCollectionPreferences tests if the stored preferences are used when the query string leaves them out.
+func TestGetPostsCollectionPreferences(t *testing.T) {
+	preferences := models.DefaultUserPreferences()
+	preferences.SortOrder = "desc"
+	preferences.PageSize = 1
+	router := setupPostsTestWithPreferences(preferences)
+
+	for i := 0; i < 2; i++ {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: fmt.Sprintf("Preferences post %d", i)})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var posts models.GetPostsCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("Expected the preferred page size of 1, got %d posts", len(posts))
+	}
+	if posts[0].Content != "Preferences post 1" {
+		t.Errorf("Expected the newest post first, got %q", posts[0].Content)
+	}
+
+	// Explicit query params still win over the preferences.
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?limit=2&creation_date=asc", nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(posts) != 2 || posts[0].Content == "Preferences post 1" {
+		t.Errorf("Expected two posts in ascending order, got %+v", posts)
+	}
+}
+
 // TestUpdatePostsHandler tests if a post can be updated successfully.
 func TestUpdatePostsHandler(t *testing.T) {
 	router := setupPostsTest()
@@ -195,3 +283,881 @@ func TestDeletePostsHandler(t *testing.T) {
 		t.Errorf("Expected message 'Post deleted successfully.', got '%s'", resp["message"])
 	}
 }
+
+// TestModeratePostHandlers tests that moderators can edit and delete posts of other users.
+func TestModeratePostHandlers(t *testing.T) {
+	router := setupPostsTest()
+
+	// Seed a post written by another user
+	db := testutils.SetupMockDB()
+	post := models.PostDBModel{Content: "Someone else's post", UserId: 2}
+	db.Create(&post)
+
+	reqBody, _ := json.Marshal(models.PostRequest{Content: "Removed by a moderator"})
+
+	// The ownership filter of the regular route still applies
+	w, req := testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d", post.ID), reqBody)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/admin/posts/%d", post.ID), reqBody)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var updated models.PostDBModel
+	db.First(&updated, post.ID)
+	if updated.Content != "Removed by a moderator" {
+		t.Errorf("Expected content to be updated, got '%s'", updated.Content)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/admin/posts/%d", post.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/admin/posts/%d", post.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestSearchPostsHandler tests if posts can be found by their content, ranked and kept in sync with edits.
+func TestSearchPostsHandler(t *testing.T) {
+	router := setupPostsTest()
+
+	create := func(content string) int {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var posts models.GetPostsCollection
+		w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?creation_date=desc&limit=1", nil)
+		router.ServeHTTP(w, req)
+		if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil || len(posts) != 1 {
+			t.Fatalf("Failed to retrieve the created post: %v", err)
+		}
+		return posts[0].ID
+	}
+
+	search := func(query string) models.SearchPostsCollection {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/search?"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, query, w.Code)
+		}
+		var results models.SearchPostsCollection
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return results
+	}
+
+	weakId := create("I once ate a whole pineapple pizza alone and never told anyone about it")
+	strongId := create("Pineapple pizza, pineapple smoothies, pineapple everything")
+	create("Nothing to see here")
+
+	// Like the weaker match to check the flag comes back
+	reqBodyBytes, _ := json.Marshal(models.UpdateLikesRequest{Action: "Like"})
+	w, req := testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d/likes", weakId), reqBodyBytes)
+	router.ServeHTTP(w, req)
+
+	results := search("q=pineapple")
+	if len(results) != 2 || results[0].ID != strongId || results[1].ID != weakId {
+		t.Fatalf("Expected posts %d and %d ranked by relevance, got %+v", strongId, weakId, results)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("Expected descending scores, got %f and %f", results[0].Score, results[1].Score)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>Pineapple</mark>") {
+		t.Errorf("Expected a highlighted snippet, got %q", results[0].Snippet)
+	}
+	if results[1].IsLiked != 1 || results[0].IsLiked != 0 {
+		t.Errorf("Expected only post %d to be liked, got %+v", weakId, results)
+	}
+
+	// Pagination, prefixes and every word having to match
+	if page := search("q=pineapple&limit=1&page=2"); len(page) != 1 || page[0].ID != weakId {
+		t.Errorf("Expected the second page to hold post %d, got %+v", weakId, page)
+	}
+	if prefix := search("q=pinea*+alone"); len(prefix) != 1 || prefix[0].ID != weakId {
+		t.Errorf("Expected a prefix search to find post %d, got %+v", weakId, prefix)
+	}
+
+	// Edits and deletions are reflected by the index
+	reqBodyBytes, _ = json.Marshal(models.PostRequest{Content: "Changed my mind about fruit"})
+	w, req = testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d", strongId), reqBodyBytes)
+	router.ServeHTTP(w, req)
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", weakId), nil)
+	router.ServeHTTP(w, req)
+
+	if results := search("q=pineapple"); len(results) != 0 {
+		t.Errorf("Expected no results after editing and deleting, got %+v", results)
+	}
+	if results := search("q=fruit"); len(results) != 1 || results[0].ID != strongId {
+		t.Errorf("Expected the edited post to be found by its new content, got %+v", results)
+	}
+
+	// Snippets are HTML-escaped apart from the highlighting
+	create("<script>alert('mango')</script>")
+	if results := search("q=mango"); len(results) != 1 || results[0].Snippet != "&lt;script&gt;alert(&#39;<mark>mango</mark>&#39;)&lt;/script&gt;" {
+		t.Errorf("Expected an escaped snippet, got %+v", results)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/search?q=%2A%2A%2A", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for a query without words, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestPostTags tests if hashtags are taken from posts, kept in sync with edits and usable as filters.
+func TestPostTags(t *testing.T) {
+	router := setupPostsTest()
+
+	create := func(content string) int {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var posts models.GetPostsCollection
+		w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?creation_date=desc&limit=1", nil)
+		router.ServeHTTP(w, req)
+		if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil || len(posts) != 1 {
+			t.Fatalf("Failed to retrieve the created post: %v", err)
+		}
+		return posts[0].ID
+	}
+
+	filter := func(query string) []int {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?creation_date=asc&"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, query, w.Code)
+		}
+		ids := []int{}
+		// An empty collection is answered with an empty object
+		if w.Body.String() == "{}" {
+			return ids
+		}
+		var posts models.GetPostsCollection
+		if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	tags := func(query string) []models.Tag {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/tags?"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, query, w.Code)
+		}
+		var tags []models.Tag
+		if err := json.Unmarshal(w.Body.Bytes(), &tags); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return tags
+	}
+
+	bothId := create("Skipped the gym again #TagTestLazy #tagtestmonday")
+	lazyId := create("Slept through my alarm #tagtestlazy")
+	create("No tags on this one")
+
+	if ids := filter("tag=tagtestlazy"); !slices.Equal(ids, []int{bothId, lazyId}) {
+		t.Errorf("Expected posts %d and %d for one tag, got %v", bothId, lazyId, ids)
+	}
+	if ids := filter("tag=%23TagTestLazy&tag=tagtestmonday"); !slices.Equal(ids, []int{bothId, lazyId}) {
+		t.Errorf("Expected posts with any of the tags, got %v", ids)
+	}
+	if ids := filter("tag=tagtestlazy&tag=tagtestmonday&tag=TagTestMonday&tag_match=all"); !slices.Equal(ids, []int{bothId}) {
+		t.Errorf("Expected only post %d to have all tags, got %v", bothId, ids)
+	}
+
+	expected := []models.Tag{{Name: "tagtestlazy", Count: 2}, {Name: "tagtestmonday", Count: 1}}
+	if found := tags("prefix=%23TagTest"); !slices.Equal(found, expected) {
+		t.Errorf("Expected tags %+v, got %+v", expected, found)
+	}
+	if found := tags("prefix=tagtest&limit=1"); len(found) != 1 || found[0].Name != "tagtestlazy" {
+		t.Errorf("Expected the most used tag only, got %+v", found)
+	}
+
+	// Editing a post replaces its tags, deleting it drops them
+	reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: "Actually went #tagtestproud"})
+	w, req := testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d", bothId), reqBodyBytes)
+	router.ServeHTTP(w, req)
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", lazyId), nil)
+	router.ServeHTTP(w, req)
+
+	if ids := filter("tag=tagtestlazy"); len(ids) != 0 {
+		t.Errorf("Expected no posts for a removed tag, got %v", ids)
+	}
+	if ids := filter("tag=tagtestproud"); !slices.Equal(ids, []int{bothId}) {
+		t.Errorf("Expected the edited post for its new tag, got %v", ids)
+	}
+	if found := tags("prefix=tagtest"); !slices.Equal(found, []models.Tag{{Name: "tagtestproud", Count: 1}}) {
+		t.Errorf("Expected only the new tag, got %+v", found)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?tag=a&tag_match=some", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown tag match, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestPostBoards tests if posts go to the board they are created on and can be filtered by it.
+func TestPostBoards(t *testing.T) {
+	router := setupPostsTest()
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(testutils.SetupMockDB()))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "posts-test-board"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	for _, content := range []string{"First post on the board", "Second post on the board"} {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content, Board: board.Slug})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: "A post without a board"})
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=posts-test-board&creation_date=asc", nil)
+	router.ServeHTTP(w, req)
+	var posts models.GetPostsCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(posts) != 2 || posts[0].Content != "First post on the board" || posts[1].BoardId != board.ID {
+		t.Errorf("Expected the two posts of board %d, got %+v", board.ID, posts)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=general&creation_date=desc&limit=1", nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Content != "A post without a board" || posts[0].BoardId != models.DefaultBoardID {
+		t.Errorf("Expected posts without a board to go to the general board, got %+v", posts)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=no-such-board", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown board, got %d", http.StatusNotFound, w.Code)
+	}
+
+	reqBodyBytes, _ = json.Marshal(models.PostRequest{Content: "Lost post", Board: "no-such-board"})
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d when posting to an unknown board, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestGetPostsCursorPagination tests if keyset pagination walks the feed in both directions without
+// skipping or repeating posts, even when posts are created in between.
+func TestGetPostsCursorPagination(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+
+	// A board of its own keeps the posts of other tests out of the feed
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	if _, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "cursor-test"}); err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	create := func(content string) int {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content, Board: "cursor-test"})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var post models.PostDBModel
+		db.Where("content = ?", content).Last(&post)
+		return post.ID
+	}
+
+	fetch := func(query string) ([]int, models.CursorPagination) {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=cursor-test&limit=2&"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, query, w.Code)
+		}
+		var page models.PostsPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		ids := []int{}
+		for _, post := range page.Posts {
+			ids = append(ids, post.ID)
+		}
+		return ids, page.Pagination
+	}
+
+	ids := make([]int, 5)
+	for i := range ids {
+		ids[i] = create(fmt.Sprintf("Cursor post %d", i))
+	}
+
+	// Newest first
+	page, pagination := fetch("cursor=&creation_date=desc")
+	if !slices.Equal(page, []int{ids[4], ids[3]}) || pagination.PrevCursor != nil || pagination.NextCursor == nil {
+		t.Fatalf("Unexpected first page %v %+v", page, pagination)
+	}
+
+	page, pagination = fetch("cursor=" + *pagination.NextCursor)
+	if !slices.Equal(page, []int{ids[2], ids[1]}) || pagination.PrevCursor == nil || pagination.NextCursor == nil {
+		t.Fatalf("Unexpected second page %v %+v", page, pagination)
+	}
+	secondPage := pagination
+
+	// A post arriving while scrolling does not shift the next page
+	newest := create("Cursor post created while scrolling")
+
+	page, pagination = fetch("cursor=" + *secondPage.NextCursor)
+	if !slices.Equal(page, []int{ids[0]}) || pagination.NextCursor != nil {
+		t.Errorf("Unexpected last page %v %+v", page, pagination)
+	}
+
+	// Scrolling back up reaches the new post
+	page, pagination = fetch("cursor=" + *secondPage.PrevCursor)
+	if !slices.Equal(page, []int{ids[4], ids[3]}) || pagination.PrevCursor == nil || pagination.NextCursor == nil {
+		t.Fatalf("Unexpected previous page %v %+v", page, pagination)
+	}
+	page, pagination = fetch("cursor=" + *pagination.PrevCursor)
+	if !slices.Equal(page, []int{newest}) || pagination.PrevCursor != nil {
+		t.Errorf("Expected the new post at the top, got %v %+v", page, pagination)
+	}
+
+	// Ties in likes are broken by the post ID
+	likes := map[int]int{ids[0]: 3, ids[1]: 1, ids[2]: 3, ids[3]: 0, ids[4]: 1, newest: 0}
+	for id, count := range likes {
+		db.Model(&models.PostDBModel{}).Where("id = ?", id).Update("total_likes", count)
+	}
+	walked := []int{}
+	query := "cursor=&sort_by_likes=desc"
+	for {
+		page, pagination = fetch(query)
+		walked = append(walked, page...)
+		if pagination.NextCursor == nil {
+			break
+		}
+		query = "cursor=" + *pagination.NextCursor
+	}
+	expected := []int{ids[2], ids[0], ids[4], ids[1], newest, ids[3]}
+	if !slices.Equal(walked, expected) {
+		t.Errorf("Expected posts by likes %v, got %v", expected, walked)
+	}
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?cursor=not-a-cursor", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid cursor, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestPostRankings tests if the hot and trending feeds follow the scores computed by the ranking job.
+func TestPostRankings(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+	service := posts.NewPostsService(posts.NewSQLitePostsRepository(db), testConfig, nil)
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "ranking-test"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	now := time.Now()
+	seed := func(age time.Duration, likes int) int {
+		post := models.PostDBModel{Content: "Ranked post", UserId: 1, BoardId: board.ID, CreatedAt: now.Add(-age), TotalLikes: likes}
+		db.Create(&post)
+		return post.ID
+	}
+	react := func(postId int, ago time.Duration, likes, comments int) {
+		for i := 0; i < likes; i++ {
+			createdAt := now.Add(-ago)
+			db.Create(&models.PostsLikesDBModel{PostId: postId, UserId: 1000 + i, CreatedAt: &createdAt})
+		}
+		for i := 0; i < comments; i++ {
+			db.Create(&models.CommentsDbModel{Content: "Reaction", UserId: 1, PostId: postId, CreatedAt: now.Add(-ago)})
+		}
+	}
+
+	// Many likes long ago, a few recent reactions on a fresh post, and an older post that is getting attention now
+	popular := seed(72*time.Hour, 50)
+	fresh := seed(time.Hour, 5)
+	react(fresh, 30*time.Minute, 0, 2)
+	active := seed(5*24*time.Hour, 4)
+	react(active, time.Hour, 4, 1)
+	ancient := seed(30*24*time.Hour, 1000)
+	db.Model(&models.PostDBModel{}).Where("id = ?", ancient).Update("hot_score", 99)
+
+	if err := service.RefreshRankings(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh rankings: %v", err)
+	}
+
+	feed := func(query string) ([]int, models.CursorPagination) {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=ranking-test&"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, query, w.Code)
+		}
+		var page models.PostsPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		ids := []int{}
+		for _, post := range page.Posts {
+			ids = append(ids, post.ID)
+		}
+		return ids, page.Pagination
+	}
+
+	// The hot feed is walked with cursors to check they follow the scores
+	hot := []int{}
+	query := "cursor=&sort=hot&limit=2"
+	for {
+		page, pagination := feed(query)
+		hot = append(hot, page...)
+		if pagination.NextCursor == nil {
+			break
+		}
+		query = "cursor=" + *pagination.NextCursor
+	}
+	if expected := []int{fresh, popular, active, ancient}; !slices.Equal(hot, expected) {
+		t.Errorf("Expected the hot feed %v, got %v", expected, hot)
+	}
+
+	if trending, _ := feed("cursor=&sort=trending&limit=2"); !slices.Equal(trending, []int{active, fresh}) {
+		t.Errorf("Expected the trending feed to start with %v, got %v", []int{active, fresh}, trending)
+	}
+
+	var posts models.GetPostsCollection
+	w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=ranking-test&sort=trending&limit=1", nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil || len(posts) != 1 || posts[0].ID != active {
+		t.Errorf("Expected page-based access to the trending feed, got %s", w.Body.String())
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?sort=best", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown sort, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestPostRevisions tests if edits keep the previous versions and who can see them.
+func TestPostRevisions(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+
+	own := models.PostDBModel{Content: "First version", UserId: 1}
+	db.Create(&own)
+	other := models.PostDBModel{Content: "Someone else's first version", UserId: 2}
+	db.Create(&other)
+
+	edit := func(url, content string) {
+		reqBody, _ := json.Marshal(models.PostRequest{Content: content})
+		w, req := testutils.HTTPTestRequest(http.MethodPatch, url, reqBody)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d for editing %s, got %d", http.StatusOK, url, w.Code)
+		}
+	}
+	revisions := func(router *gin.Engine, postId, expectedCode int) []models.Revision {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/revisions", postId), nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for the revisions of post %d, got %d", expectedCode, postId, w.Code)
+		}
+		var revisions []models.Revision
+		json.Unmarshal(w.Body.Bytes(), &revisions)
+		return revisions
+	}
+
+	// A fresh post has no history and is not flagged as edited
+	if history := revisions(router, own.ID, http.StatusOK); len(history) != 0 {
+		t.Errorf("Expected no revisions for an unedited post, got %d", len(history))
+	}
+
+	edit(fmt.Sprintf("/api/v1/posts/%d", own.ID), "Second version")
+	edit(fmt.Sprintf("/api/v1/posts/%d", own.ID), "Second version")
+	edit(fmt.Sprintf("/api/v1/admin/posts/%d", own.ID), "Third version")
+
+	// The author sees the content a moderator removed as well
+	history := revisions(router, own.ID, http.StatusOK)
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 revisions, saving unchanged content must not add one, got %d", len(history))
+	}
+	if history[0].Content != "Second version" || !history[0].ByModerator {
+		t.Errorf("Expected the newest revision to be the moderated 'Second version', got %+v", history[0])
+	}
+	if history[1].Content != "First version" || history[1].ByModerator {
+		t.Errorf("Expected the oldest revision to be the author's 'First version', got %+v", history[1])
+	}
+
+	moderator := setupPostsTestWithUser(stubUser{preferences: models.DefaultUserPreferences(), role: models.RoleModerator}, testConfig)
+	if history := revisions(moderator, own.ID, http.StatusOK); len(history) != 2 {
+		t.Errorf("Expected moderators to see 2 revisions, got %d", len(history))
+	}
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", own.ID), nil)
+	router.ServeHTTP(w, req)
+	var post models.GetPostWithComments
+	if err := json.Unmarshal(w.Body.Bytes(), &post); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if post.Content != "Third version" || !post.Edited || post.EditedAt == nil {
+		t.Errorf("Expected the edited post to carry edited and editedAt, got %s", w.Body.String())
+	}
+
+	// The history of someone else's post is hidden unless it is public or the viewer is a moderator
+	edit(fmt.Sprintf("/api/v1/admin/posts/%d", other.ID), "Someone else's second version")
+	revisions(router, other.ID, http.StatusForbidden)
+	revisions(router, 999999, http.StatusNotFound)
+
+	if history := revisions(moderator, other.ID, http.StatusOK); len(history) != 1 {
+		t.Errorf("Expected moderators to see 1 revision, got %d", len(history))
+	}
+
+	publicConfig := *testConfig
+	publicConfig.Content.PublicEditHistory = true
+	public := setupPostsTestWithUser(stubUser{preferences: models.DefaultUserPreferences(), role: models.RoleUser}, &publicConfig)
+	if history := revisions(public, other.ID, http.StatusOK); len(history) != 0 {
+		t.Errorf("Expected the public history to leave out the moderated content, got %+v", history)
+	}
+	db.Create(&models.PostRevisionDBModel{PostId: other.ID, Content: "Someone else's own edit"})
+	if history := revisions(public, other.ID, http.StatusOK); len(history) != 1 || history[0].Content != "Someone else's own edit" {
+		t.Errorf("Expected the public history of the author's edits, got %+v", history)
+	}
+
+	// Scheduled posts only have a history for their author
+	publishAt := time.Now().Add(time.Hour).UTC()
+	scheduled := models.PostDBModel{Content: "Scheduled first version", UserId: 1, PublishAt: &publishAt}
+	db.Create(&scheduled)
+	othersScheduled := models.PostDBModel{Content: "Someone else's scheduled post", UserId: 2, PublishAt: &publishAt}
+	db.Create(&othersScheduled)
+	revisions(router, scheduled.ID, http.StatusOK)
+	revisions(public, othersScheduled.ID, http.StatusNotFound)
+}
+
+// TestPostSoftDelete tests if deleted posts disappear everywhere, can be restored within the window and are purged after it.
+func TestPostSoftDelete(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+	service := posts.NewPostsService(posts.NewSQLitePostsRepository(db), testConfig, nil)
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "soft-delete-test"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	create := func(content string) models.PostDBModel {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content, Board: board.Slug})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+		var post models.PostDBModel
+		db.Where("board_id = ?", board.ID).Last(&post)
+		return post
+	}
+	post := create("Regretting this #soapboxconfession")
+	db.Create(&models.CommentsDbModel{Content: "Kept while deleted", UserId: 2, PostId: post.ID})
+	expired := create("Deleted long ago #soapboxconfession")
+
+	request := func(method, url string, expectedCode int) string {
+		w, req := testutils.HTTPTestRequest(method, url, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for %s %s, got %d", expectedCode, method, url, w.Code)
+		}
+		return w.Body.String()
+	}
+	visible := func() bool {
+		found := strings.Contains(request(http.MethodGet, "/api/v1/posts/?board=soft-delete-test", http.StatusOK), "Regretting this")
+		searched := strings.Contains(request(http.MethodGet, "/api/v1/posts/search?q=regretting", http.StatusOK), "Regretting this")
+		tagged := strings.Contains(request(http.MethodGet, "/api/v1/tags?prefix=soapbox", http.StatusOK), `"count":1`)
+		if found != searched || found != tagged {
+			t.Fatalf("Expected the feed, search and tags to agree, got %v, %v and %v", found, searched, tagged)
+		}
+		return found
+	}
+
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", expired.ID), http.StatusOK)
+	db.Model(&models.PostDBModel{}).Where("id = ?", expired.ID).Update("deleted_at", time.Now().Add(-73*time.Hour))
+
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", post.ID), http.StatusOK)
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", post.ID), http.StatusNotFound)
+	request(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", post.ID), http.StatusNotFound)
+	request(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d/revisions", post.ID), http.StatusNotFound)
+	likeBody, _ := json.Marshal(models.UpdateLikesRequest{Action: "Like"})
+	w, req := testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d/likes", post.ID), likeBody)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for liking a deleted post, got %d", http.StatusNotFound, w.Code)
+	}
+	if visible() {
+		t.Errorf("Expected the deleted post to be hidden")
+	}
+
+	// Restoring brings the post back with its comments, but only within the window
+	request(http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/restore", post.ID), http.StatusOK)
+	request(http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/restore", post.ID), http.StatusNotFound)
+	request(http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/restore", expired.ID), http.StatusNotFound)
+	if !visible() {
+		t.Errorf("Expected the restored post to be visible again")
+	}
+	if body := request(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", post.ID), http.StatusOK); !strings.Contains(body, "Kept while deleted") {
+		t.Errorf("Expected the restored post to keep its comments, got %s", body)
+	}
+
+	// The purge only removes posts past the window
+	request(http.MethodDelete, fmt.Sprintf("/api/v1/posts/%d", post.ID), http.StatusOK)
+	if err := service.PurgeDeletedPosts(context.Background()); err != nil {
+		t.Fatalf("Failed to purge deleted posts: %v", err)
+	}
+	var remaining []int
+	db.Model(&models.PostDBModel{}).Where("id IN ?", []int{post.ID, expired.ID}).Pluck("id", &remaining)
+	if !slices.Equal(remaining, []int{post.ID}) {
+		t.Errorf("Expected only post %d to survive the purge, got %v", post.ID, remaining)
+	}
+	request(http.MethodPost, fmt.Sprintf("/api/v1/posts/%d/restore", post.ID), http.StatusOK)
+}
+
+// TestPostExpiry tests if ephemeral posts are hidden once expired and then removed by the reaper.
+func TestPostExpiry(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "expiry-test"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	create := func(content string, expiresIn, expectedCode int) models.PostDBModel {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content, Board: board.Slug, ExpiresIn: expiresIn})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for expiresIn %d, got %d", expectedCode, expiresIn, w.Code)
+		}
+		var post models.PostDBModel
+		db.Where("board_id = ?", board.ID).Last(&post)
+		return post
+	}
+	feed := func() models.GetPostsCollection {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/?board=expiry-test&creation_date=asc", nil)
+		router.ServeHTTP(w, req)
+		var collection models.GetPostsCollection
+		json.Unmarshal(w.Body.Bytes(), &collection)
+		return collection
+	}
+
+	create("Too short lived", 30, http.StatusBadRequest)
+	create("Too long lived", 25*60*60, http.StatusBadRequest)
+	lasting := create("Here to stay", 0, http.StatusCreated)
+	ephemeral := create("Gone by tomorrow vanishingword", 60*60, http.StatusCreated)
+
+	collection := feed()
+	if len(collection) != 2 || collection[0].ExpiresAt != nil || collection[1].ExpiresAt == nil {
+		t.Fatalf("Expected only the ephemeral post to carry expiresAt, got %+v", collection)
+	}
+	if lifetime := collection[1].ExpiresAt.Sub(collection[1].CreatedAt); lifetime != time.Hour {
+		t.Errorf("Expected the post to expire an hour after its creation, got %v", lifetime)
+	}
+
+	// Expired posts are hidden right away, before the reaper runs
+	db.Model(&models.PostDBModel{}).Where("id = ?", ephemeral.ID).Update("expires_at", time.Now().Add(-time.Second))
+	if collection := feed(); len(collection) != 1 || collection[0].ID != lasting.ID {
+		t.Errorf("Expected the expired post to be hidden from the feed, got %+v", collection)
+	}
+	w, req := testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/posts/%d", ephemeral.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an expired post, got %d", http.StatusNotFound, w.Code)
+	}
+	likeBody, _ := json.Marshal(models.UpdateLikesRequest{Action: "Like"})
+	w, req = testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d/likes", ephemeral.ID), likeBody)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for liking an expired post, got %d", http.StatusNotFound, w.Code)
+	}
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/posts/search?q=vanishingword", nil)
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "[]" {
+		t.Errorf("Expected the expired post to be left out of search results, got %s", w.Body.String())
+	}
+
+	// The reaper removes the post and announces it
+	hub := websocket.NewHub()
+	service := posts.NewPostsService(posts.NewSQLitePostsRepository(db), testConfig, hub)
+	reaped := make(chan error, 1)
+	go func() { reaped <- service.ReapExpiredPosts(context.Background()) }()
+
+	var message models.WebSocketMessage
+	select {
+	case raw := <-hub.Broadcast:
+		json.Unmarshal(raw, &message)
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the reaper to broadcast the expired posts")
+	}
+	select {
+	case boardMessage := <-hub.BoardBroadcast:
+		if boardMessage.Board != board.Slug || !strings.Contains(string(boardMessage.Message), fmt.Sprint(ephemeral.ID)) {
+			t.Errorf("Expected post %d to expire on board %q, got %s", ephemeral.ID, board.Slug, boardMessage.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the reaper to tell the followers of the board")
+	}
+	if err := <-reaped; err != nil {
+		t.Fatalf("Failed to reap expired posts: %v", err)
+	}
+	if message.Type != "postExpired" || !strings.Contains(fmt.Sprint(message.Content), fmt.Sprint(ephemeral.ID)) {
+		t.Errorf("Expected a postExpired message for post %d, got %+v", ephemeral.ID, message)
+	}
+
+	var remaining []int
+	db.Model(&models.PostDBModel{}).Where("id IN ?", []int{lasting.ID, ephemeral.ID}).Pluck("id", &remaining)
+	if !slices.Equal(remaining, []int{lasting.ID}) {
+		t.Errorf("Expected only post %d to remain, got %v", lasting.ID, remaining)
+	}
+}
+
+// TestScheduledPosts tests if scheduled posts stay hidden until the scheduler publishes them.
+func TestScheduledPosts(t *testing.T) {
+	router := setupPostsTest()
+	db := testutils.SetupMockDB()
+
+	boardsService := boards.NewBoardsService(boards.NewSQLiteBoardsRepository(db))
+	board, err := boardsService.CreateBoard(context.Background(), 1, models.CreateBoardRequest{Slug: "schedule-test"})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+
+	create := func(content string, publishAt time.Time, expectedCode int) models.PostDBModel {
+		reqBodyBytes, _ := json.Marshal(models.PostRequest{Content: content, Board: board.Slug, PublishAt: &publishAt})
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/posts/", reqBodyBytes)
+		router.ServeHTTP(w, req)
+		if w.Code != expectedCode {
+			t.Fatalf("Expected status code %d for publishAt %v, got %d", expectedCode, publishAt, w.Code)
+		}
+		var post models.PostDBModel
+		db.Where("board_id = ?", board.ID).Last(&post)
+		return post
+	}
+	get := func(url string) *httptest.ResponseRecorder {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, url, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	ownState := func(id int) string {
+		var own []models.OwnPost
+		json.Unmarshal(get("/api/v1/posts/mine?limit=100").Body.Bytes(), &own)
+		for _, post := range own {
+			if post.ID == id {
+				return post.State
+			}
+		}
+		return ""
+	}
+
+	create("Back to the past", time.Now().Add(-time.Minute), http.StatusBadRequest)
+	create("Far in the future", time.Now().Add(48*time.Hour), http.StatusBadRequest)
+	scheduled := create("Coming soon postponedword", time.Now().Add(time.Hour), http.StatusCreated)
+
+	// Scheduled posts are only visible to their author
+	var collection models.GetPostsCollection
+	json.Unmarshal(get("/api/v1/posts/?board=schedule-test").Body.Bytes(), &collection)
+	if len(collection) != 0 {
+		t.Errorf("Expected the scheduled post to be hidden from the feed, got %+v", collection)
+	}
+	if w := get(fmt.Sprintf("/api/v1/posts/%d", scheduled.ID)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a scheduled post, got %d", http.StatusNotFound, w.Code)
+	}
+	likeBody, _ := json.Marshal(models.UpdateLikesRequest{Action: "Like"})
+	w, req := testutils.HTTPTestRequest(http.MethodPatch, fmt.Sprintf("/api/v1/posts/%d/likes", scheduled.ID), likeBody)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for liking a scheduled post, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get("/api/v1/posts/search?q=postponedword"); w.Body.String() != "[]" {
+		t.Errorf("Expected the scheduled post to be left out of search results, got %s", w.Body.String())
+	}
+	if state := ownState(scheduled.ID); state != models.PostStateScheduled {
+		t.Errorf("Expected the post to be listed as %q among the own posts, got %q", models.PostStateScheduled, state)
+	}
+
+	// The scheduler publishes due posts and announces them
+	publishAt := time.Now().Add(-time.Second)
+	db.Model(&models.PostDBModel{}).Where("id = ?", scheduled.ID).Update("publish_at", publishAt)
+	hub := websocket.NewHub()
+	service := posts.NewPostsService(posts.NewSQLitePostsRepository(db), testConfig, hub)
+	published := make(chan error, 1)
+	go func() { published <- service.PublishScheduledPosts(context.Background()) }()
+
+	var message models.WebSocketMessage
+	select {
+	case raw := <-hub.Broadcast:
+		json.Unmarshal(raw, &message)
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the scheduler to broadcast the published post")
+	}
+	select {
+	case boardMessage := <-hub.BoardBroadcast:
+		if boardMessage.Board != board.Slug {
+			t.Errorf("Expected the post to be announced on board %q, got %q", board.Slug, boardMessage.Board)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the scheduler to broadcast the published post to its board")
+	}
+	if err := <-published; err != nil {
+		t.Fatalf("Failed to publish scheduled posts: %v", err)
+	}
+	if message.Type != "newPost" {
+		t.Errorf("Expected a newPost message, got %+v", message)
+	}
+
+	collection = nil
+	json.Unmarshal(get("/api/v1/posts/?board=schedule-test").Body.Bytes(), &collection)
+	if len(collection) != 1 || collection[0].ID != scheduled.ID {
+		t.Fatalf("Expected the published post in the feed, got %+v", collection)
+	}
+	if !collection[0].CreatedAt.Equal(publishAt) {
+		t.Errorf("Expected the post to be dated at its publish time %v, got %v", publishAt, collection[0].CreatedAt)
+	}
+	if state := ownState(scheduled.ID); state != models.PostStatePublished {
+		t.Errorf("Expected the post to be listed as %q among the own posts, got %q", models.PostStatePublished, state)
+	}
+}
@@ -4,20 +4,59 @@ import (
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type PostsRepository interface {
-	CreatePosts(context.Context, models.PostDBModel) error
+	CreatePosts(context.Context, models.PostDBModel, []string, int) error
 	GetPost(context.Context, int) (*models.GetPostWithComments, error)
 	GetPostsCollection(context.Context, int, models.PostQueryParams) (*models.GetPostsCollection, error)
-	UpdatePosts(context.Context, int, int, models.PostRequest) (int64, error)
+	GetPostsPage(context.Context, int, models.PostQueryParams, models.PostCursor, int) (models.GetPostsCollection, error)
+	SearchPosts(context.Context, int, string, int, int) (models.SearchPostsCollection, error)
+	UpdatePosts(context.Context, int, int, models.PostRequest, []string) (int64, error)
 	DeletePost(int, int) (int64, error)
+	RestorePost(context.Context, int, int, time.Time) (int64, error)
+	PurgeDeletedPosts(context.Context, time.Time) (int64, error)
+	DeleteExpiredPosts(context.Context, time.Time) ([]models.BoardContent, error)
+	GetOwnPosts(context.Context, int, models.OwnPostsQueryParams) ([]models.OwnPost, error)
+	PublishDuePosts(context.Context, time.Time) ([]models.BoardContent, error)
 	UpdateLikes(context.Context, int, int, int) (int64, error)
+	GetTags(context.Context, models.TagQueryParams) ([]models.Tag, error)
+	GetPostAuthorId(context.Context, int, int) (int, error)
+	GetPostBoard(context.Context, int) (string, error)
+	GetThreadParticipants(context.Context, int) ([]int, error)
+	GetPostRevisions(context.Context, int, bool) ([]models.Revision, error)
+	GetRankingCandidates(context.Context, time.Time) ([]models.RankingCandidate, error)
+	GetRecentReactions(context.Context, time.Time) ([]models.PostReaction, []models.PostReaction, error)
+	SaveRankings(context.Context, []models.PostRanking) error
+	ModerateUpdatePost(context.Context, int, models.PostRequest, []string) (int64, error)
+	ModerateDeletePost(context.Context, int) (int64, error)
 }
 
+// LivePost is the condition a post has to meet to be shown to its author: neither deleted nor expired at the given time,
+// see VisibleAt. VisiblePost is the one to be shown to everyone, which also needs it to be published.
+// Both are also used by the modules working with the posts, such as comments.
+const (
+	LivePost    = "posts.deleted_at IS NULL AND (posts.expires_at IS NULL OR julianday(posts.expires_at) > julianday(?))"
+	VisiblePost = LivePost + " AND posts.publish_at IS NULL"
+)
+
+// VisibleAt formats a time for the LivePost and VisiblePost conditions.
+func VisibleAt(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// storedTimeFormat is the format go-sqlite3 writes times in. Creation times are written in UTC,
+// so a time formatted with it compares as text like the stored value, see GetPostsPage.
+const storedTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
 type SQLitePostsRepository struct {
 	db *gorm.DB
 }
@@ -26,20 +65,53 @@ func NewSQLitePostsRepository(db *gorm.DB) *SQLitePostsRepository {
 	return &SQLitePostsRepository{db: db}
 }
 
-func (repo *SQLitePostsRepository) CreatePosts(ctx context.Context, post models.PostDBModel) error {
-	result := repo.db.WithContext(ctx).Create(&post)
+// CreatePosts inserts a post together with its hashtags in one transaction.
+// A draftId other than 0 removes that draft of the post author in the same transaction, as long as it has not expired
+// by the post creation time. Only one of concurrent requests can remove it, the others get ErrDraftNotFound and insert nothing.
+func (repo *SQLitePostsRepository) CreatePosts(ctx context.Context, post models.PostDBModel, tags []string, draftId int) error {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if draftId != 0 {
+			result := tx.Where("id = ? AND user_id = ? AND julianday(expires_at) > julianday(?)", draftId, post.UserId, post.CreatedAt.Format(time.RFC3339Nano)).
+				Delete(&models.DraftDBModel{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrDraftNotFound
+			}
+		}
+
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		slog.Error("Failed to create post", slog.String("error", result.Error.Error()))
-		return result.Error
+		return syncPostTags(tx, post.ID, tags)
+	})
+
+	if errors.Is(err, ErrDraftNotFound) {
+		return err
+	}
+	if err != nil {
+		slog.Error("Failed to create post", slog.Int("draftId", draftId), slog.String("error", err.Error()))
+		return err
 	}
 
 	return nil
 }
 
+// GetPost returns a post with its comments, or nil without an error when the post does not exist, was deleted or expired.
 func (repo *SQLitePostsRepository) GetPost(ctx context.Context, id int) (*models.GetPostWithComments, error) {
 	var post models.GetPostWithComments
-	err := repo.db.Preload("Comments").First(&post, id).Error
+	err := repo.db.
+		Select("posts.*, posts.edited_at IS NOT NULL AS edited, posts.user_id IN (SELECT id FROM users WHERE lookup_hash = ?) AS anonymized", models.TombstoneLookupHash).
+		Where(VisiblePost, VisibleAt(time.Now())).
+		Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Select("comments.*, comments.edited_at IS NOT NULL AS edited").Where("comments.deleted_at IS NULL")
+		}).
+		First(&post, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		slog.Error("Failed to retrieve post", slog.Int("postId", id), slog.String("error", err.Error()))
 		return nil, err
@@ -53,16 +125,7 @@ func (repo *SQLitePostsRepository) GetPostsCollection(ctx context.Context, userI
 
 	orderClause := helper.GenerateOrderClause(postQueryParams)
 
-	result := repo.db.WithContext(ctx).
-		Model(&models.PostDBModel{}).
-		Select(`
-			posts.id,
-			posts.content,
-			posts.created_at,
-			posts.total_likes,
-        	posts_likes.user_id IS NOT NULL AS IsLiked
-		`).
-		Joins("LEFT JOIN posts_likes ON posts.id = posts_likes.post_id AND posts_likes.user_id = ?", userId).
+	result := repo.feedQuery(ctx, userId, postQueryParams).
 		Order(orderClause).
 		Limit(postQueryParams.Limit).
 		Offset((postQueryParams.Page - 1) * postQueryParams.Limit).Scan(&postCollection)
@@ -79,22 +142,455 @@ func (repo *SQLitePostsRepository) GetPostsCollection(ctx context.Context, userI
 	return &postCollection, nil
 }
 
-func (repo *SQLitePostsRepository) UpdatePosts(ctx context.Context, id int, userId int, post models.PostRequest) (int64, error) {
-	result := repo.db.WithContext(ctx).Model(&models.PostDBModel{}).Where("id = ? AND user_id = ?", id, userId).Update("content", post.Content)
+// GetPostsPage returns up to limit posts of the feed after the cursor, or before it for backward cursors,
+// in the order of the feed. A cursor without a post ID starts at the top of the feed.
+// Posts are compared on their sort key and then their ID, so posts created while paging neither shift nor repeat the pages.
+// The comparison and order use the raw columns, so each feed is served by its (sort key, id) index.
+func (repo *SQLitePostsRepository) GetPostsPage(ctx context.Context, userId int, postQueryParams models.PostQueryParams, cursor models.PostCursor, limit int) (models.GetPostsCollection, error) {
+	column := "posts.created_at"
+	var key interface{} = cursor.CreatedAt.UTC().Format(storedTimeFormat)
+	switch cursor.SortBy {
+	case models.PreferenceSortByLikes:
+		column, key = "posts.total_likes", cursor.Likes
+	case models.PostSortHot:
+		column, key = "posts.hot_score", cursor.Score
+	case models.PostSortTrending:
+		column, key = "posts.trending_score", cursor.Score
+	}
+
+	ascending := (cursor.Order == "asc") != cursor.Backward
+	operator, direction := "<", "DESC"
+	if ascending {
+		operator, direction = ">", "ASC"
+	}
+
+	query := repo.feedQuery(ctx, userId, postQueryParams)
+	if cursor.ID != 0 {
+		query = query.Where(fmt.Sprintf("(%s, posts.id) %s (?, ?)", column, operator), key, cursor.ID)
+	}
+
+	posts := models.GetPostsCollection{}
+	err := query.
+		Order(fmt.Sprintf("%s %s, posts.id %s", column, direction, direction)).
+		Limit(limit).
+		Scan(&posts).Error
+	if err != nil {
+		slog.Error("Failed to retrieve posts page", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if cursor.Backward {
+		slices.Reverse(posts)
+	}
+
+	return posts, nil
+}
+
+// feedQuery selects the posts of the feed matching the board and tag filters, along with whether the user liked them.
+// Deleted and expired posts are left out.
+func (repo *SQLitePostsRepository) feedQuery(ctx context.Context, userId int, postQueryParams models.PostQueryParams) *gorm.DB {
+	query := repo.db.WithContext(ctx).Model(&models.PostDBModel{}).Where(VisiblePost, VisibleAt(time.Now()))
+	if postQueryParams.BoardId != 0 {
+		query = query.Where("posts.board_id = ?", postQueryParams.BoardId)
+	}
+	if len(postQueryParams.Tags) > 0 {
+		tagged := repo.db.Model(&models.PostTagDBModel{}).
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name IN ?", postQueryParams.Tags)
+		if postQueryParams.TagMatch == models.TagMatchAll {
+			tagged = tagged.Group("post_tags.post_id").Having("COUNT(*) = ?", len(postQueryParams.Tags))
+		}
+		query = query.Where("posts.id IN (?)", tagged)
+	}
+
+	return query.
+		Select(`
+			posts.id,
+			posts.content,
+			posts.created_at,
+			posts.total_likes,
+			posts.board_id,
+			posts.hot_score,
+			posts.trending_score,
+			posts.edited_at,
+			posts.edited_at IS NOT NULL AS edited,
+			posts.expires_at,
+        	posts_likes.user_id IS NOT NULL AS IsLiked
+		`).
+		Joins("LEFT JOIN posts_likes ON posts.id = posts_likes.post_id AND posts_likes.user_id = ?", userId)
+}
+
+// SearchPosts returns a page of the posts matching an FTS MATCH expression, ranked by bm25.
+// FTS5's bm25() is lower for better matches, so the score is its negation. Deleted posts are not in the index,
+// expired and scheduled ones are left out until the reaper removes or the scheduler publishes them.
+func (repo *SQLitePostsRepository) SearchPosts(ctx context.Context, userId int, match string, limit, offset int) (models.SearchPostsCollection, error) {
+	posts := models.SearchPostsCollection{}
+	err := repo.db.WithContext(ctx).Raw(`
+		SELECT
+			posts.id,
+			posts.content,
+			posts.created_at,
+			posts.total_likes,
+			posts.board_id,
+			posts.edited_at,
+			posts.edited_at IS NOT NULL AS edited,
+			posts.expires_at,
+			posts_likes.user_id IS NOT NULL AS is_liked,
+			snippet(posts_fts, 0, ?, ?, '…', 24) AS snippet,
+			-bm25(posts_fts) AS score
+		FROM posts_fts
+		JOIN posts ON posts.id = posts_fts.rowid
+		LEFT JOIN posts_likes ON posts.id = posts_likes.post_id AND posts_likes.user_id = ?
+		WHERE posts_fts MATCH ? AND `+VisiblePost+`
+		ORDER BY bm25(posts_fts), posts.id DESC
+		LIMIT ? OFFSET ?
+	`, helper.SnippetMarkStart, helper.SnippetMarkEnd, userId, match, VisibleAt(time.Now()), limit, offset).
+		Scan(&posts).Error
+	if err != nil {
+		slog.Error("Failed to search posts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	for i := range posts {
+		posts[i].Snippet = helper.HighlightSnippet(posts[i].Snippet)
+	}
+
+	return posts, nil
+}
+
+// UpdatePosts updates the content of a post of the given user, keeping the previous version as a revision,
+// and re-syncs its hashtags in the same transaction.
+func (repo *SQLitePostsRepository) UpdatePosts(ctx context.Context, id int, userId int, post models.PostRequest, tags []string) (int64, error) {
+	var rowsAffected int64
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rowsAffected, err = revisePost(tx, post.Content, tags, false, "id = ? AND user_id = ?", id, userId)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("Failed to update post", slog.Int("postId", id), slog.String("error", err.Error()))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// DeletePost marks a post of the given user as deleted. The post keeps its comments and likes, so it comes back
+// whole when restored, and is only removed for good by PurgeDeletedPosts.
+func (repo *SQLitePostsRepository) DeletePost(id, userId int) (int64, error) {
+	result := repo.db.Model(&models.PostDBModel{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userId).
+		Update("deleted_at", time.Now())
 
 	if result.Error != nil {
-		slog.Error("Failed to update post", slog.Int("postId", id), slog.String("error", result.Error.Error()))
+		slog.Error("Failed to delete post", slog.Int("postId", id), slog.String("error", result.Error.Error()))
 		return -1, result.Error
 	}
 
 	return result.RowsAffected, nil
 }
 
-func (repo *SQLitePostsRepository) DeletePost(id, userId int) (int64, error) {
-	result := repo.db.Where("id = ? AND user_id = ?", id, userId).Delete(&models.PostDBModel{})
+// RestorePost brings back a post of the given user that was deleted since the given time.
+func (repo *SQLitePostsRepository) RestorePost(ctx context.Context, id, userId int, since time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.PostDBModel{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).
+		Where("julianday(deleted_at) >= julianday(?)", since.Format(time.RFC3339Nano)).
+		Update("deleted_at", nil)
 
 	if result.Error != nil {
-		slog.Error("Failed to delete post", slog.Int("postId", id), slog.String("error", result.Error.Error()))
+		slog.Error("Failed to restore post", slog.Int("postId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// PurgeDeletedPosts removes the posts deleted before the given time for good.
+// Their comments, likes, revisions and tags are removed by the cascade.
+func (repo *SQLitePostsRepository) PurgeDeletedPosts(ctx context.Context, before time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).
+		Where("deleted_at IS NOT NULL AND julianday(deleted_at) < julianday(?)", before.Format(time.RFC3339Nano)).
+		Delete(&models.PostDBModel{})
+
+	if result.Error != nil {
+		slog.Error("Failed to purge deleted posts", slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteExpiredPosts removes the posts that expired by the given time, deleted or not, and returns them
+// with their boards. Their comments and likes are removed by the cascade.
+func (repo *SQLitePostsRepository) DeleteExpiredPosts(ctx context.Context, now time.Time) ([]models.BoardContent, error) {
+	var expired []models.BoardContent
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PostDBModel{}).
+			Select("posts.id, boards.slug AS board").
+			Joins("JOIN boards ON boards.id = posts.board_id").
+			Where("posts.expires_at IS NOT NULL AND julianday(posts.expires_at) <= julianday(?)", now.Format(time.RFC3339Nano)).
+			Scan(&expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+
+		return tx.Where("id IN ?", helper.ContentIDs(expired)).Delete(&models.PostDBModel{}).Error
+	})
+
+	if err != nil {
+		slog.Error("Failed to delete expired posts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// GetOwnPosts returns a page of the posts of a user that are neither deleted nor expired, scheduled ones included,
+// latest first.
+func (repo *SQLitePostsRepository) GetOwnPosts(ctx context.Context, userId int, queryParams models.OwnPostsQueryParams) ([]models.OwnPost, error) {
+	posts := []models.OwnPost{}
+	err := repo.db.WithContext(ctx).
+		Model(&models.PostDBModel{}).
+		Select(`
+			posts.id,
+			posts.content,
+			posts.created_at,
+			posts.total_likes,
+			posts.board_id,
+			posts.edited_at,
+			posts.edited_at IS NOT NULL AS edited,
+			posts.expires_at,
+			posts.publish_at,
+			CASE WHEN posts.publish_at IS NULL THEN ? ELSE ? END AS state
+		`, models.PostStatePublished, models.PostStateScheduled).
+		Where("posts.user_id = ?", userId).
+		Where(LivePost, VisibleAt(time.Now())).
+		Order("julianday(COALESCE(posts.publish_at, posts.created_at)) DESC, posts.id DESC").
+		Limit(queryParams.Limit).
+		Offset((queryParams.Page - 1) * queryParams.Limit).
+		Scan(&posts).Error
+	if err != nil {
+		slog.Error("Failed to retrieve own posts", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// PublishDuePosts publishes the scheduled posts due by the given time and returns them. Publishing moves the
+// creation date of a post to the time it was scheduled for, so it shows up as new in the feed.
+// Deleted posts wait until they are restored.
+func (repo *SQLitePostsRepository) PublishDuePosts(ctx context.Context, now time.Time) ([]models.BoardContent, error) {
+	var due []models.BoardContent
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PostDBModel{}).
+			Select("posts.id, boards.slug AS board").
+			Joins("JOIN boards ON boards.id = posts.board_id").
+			Where("posts.publish_at IS NOT NULL AND posts.deleted_at IS NULL").
+			Where("julianday(posts.publish_at) <= julianday(?)", now.Format(time.RFC3339Nano)).
+			Scan(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		return tx.Model(&models.PostDBModel{}).
+			Where("id IN ?", helper.ContentIDs(due)).
+			Updates(map[string]interface{}{"created_at": gorm.Expr("publish_at"), "publish_at": nil}).Error
+	})
+
+	if err != nil {
+		slog.Error("Failed to publish scheduled posts", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return due, nil
+}
+
+// ModerateUpdatePost updates the content of any post, regardless of who wrote it, keeping the previous version
+// as a revision and re-syncing its hashtags. It must only be reachable by moderators.
+func (repo *SQLitePostsRepository) ModerateUpdatePost(ctx context.Context, id int, post models.PostRequest, tags []string) (int64, error) {
+	var rowsAffected int64
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rowsAffected, err = revisePost(tx, post.Content, tags, true, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("Failed to moderate post update", slog.Int("postId", id), slog.String("error", err.Error()))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// revisePost replaces the content of the post matching the condition. The replaced content is kept as a revision
+// and the hashtags are re-synced. Saving unchanged content leaves the post untouched but still counts as a match.
+// Deleted and expired posts never match, scheduled ones do. It has to run inside a transaction.
+func revisePost(tx *gorm.DB, content string, tags []string, byModerator bool, condition string, args ...interface{}) (int64, error) {
+	var current models.PostDBModel
+	err := tx.Select("id", "content").Where(condition, args...).Where(LivePost, VisibleAt(time.Now())).First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	if current.Content == content {
+		return 1, nil
+	}
+
+	revision := models.PostRevisionDBModel{PostId: current.ID, Content: current.Content, ByModerator: byModerator, CreatedAt: time.Now()}
+	if err := tx.Create(&revision).Error; err != nil {
+		return -1, err
+	}
+
+	result := tx.Model(&models.PostDBModel{}).Where("id = ?", current.ID).
+		Updates(map[string]interface{}{"content": content, "edited_at": revision.CreatedAt})
+	if result.Error != nil {
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, syncPostTags(tx, current.ID, tags)
+}
+
+// GetPostAuthorId returns the ID of the user who wrote a post, or 0 without an error when the post does not exist,
+// was deleted or expired. Scheduled posts are only found for their author, the given viewer.
+func (repo *SQLitePostsRepository) GetPostAuthorId(ctx context.Context, id, viewerId int) (int, error) {
+	var post models.PostDBModel
+	err := repo.db.WithContext(ctx).Select("user_id").
+		Where(LivePost, VisibleAt(time.Now())).
+		Where("posts.publish_at IS NULL OR posts.user_id = ?", viewerId).
+		First(&post, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve post author", slog.Int("postId", id), slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return post.UserId, nil
+}
+
+// GetThreadParticipants returns the IDs of the users who commented on a post, deleted comments included,
+// in the order of their first comment. It keeps the pseudonyms of the thread stable, see helper.AnnotateCommentAuthors.
+func (repo *SQLitePostsRepository) GetThreadParticipants(ctx context.Context, id int) ([]int, error) {
+	var participants []int
+	err := repo.db.WithContext(ctx).Model(&models.CommentsDbModel{}).
+		Where("post_id = ?", id).
+		Group("user_id").
+		Order("MIN(id)").
+		Pluck("user_id", &participants).Error
+	if err != nil {
+		slog.Error("Failed to retrieve thread participants", slog.Int("postId", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return participants, nil
+}
+
+// GetPostBoard returns the slug of the board of a post, or an empty string without an error when the post does not exist.
+func (repo *SQLitePostsRepository) GetPostBoard(ctx context.Context, id int) (string, error) {
+	var slugs []string
+	err := repo.db.WithContext(ctx).
+		Model(&models.PostDBModel{}).
+		Joins("JOIN boards ON boards.id = posts.board_id").
+		Where("posts.id = ?", id).
+		Pluck("boards.slug", &slugs).Error
+	if err != nil {
+		slog.Error("Failed to retrieve post board", slog.Int("postId", id), slog.String("error", err.Error()))
+		return "", err
+	}
+	if len(slugs) == 0 {
+		return "", nil
+	}
+
+	return slugs[0], nil
+}
+
+// GetPostRevisions returns the previous versions of a post, newest first.
+// The content replaced by moderators is only included with includeModerated.
+func (repo *SQLitePostsRepository) GetPostRevisions(ctx context.Context, id int, includeModerated bool) ([]models.Revision, error) {
+	revisions := []models.Revision{}
+	query := repo.db.WithContext(ctx).Model(&models.PostRevisionDBModel{}).Where("post_id = ?", id)
+	if !includeModerated {
+		query = query.Where("by_moderator = ?", false)
+	}
+	err := query.Order("id DESC").Scan(&revisions).Error
+	if err != nil {
+		slog.Error("Failed to retrieve post revisions", slog.Int("postId", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// syncPostTags replaces the hashtags of a post with the given ones, creating tags that do not exist yet.
+// It has to run inside the transaction that writes the post content.
+func syncPostTags(tx *gorm.DB, postId int, tags []string) error {
+	if err := tx.Where("post_id = ?", postId).Delete(&models.PostTagDBModel{}).Error; err != nil {
+		return err
+	}
+
+	for _, name := range tags {
+		tag := models.TagDBModel{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.PostTagDBModel{PostId: postId, TagId: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTags lists the tags in use with the number of posts carrying each, most used first.
+func (repo *SQLitePostsRepository) GetTags(ctx context.Context, queryParams models.TagQueryParams) ([]models.Tag, error) {
+	tags := []models.Tag{}
+
+	query := repo.db.WithContext(ctx).
+		Model(&models.TagDBModel{}).
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND "+VisiblePost, VisibleAt(time.Now()))
+	if queryParams.Prefix != "" {
+		query = query.Where("tags.name LIKE ? ESCAPE '\\'", escapeLike(queryParams.Prefix)+"%")
+	}
+
+	err := query.
+		Group("tags.id").
+		Order("count DESC, tags.name").
+		Limit(queryParams.Limit).
+		Scan(&tags).Error
+	if err != nil {
+		slog.Error("Failed to retrieve tags", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so user input only matches literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// ModerateDeletePost deletes any post for good, regardless of who wrote it or whether it was already deleted,
+// so removals by moderators cannot be restored. Its comments and likes are removed by the cascade.
+// It must only be reachable by moderators.
+func (repo *SQLitePostsRepository) ModerateDeletePost(ctx context.Context, id int) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("id = ?", id).Delete(&models.PostDBModel{})
+
+	if result.Error != nil {
+		slog.Error("Failed to moderate post deletion", slog.Int("postId", id), slog.String("error", result.Error.Error()))
 		return -1, result.Error
 	}
 
@@ -105,10 +601,19 @@ func (repo *SQLitePostsRepository) DeletePost(id, userId int) (int64, error) {
 // Transactions are used here because the operation involves two different tables. If one operation fails,
 // the entire transaction is rolled back to ensure data consistency.
 // We return rowsAffected so the handler can check it and provide a better response to the API user.
+// Deleted, expired and scheduled posts cannot be liked or unliked, ErrPostNotFound is returned for them.
 func (repo *SQLitePostsRepository) UpdateLikes(ctx context.Context, postId, userId int, sign int) (int64, error) {
 	var rowsAffected int64
 
 	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var visible int64
+		if err := tx.Model(&models.PostDBModel{}).Where("posts.id = ?", postId).Where(VisiblePost, VisibleAt(time.Now())).Count(&visible).Error; err != nil {
+			return err
+		}
+		if visible == 0 {
+			return ErrPostNotFound
+		}
+
 		if err := tx.Model(&models.PostDBModel{}).
 			Where("id = ? AND user_id = ?", postId, userId).
 			Update("total_likes", gorm.Expr("total_likes + ?", sign)).Error; err != nil {
@@ -121,10 +626,10 @@ func (repo *SQLitePostsRepository) UpdateLikes(ctx context.Context, postId, user
 		// allows us to handle this case efficiently in a single operation.Ignoring the insert if it already exists.
 		if sign > 0 {
 			rawSQL := `
-			INSERT OR IGNORE INTO posts_likes (post_id, user_id) 
-			VALUES (?, ?);
+			INSERT OR IGNORE INTO posts_likes (post_id, user_id, created_at) 
+			VALUES (?, ?, ?);
 			`
-			result := tx.Exec(rawSQL, postId, userId)
+			result := tx.Exec(rawSQL, postId, userId, time.Now())
 			if result.Error != nil {
 				slog.Error("Failed to insert like in transaction", slog.Int("postId", postId), slog.Int("userId", userId), slog.String("error", result.Error.Error()))
 				return result.Error
@@ -144,6 +649,9 @@ func (repo *SQLitePostsRepository) UpdateLikes(ctx context.Context, postId, user
 		return nil
 	})
 
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
 	if err != nil {
 		slog.Error("Transaction failed for updating likes", slog.Int("postId", postId), slog.Int("userId", userId), slog.String("error", err.Error()))
 		return 0, err
@@ -151,3 +659,78 @@ func (repo *SQLitePostsRepository) UpdateLikes(ctx context.Context, postId, user
 
 	return rowsAffected, nil
 }
+
+// GetRankingCandidates returns the posts created since the given time with their likes and number of comments.
+func (repo *SQLitePostsRepository) GetRankingCandidates(ctx context.Context, since time.Time) ([]models.RankingCandidate, error) {
+	var candidates []models.RankingCandidate
+	err := repo.db.WithContext(ctx).
+		Model(&models.PostDBModel{}).
+		Select("posts.id, posts.created_at, posts.total_likes, (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) AS comments").
+		Where(VisiblePost, VisibleAt(time.Now())).
+		Where("julianday(posts.created_at) >= julianday(?)", since.Format(time.RFC3339Nano)).
+		Scan(&candidates).Error
+	if err != nil {
+		slog.Error("Failed to retrieve ranking candidates", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// GetRecentReactions returns the likes and the comments given since the given time.
+// Likes from before likes were timestamped are left out.
+func (repo *SQLitePostsRepository) GetRecentReactions(ctx context.Context, since time.Time) ([]models.PostReaction, []models.PostReaction, error) {
+	var likes, comments []models.PostReaction
+
+	err := repo.db.WithContext(ctx).
+		Model(&models.PostsLikesDBModel{}).
+		Select("post_id, created_at").
+		Where("created_at IS NOT NULL AND julianday(created_at) >= julianday(?)", since.Format(time.RFC3339Nano)).
+		Scan(&likes).Error
+	if err != nil {
+		slog.Error("Failed to retrieve recent likes", slog.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	err = repo.db.WithContext(ctx).
+		Model(&models.CommentsDbModel{}).
+		Select("post_id, created_at").
+		Where("deleted_at IS NULL AND julianday(created_at) >= julianday(?)", since.Format(time.RFC3339Nano)).
+		Scan(&comments).Error
+	if err != nil {
+		slog.Error("Failed to retrieve recent comments", slog.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	return likes, comments, nil
+}
+
+// SaveRankings replaces every score with the given ones in one transaction, so posts without a ranking drop to 0.
+func (repo *SQLitePostsRepository) SaveRankings(ctx context.Context, rankings []models.PostRanking) error {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PostDBModel{}).
+			Where("hot_score != 0 OR trending_score != 0").
+			Updates(map[string]interface{}{"hot_score": 0, "trending_score": 0}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, ranking := range rankings {
+			err := tx.Model(&models.PostDBModel{}).
+				Where("id = ?", ranking.PostId).
+				Updates(map[string]interface{}{"hot_score": ranking.HotScore, "trending_score": ranking.TrendingScore}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to save rankings", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
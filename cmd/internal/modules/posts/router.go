@@ -1,20 +1,44 @@
 package posts
 
 import (
+	"anon-confessions/cmd/internal/middleware"
+	"anon-confessions/cmd/internal/models"
+
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterPostRoutes registers all routes related to posts.
+// Requests authenticated with an API key need the scope matching each route.
 func RegisterPostRoutes(router *gin.RouterGroup, postsHandler *PostsHandler) {
 	postGroup := router.Group("/posts")
 	{
-		postGroup.POST("/", postsHandler.CreatePostHandler)
-		postGroup.GET("/", postsHandler.GetPostsCollectionHandler)
-		postGroup.GET("/:id", postsHandler.GetPostHandler)
-		postGroup.PATCH("/:id", postsHandler.UpdatePostsHandler)
-		postGroup.DELETE("/:id", postsHandler.DeletePostsHandler)
-		postGroup.PATCH("/:id/likes", postsHandler.UpdateLikesHandler)
+		postGroup.POST("/", middleware.RequireScope(models.ScopePostsWrite), postsHandler.CreatePostHandler)
+		postGroup.GET("/", middleware.RequireScope(models.ScopePostsRead), postsHandler.GetPostsCollectionHandler)
+		postGroup.GET("/search", middleware.RequireScope(models.ScopePostsRead), postsHandler.SearchPostsHandler)
+		postGroup.GET("/mine", middleware.RequireScope(models.ScopePostsRead), postsHandler.GetOwnPostsHandler)
+		postGroup.GET("/:id", middleware.RequireScope(models.ScopePostsRead), postsHandler.GetPostHandler)
+		postGroup.GET("/:id/revisions", middleware.RequireScope(models.ScopePostsRead), postsHandler.GetPostRevisionsHandler)
+		postGroup.PATCH("/:id", middleware.RequireScope(models.ScopePostsWrite), postsHandler.UpdatePostsHandler)
+		postGroup.DELETE("/:id", middleware.RequireScope(models.ScopePostsWrite), postsHandler.DeletePostsHandler)
+		postGroup.POST("/:id/restore", middleware.RequireScope(models.ScopePostsWrite), postsHandler.RestorePostHandler)
+		postGroup.PATCH("/:id/likes", middleware.RequireScope(models.ScopeLikesWrite), postsHandler.UpdateLikesHandler)
+
+	}
+
+	tagGroup := router.Group("/tags")
+	{
+		tagGroup.GET("", middleware.RequireScope(models.ScopePostsRead), postsHandler.GetTagsHandler)
+	}
+}
 
+// RegisterAdminPostRoutes registers the moderation routes for posts.
+// The router group is expected to only let moderators through.
+func RegisterAdminPostRoutes(router *gin.RouterGroup, postsHandler *PostsHandler) {
+	postGroup := router.Group("/posts")
+	postGroup.Use(middleware.RequireScope(models.ScopeModerate))
+	{
+		postGroup.PATCH("/:id", postsHandler.ModerateUpdatePostHandler)
+		postGroup.DELETE("/:id", postsHandler.ModerateDeletePostHandler)
 	}
 }
 
@@ -22,7 +46,7 @@ func RegisterPostRoutes(router *gin.RouterGroup, postsHandler *PostsHandler) {
 
 // GetPost handles retrieving a post by its ID.
 // @Summary Retrieve a post
-// @Description Fetches a post using its unique ID. Requires authentication using X-Account-Number.
+// @Description Fetches a post using its unique ID. Its comments carry per-post pseudonyms of their authors and an isOP flag. Requires authentication using X-Account-Number.
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -30,24 +54,45 @@ func RegisterPostRoutes(router *gin.RouterGroup, postsHandler *PostsHandler) {
 // @Success 200 {object} models.GetPost "Post retrieved successfully"
 // @Failure 400 {object} helper.ErrorMessage "Invalid post ID"
 // @Failure 401 {object} helper.ErrorMessage "Missing or invalid X-Account-Number"
+// @Failure 404 {object} helper.ErrorMessage "Post does not exist"
 // @Failure 500 {object} helper.ErrorMessage "Failed to retrieve post"
 // @Router /posts/{id} [get]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func getPostsHandler(c *gin.Context) {}
 
+// GetPostRevisionsHandler handles retrieving the edit history of a post.
+// @Summary Retrieve the edit history of a post
+// @Description Lists the previous versions of a post, newest first. Only the author and moderators can see them unless PUBLIC_EDIT_HISTORY is enabled. Content removed by a moderator edit is only listed for the author and moderators.
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {array} models.Revision "Revisions retrieved successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid post ID"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid X-Account-Number"
+// @Failure 403 {object} helper.ErrorMessage "Edit history not visible"
+// @Failure 404 {object} helper.ErrorMessage "Post does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve revisions"
+// @Router /posts/{id}/revisions [get]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func getPostRevisionsHandler(c *gin.Context) {}
+
 // CreatePostHandler handles the creation of a new post.
 // @Summary Create a new post
-// @Description Allows authenticated users to create a new post using their X-Account-Number.
+// @Description Allows authenticated users to create a new post using their X-Account-Number. With expiresIn, in seconds, the post disappears after that long, at most MAX_POST_EXPIRY.
+// @Description With publishAt, a future RFC 3339 time at most MAX_SCHEDULE_AHEAD away, the post stays hidden from everyone but its author until then and is announced when it goes live.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param post body models.PostRequest true "Post content"
 // @Success 201 {object} helper.SuccessMessage "Post created successfully"
-// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body, expiresIn too long or publishAt in the past or too far ahead"
 // @Failure 401 {object} helper.ErrorMessage "Invalid or missing X-Account-Number"
 // @Failure 500 {object} helper.ErrorMessage "Internal server error"
 // @Router /posts [post]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func createPostHandler(c *gin.Context) {}
 
 // GetPostsCollectionHandler handles retrieving a collection of posts.
@@ -57,19 +102,80 @@ func createPostHandler(c *gin.Context) {}
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number (default: 1)" minimum(1) default(1)
-// @Param limit query int false "Number of items per page (default: 10)" minimum(1) default(10)
+// @Description Page size and sort order default to the preferences of the user (GET /users/me/preferences) when they are left out.
+// @Param limit query int false "Number of items per page (default: the user's page size preference)" minimum(1) default(10)
 // @Param creation_date query string false "Sort by creation date (asc or desc)" Enums(asc,desc) default()
 // @Param sort_by_likes query string false "Sort by likes (asc or desc)" Enums(asc,desc) default()
+// @Param sort query string false "Rank by recent likes and comments, best first; overrides the other sort options" Enums(hot,trending)
+// @Param tag query []string false "Only posts with these hashtags, with or without the #" collectionFormat(multi)
+// @Param tag_match query string false "Whether posts need any (default) or all of the given tags" Enums(any,all)
+// @Param board query string false "Only posts of the board with this slug"
+// @Description Sending a cursor switches to keyset pagination: an empty cursor returns the first page in a models.PostsPage,
+// @Description whose nextCursor and prevCursor lead to the neighbouring pages. Cursors keep their sort order, filters have to be sent again.
+// @Param cursor query string false "Opaque cursor from a previous page, empty for the first page"
 // @Success 200 {object} models.GetPostsCollection "Posts retrieved successfully"
 // @Success 200 {object} map[string]interface{} "{} if no posts are found"
+// @Success 200 {object} models.PostsPage "Page of posts when paginating with cursors"
+// @Failure 400 {object} helper.ErrorMessage "Invalid query params or cursor"
 // @Failure 500 {object} helper.ErrorMessage "Failed to retrieve posts"
 // @Router /posts [get]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *PostsHandler) getPostsCollectionHandler(c *gin.Context) {}
 
+// GetTagsHandler handles listing hashtags.
+// @Summary List tags
+// @Description Lists the hashtags used in posts with the number of posts carrying each, most used first.
+// @Description Hashtags are taken from the content of posts whenever a post is created or edited.
+// @Tags posts
+// @Produce json
+// @Param prefix query string false "Only tags starting with this prefix" maxlength(50)
+// @Param limit query int false "Maximum number of tags (default: 50)" minimum(1) maximum(200) default(50)
+// @Success 200 {array} models.Tag "Tags retrieved successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid query params"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve tags"
+// @Router /tags [get]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func getTagsHandler(c *gin.Context) {}
+
+// SearchPostsHandler handles searching posts.
+// @Summary Search posts
+// @Description Full-text search over the content of posts. Every word of the query has to match; a trailing * matches word prefixes.
+// @Description Results are ranked by bm25, best matches first, and carry an HTML-escaped snippet with the matching words wrapped in <mark> tags.
+// @Tags posts
+// @Produce json
+// @Param q query string true "Search query" maxlength(200)
+// @Param page query int false "Page number (default: 1)" minimum(1) default(1)
+// @Param limit query int false "Number of items per page (default: 10)" minimum(1) maximum(100) default(10)
+// @Success 200 {array} models.SearchPost "Search results retrieved successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid or empty search query"
+// @Failure 500 {object} helper.ErrorMessage "Failed to search posts"
+// @Router /posts/search [get]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func searchPostsHandler(c *gin.Context) {}
+
+// GetOwnPostsHandler handles listing the posts of the logged-in user.
+// @Summary List own posts
+// @Description Lists the posts of the logged-in user, including the scheduled ones that are not public yet, newest first.
+// @Description Each post carries its state, published or scheduled, and for scheduled posts the time they go live.
+// @Tags posts
+// @Produce json
+// @Param page query int false "Page number (default: 1)" minimum(1) default(1)
+// @Param limit query int false "Number of items per page (default: 10)" minimum(1) maximum(100) default(10)
+// @Success 200 {array} models.OwnPost "Posts retrieved successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid query params"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid X-Account-Number"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve posts"
+// @Router /posts/mine [get]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func getOwnPostsHandler(c *gin.Context) {}
+
 // DeletePostsHandler handles deleting a post by its ID.
 // @Summary Delete a post
-// @Description Deletes a post using its unique ID. Requires the user to be logged in and authenticated using X-Account-Number.
+// @Description Deletes a post using its unique ID. The author can restore it within RESTORE_WINDOW, after which it is removed for good. Requires the user to be logged in and authenticated using X-Account-Number.
 // @Tags posts
 // @Accept json
 // @Produce json
@@ -80,8 +186,25 @@ func (h *PostsHandler) getPostsCollectionHandler(c *gin.Context) {}
 // @Failure 500 {object} helper.ErrorMessage "Failed to delete post"
 // @Router /posts/{id} [delete]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *PostsHandler) deletePostsHandler(c *gin.Context) {}
 
+// RestorePostHandler handles restoring a deleted post.
+// @Summary Restore a deleted post
+// @Description Brings back a post the logged-in user deleted, as long as RESTORE_WINDOW has not passed since.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} helper.SuccessMessage "Post restored successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid post ID"
+// @Failure 401 {object} helper.ErrorMessage "Unauthorized user or missing X-Account-Number"
+// @Failure 404 {object} helper.ErrorMessage "No deleted post to restore"
+// @Failure 500 {object} helper.ErrorMessage "Failed to restore post"
+// @Router /posts/{id}/restore [post]
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *PostsHandler) restorePostHandler(c *gin.Context) {}
+
 // UpdatePostsHandler handles updating a post by its ID.
 // @Summary Update a post
 // @Description Updates a post's content. Requires the user to be authenticated using X-Account-Number.
@@ -96,6 +219,7 @@ func (h *PostsHandler) deletePostsHandler(c *gin.Context) {}
 // @Failure 500 {object} helper.ErrorMessage "Failed to update post"
 // @Router /posts/{id} [patch]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *PostsHandler) updatePostsHandler(c *gin.Context) {}
 
 // UpdateLikesHandler handles liking or unliking a post by a user.
@@ -112,4 +236,43 @@ func (h *PostsHandler) updatePostsHandler(c *gin.Context) {}
 // @Failure 500 {object} helper.ErrorMessage "Failed to apply action on the post"
 // @Router /posts/{id}/likes [patch]
 // @security AccountNumberAuth
+// @security APIKeyAuth
 func (h *PostsHandler) updateLikesHandler(c *gin.Context) {}
+
+// ModerateUpdatePostHandler handles a moderator updating any post.
+// @Summary Moderate a post
+// @Description Replaces the content of any post, regardless of its author. Requires the moderator or admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param post body models.PostRequest true "Post content"
+// @Success 200 {object} helper.SuccessMessage "Updated successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body or parameters"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "Post not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to update post"
+// @Router /admin/posts/{id} [patch]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *PostsHandler) moderateUpdatePostHandler(c *gin.Context) {}
+
+// ModerateDeletePostHandler handles a moderator deleting any post.
+// @Summary Remove a post
+// @Description Deletes any post along with its comments and likes, regardless of its author. Requires the moderator or admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} helper.SuccessMessage "Post deleted successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "Post not found"
+// @Failure 500 {object} helper.ErrorMessage "Failed to delete post"
+// @Router /admin/posts/{id} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+// @security APIKeyAuth
+func (h *PostsHandler) moderateDeletePostHandler(c *gin.Context) {}
@@ -1,55 +1,150 @@
 package posts
 
 import (
+	"anon-confessions/cmd/internal/config"
+	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
 	"anon-confessions/cmd/internal/websocket"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 )
 
+var (
+	// ErrEmptySearchQuery is returned when a search query contains no searchable words.
+	ErrEmptySearchQuery = errors.New("search query contains no searchable words")
+	// ErrPostNotFound is returned when a post does not exist.
+	ErrPostNotFound = errors.New("post not found")
+	// ErrHistoryNotVisible is returned when someone other than the author or a moderator asks for the edit history
+	// while it is not public.
+	ErrHistoryNotVisible = errors.New("edit history not visible")
+	// ErrExpiryTooLong is returned when a post is asked to expire later than the configured maximum.
+	ErrExpiryTooLong = errors.New("post expiry exceeds the maximum")
+	// ErrPublishAtInPast is returned when a post is scheduled for a time that has already passed.
+	ErrPublishAtInPast = errors.New("publish time is in the past")
+	// ErrPublishAtTooLate is returned when a post is scheduled further ahead than the configured maximum.
+	ErrPublishAtTooLate = errors.New("publish time exceeds the maximum")
+	// ErrDraftNotFound is returned when a draft to publish does not exist, has expired or was already published.
+	ErrDraftNotFound = errors.New("draft not found")
+)
+
 type PostsService struct {
 	PostsRepo PostsRepository
+	cfg       *config.Config
 	hub       *websocket.Hub
 }
 
-func NewPostsService(PostsRepo PostsRepository, hub *websocket.Hub) *PostsService {
-	return &PostsService{PostsRepo: PostsRepo, hub: hub}
+func NewPostsService(PostsRepo PostsRepository, cfg *config.Config, hub *websocket.Hub) *PostsService {
+	return &PostsService{PostsRepo: PostsRepo, cfg: cfg, hub: hub}
 }
 
-func (s *PostsService) CreatePosts(ctx context.Context, post models.PostRequest, userID int) error {
-	slog.Info("Creating a new post", slog.Int("userId", userID))
+// CreatePosts creates a post on the given board and announces it to the clients following every board and to those following that board.
+// Posts with ExpiresIn expire that long after being published. Posts with PublishAt are only announced once the scheduler
+// publishes them, see PublishScheduledPosts.
+// A draftID other than 0 publishes that draft of the user: it is removed in the same transaction as the post is created,
+// so it becomes one post at most. ErrDraftNotFound is returned when it is gone or has expired.
+func (s *PostsService) CreatePosts(ctx context.Context, post models.PostRequest, board models.Board, userID, draftID int) error {
+	slog.Info("Creating a new post", slog.Int("userId", userID), slog.String("board", board.Slug), slog.Int("draftId", draftID))
+
+	expiresIn := time.Duration(post.ExpiresIn) * time.Second
+	if expiresIn > s.cfg.Content.MaxPostExpiry {
+		return ErrExpiryTooLong
+	}
 
 	postDBModel := models.PostDBModel{
 		Content:   post.Content,
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
 		UserId:    userID,
+		BoardId:   board.ID,
+	}
+	publishedAt := postDBModel.CreatedAt
+	if post.PublishAt != nil {
+		if !post.PublishAt.After(postDBModel.CreatedAt) {
+			return ErrPublishAtInPast
+		}
+		if post.PublishAt.After(postDBModel.CreatedAt.Add(s.cfg.Content.MaxScheduleAhead)) {
+			return ErrPublishAtTooLate
+		}
+		publishedAt = post.PublishAt.UTC()
+		postDBModel.PublishAt = &publishedAt
+	}
+	if expiresIn > 0 {
+		expiresAt := publishedAt.Add(expiresIn)
+		postDBModel.ExpiresAt = &expiresAt
 	}
 
-	err := s.PostsRepo.CreatePosts(ctx, postDBModel)
+	err := s.PostsRepo.CreatePosts(ctx, postDBModel, helper.ExtractTags(post.Content), draftID)
+	if errors.Is(err, ErrDraftNotFound) {
+		return err
+	}
 	if err != nil {
 		slog.Error("Failed to create post", slog.String("error", err.Error()), slog.Int("userId", userID))
 		return err
 	}
 
 	slog.Info("Post created successfully", slog.Int("userId", userID))
+	if postDBModel.PublishAt != nil {
+		return nil
+	}
+
+	s.announceNewPost(board.Slug)
+	return nil
+}
+
+// PublishScheduledPosts publishes the scheduled posts that are due and announces them like new posts.
+// It runs as a background job; posts due while the server was down are published on its first run.
+func (s *PostsService) PublishScheduledPosts(ctx context.Context) error {
+	due, err := s.PostsRepo.PublishDuePosts(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to publish scheduled posts: %w", err)
+	}
 
-	wsMsg := models.WebSocketMessage{
+	for _, post := range due {
+		slog.Info("Published scheduled post", slog.Int("postId", post.ID), slog.String("board", post.Board))
+		s.announceNewPost(post.Board)
+	}
+	return nil
+}
+
+// announceNewPost tells the clients following every board and those following the given board about a new post.
+func (s *PostsService) announceNewPost(board string) {
+	s.broadcast(models.WebSocketMessage{
 		Type:    "newPost",
 		Message: "New Post was created",
-	}
+		Content: map[string]interface{}{
+			"board": board,
+		},
+	}, board)
+
+	slog.Debug("Broadcasted new post message via WebSocket", slog.String("board", board))
+}
 
+// broadcast sends a message to the clients following every board and, unless board is empty,
+// to the clients following that board.
+func (s *PostsService) broadcast(wsMsg models.WebSocketMessage, board string) {
 	marshalledWSMsg, err := json.Marshal(wsMsg)
 	if err != nil {
-		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()))
-		return nil
+		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()), slog.String("type", wsMsg.Type))
+		return
 	}
 	s.hub.Broadcast <- marshalledWSMsg
+	if board != "" {
+		s.hub.BoardBroadcast <- websocket.BoardMessage{Board: board, Message: marshalledWSMsg}
+	}
+}
 
-	slog.Debug("Broadcasted new post message via WebSocket", slog.Int("userId", userID))
-	return nil
+// GetOwnPosts returns a page of the posts of the user, including the scheduled ones, each with its state.
+func (s *PostsService) GetOwnPosts(ctx context.Context, userId int, queryParams models.OwnPostsQueryParams) ([]models.OwnPost, error) {
+	posts, err := s.PostsRepo.GetOwnPosts(ctx, userId, queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve own posts: %w", err)
+	}
+
+	return posts, nil
 }
 
 func (s *PostsService) GetPost(ctx context.Context, postID int) (*models.GetPostWithComments, error) {
@@ -60,6 +155,20 @@ func (s *PostsService) GetPost(ctx context.Context, postID int) (*models.GetPost
 		slog.Error("Failed to retrieve post", slog.Int("postId", postID), slog.String("error", err.Error()))
 		return nil, fmt.Errorf("failed to retrieve post: %w", err)
 	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+
+	participants, err := s.PostsRepo.GetThreadParticipants(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve thread participants: %w", err)
+	}
+
+	postAuthorID := post.UserId
+	if post.Anonymized {
+		postAuthorID = 0
+	}
+	helper.AnnotateCommentAuthors(s.cfg.Content.PseudonymSecret, post.ID, postAuthorID, participants, post.Comments)
 
 	slog.Info("Post retrieved successfully", slog.Int("postId", postID))
 	return post, nil
@@ -68,6 +177,8 @@ func (s *PostsService) GetPost(ctx context.Context, postID int) (*models.GetPost
 func (s *PostsService) GetPostsCollection(ctx context.Context, userId int, postQueryParam models.PostQueryParams) (*models.GetPostsCollection, error) {
 	slog.Info("Fetching posts collection", slog.Int("userId", userId))
 
+	postQueryParam.Tags = normalizeTagFilter(postQueryParam.Tags)
+
 	postCollection, err := s.PostsRepo.GetPostsCollection(ctx, userId, postQueryParam)
 	if err != nil {
 		slog.Error("Failed to retrieve posts collection", slog.String("error", err.Error()), slog.Int("userId", userId))
@@ -78,6 +189,189 @@ func (s *PostsService) GetPostsCollection(ctx context.Context, userId int, postQ
 	return postCollection, nil
 }
 
+// GetPostsPage returns a page of the feed with the cursors of the neighbouring pages.
+// Without a cursor it starts at the top of the feed in the requested sort order, otherwise the cursor decides the order.
+func (s *PostsService) GetPostsPage(ctx context.Context, userId int, postQueryParam models.PostQueryParams) (*models.PostsPage, error) {
+	slog.Info("Fetching posts page", slog.Int("userId", userId))
+
+	cursor := models.PostCursor{}
+	if postQueryParam.Cursor != "" {
+		decoded, err := helper.DecodeCursor(postQueryParam.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = *decoded
+	} else {
+		cursor.SortBy, cursor.Order = helper.PostSortOrder(postQueryParam)
+	}
+	postQueryParam.Tags = normalizeTagFilter(postQueryParam.Tags)
+
+	// One extra post tells whether there is another page in the direction of the cursor.
+	posts, err := s.PostsRepo.GetPostsPage(ctx, userId, postQueryParam, cursor, postQueryParam.Limit+1)
+	if err != nil {
+		slog.Error("Failed to retrieve posts page", slog.String("error", err.Error()), slog.Int("userId", userId))
+		return nil, fmt.Errorf("failed to retrieve posts: %w", err)
+	}
+
+	hasMore := len(posts) > postQueryParam.Limit
+	if hasMore && cursor.Backward {
+		posts = posts[1:]
+	} else if hasMore {
+		posts = posts[:postQueryParam.Limit]
+	}
+
+	page := &models.PostsPage{Posts: posts}
+	if len(posts) == 0 {
+		return page, nil
+	}
+
+	positionOf := func(post models.GetPost, backward bool) *string {
+		score := post.HotScore
+		if cursor.SortBy == models.PostSortTrending {
+			score = post.TrendingScore
+		}
+
+		encoded := helper.EncodeCursor(models.PostCursor{
+			SortBy:    cursor.SortBy,
+			Order:     cursor.Order,
+			CreatedAt: post.CreatedAt,
+			Likes:     post.TotalLikes,
+			Score:     score,
+			ID:        post.ID,
+			Backward:  backward,
+		})
+		return &encoded
+	}
+
+	// Coming from a cursor means there is a page on the side it came from.
+	if cursor.Backward {
+		page.Pagination.NextCursor = positionOf(posts[len(posts)-1], false)
+		if hasMore {
+			page.Pagination.PrevCursor = positionOf(posts[0], true)
+		}
+	} else {
+		if hasMore {
+			page.Pagination.NextCursor = positionOf(posts[len(posts)-1], false)
+		}
+		if cursor.ID != 0 {
+			page.Pagination.PrevCursor = positionOf(posts[0], true)
+		}
+	}
+
+	return page, nil
+}
+
+// RefreshRankings recomputes the scores of the hot and trending feeds. It is run periodically by a background job.
+// Posts created within the hot window are scored by their likes and comments and their age. Posts that got likes
+// or comments within the trending window are scored by those reactions, each counting less the older it is.
+func (s *PostsService) RefreshRankings(ctx context.Context) error {
+	now := time.Now()
+	cfg := s.cfg.Ranking
+
+	candidates, err := s.PostsRepo.GetRankingCandidates(ctx, now.Add(-cfg.HotWindow))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve ranking candidates: %w", err)
+	}
+	likes, comments, err := s.PostsRepo.GetRecentReactions(ctx, now.Add(-cfg.TrendingWindow))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve recent reactions: %w", err)
+	}
+
+	rankings := make(map[int]*models.PostRanking)
+	rankingOf := func(postId int) *models.PostRanking {
+		if rankings[postId] == nil {
+			rankings[postId] = &models.PostRanking{PostId: postId}
+		}
+		return rankings[postId]
+	}
+
+	for _, candidate := range candidates {
+		rankingOf(candidate.ID).HotScore = helper.HotScore(candidate.TotalLikes, candidate.Comments, now.Sub(candidate.CreatedAt))
+	}
+
+	halfLife := cfg.TrendingWindow / 4
+	for _, like := range likes {
+		rankingOf(like.PostId).TrendingScore += helper.TrendingWeight(now.Sub(like.CreatedAt), halfLife)
+	}
+	for _, comment := range comments {
+		rankingOf(comment.PostId).TrendingScore += helper.CommentWeight * helper.TrendingWeight(now.Sub(comment.CreatedAt), halfLife)
+	}
+
+	result := make([]models.PostRanking, 0, len(rankings))
+	for _, ranking := range rankings {
+		result = append(result, *ranking)
+	}
+	if err := s.PostsRepo.SaveRankings(ctx, result); err != nil {
+		return fmt.Errorf("failed to save rankings: %w", err)
+	}
+
+	slog.Info("Post rankings refreshed", slog.Int("posts", len(result)), slog.Duration("took", time.Since(now)))
+	return nil
+}
+
+// normalizeTagFilter matches tags the way they are stored and drops duplicates, which would break the "all" match.
+func normalizeTagFilter(filter []string) []string {
+	tags := make([]string, 0, len(filter))
+	for _, tag := range filter {
+		if tag = helper.NormalizeTag(tag); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// GetPostRevisions returns the previous versions of a post, newest first. Only the author and moderators
+// can see them, unless the edit history is public. Content removed by a moderator edit is only shown to them as well.
+// Authors can also see the history of their scheduled posts.
+func (s *PostsService) GetPostRevisions(ctx context.Context, postId, userId int, isModerator bool) ([]models.Revision, error) {
+	authorId, err := s.PostsRepo.GetPostAuthorId(ctx, postId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve post: %w", err)
+	}
+	if authorId == 0 {
+		return nil, ErrPostNotFound
+	}
+	if authorId != userId && !isModerator && !s.cfg.Content.PublicEditHistory {
+		return nil, ErrHistoryNotVisible
+	}
+
+	revisions, err := s.PostsRepo.GetPostRevisions(ctx, postId, isModerator || authorId == userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetTags lists the tags in use with their usage counts.
+func (s *PostsService) GetTags(ctx context.Context, queryParams models.TagQueryParams) ([]models.Tag, error) {
+	queryParams.Prefix = helper.NormalizeTag(queryParams.Prefix)
+
+	tags, err := s.PostsRepo.GetTags(ctx, queryParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// SearchPosts returns a page of the posts matching a free text query, best matches first.
+func (s *PostsService) SearchPosts(ctx context.Context, userId int, queryParams models.SearchPostsQueryParams) (models.SearchPostsCollection, error) {
+	match := helper.BuildMatchQuery(queryParams.Query)
+	if match == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	posts, err := s.PostsRepo.SearchPosts(ctx, userId, match, queryParams.Limit, (queryParams.Page-1)*queryParams.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+// DeletePost marks a post of the user as deleted. It can be restored until the restore window has passed.
 func (s *PostsService) DeletePost(id int, userId int) (int64, error) {
 	slog.Info("Attempting to delete post", slog.Int("postId", id), slog.Int("userId", userId))
 
@@ -96,10 +390,78 @@ func (s *PostsService) DeletePost(id int, userId int) (int64, error) {
 	return rowsAffected, nil
 }
 
+// RestorePost brings back a post the user deleted within the restore window.
+func (s *PostsService) RestorePost(ctx context.Context, id, userId int) (int64, error) {
+	slog.Info("Attempting to restore post", slog.Int("postId", id), slog.Int("userId", userId))
+
+	rowsAffected, err := s.PostsRepo.RestorePost(ctx, id, userId, time.Now().Add(-s.cfg.Content.RestoreWindow))
+	if err != nil {
+		return -1, fmt.Errorf("failed to restore post: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ReapExpiredPosts removes the expired posts along with their comments and likes and tells connected clients
+// which posts expired. It runs as a background job.
+func (s *PostsService) ReapExpiredPosts(ctx context.Context) error {
+	expired, err := s.PostsRepo.DeleteExpiredPosts(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired posts: %w", err)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	slog.Info("Removed expired posts", slog.Int("count", len(expired)))
+
+	s.broadcast(models.WebSocketMessage{
+		Type:    "postExpired",
+		Message: "Posts expired",
+		Content: map[string]interface{}{
+			"postIds": helper.ContentIDs(expired),
+		},
+	}, "")
+
+	// The followers of each board only hear about the posts of their board
+	for board, postIds := range helper.GroupByBoard(expired) {
+		wsMsg := models.WebSocketMessage{
+			Type:    "postExpired",
+			Message: "Posts expired",
+			Content: map[string]interface{}{
+				"board":   board,
+				"postIds": postIds,
+			},
+		}
+
+		marshalledWSMsg, err := json.Marshal(wsMsg)
+		if err != nil {
+			slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()))
+			continue
+		}
+		s.hub.BoardBroadcast <- websocket.BoardMessage{Board: board, Message: marshalledWSMsg}
+	}
+
+	return nil
+}
+
+// PurgeDeletedPosts removes the posts deleted longer ago than the restore window for good. It runs as a background job.
+func (s *PostsService) PurgeDeletedPosts(ctx context.Context) error {
+	rowsAffected, err := s.PostsRepo.PurgeDeletedPosts(ctx, time.Now().Add(-s.cfg.Content.RestoreWindow))
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted posts: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("Purged deleted posts", slog.Int64("count", rowsAffected))
+	}
+	return nil
+}
+
 func (s *PostsService) UpdatePosts(ctx context.Context, postId, userId int, post models.PostRequest) (int64, error) {
 	slog.Info("Attempting to update post", slog.Int("postId", postId), slog.Int("userId", userId))
 
-	rowsAffected, err := s.PostsRepo.UpdatePosts(ctx, postId, userId, post)
+	rowsAffected, err := s.PostsRepo.UpdatePosts(ctx, postId, userId, post, helper.ExtractTags(post.Content))
 	if err != nil {
 		slog.Error("Failed to update post", slog.Int("postId", postId), slog.String("error", err.Error()))
 		return -1, fmt.Errorf("failed to update post: %w", err)
@@ -114,6 +476,51 @@ func (s *PostsService) UpdatePosts(ctx context.Context, postId, userId int, post
 	return rowsAffected, nil
 }
 
+// ModeratePostUpdate lets a moderator replace the content of any post.
+func (s *PostsService) ModeratePostUpdate(ctx context.Context, postId, moderatorId int, post models.PostRequest) (int64, error) {
+	slog.Info("Moderator updating post", slog.Int("postId", postId), slog.Int("moderatorId", moderatorId))
+
+	rowsAffected, err := s.PostsRepo.ModerateUpdatePost(ctx, postId, post, helper.ExtractTags(post.Content))
+	if err != nil {
+		slog.Error("Failed to moderate post update", slog.Int("postId", postId), slog.String("error", err.Error()))
+		return -1, fmt.Errorf("failed to update post: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// ModeratePostDeletion lets a moderator delete any post and tells connected clients to drop it.
+func (s *PostsService) ModeratePostDeletion(ctx context.Context, postId, moderatorId int) (int64, error) {
+	slog.Info("Moderator deleting post", slog.Int("postId", postId), slog.Int("moderatorId", moderatorId))
+
+	// The board is looked up first, since the post is gone afterwards
+	board, err := s.PostsRepo.GetPostBoard(ctx, postId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve post board: %w", err)
+	}
+
+	rowsAffected, err := s.PostsRepo.ModerateDeletePost(ctx, postId)
+	if err != nil {
+		slog.Error("Failed to moderate post deletion", slog.Int("postId", postId), slog.String("error", err.Error()))
+		return -1, fmt.Errorf("failed to delete post: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	s.broadcast(models.WebSocketMessage{
+		Type:    "contentRemoved",
+		Message: "Content was removed",
+		Content: map[string]interface{}{
+			"board":      board,
+			"postIds":    []int{postId},
+			"commentIds": []int{},
+		},
+	}, board)
+
+	return rowsAffected, nil
+}
+
 func (s *PostsService) UpdateLikes(ctx context.Context, postId, userId int, postsLikes models.UpdateLikesRequest) (int64, error) {
 	slog.Info("Updating likes for post", slog.Int("postId", postId), slog.Int("userId", userId), slog.String("action", postsLikes.Action))
 
@@ -129,26 +536,28 @@ func (s *PostsService) UpdateLikes(ctx context.Context, postId, userId int, post
 	}
 
 	rowsAffected, err := s.PostsRepo.UpdateLikes(ctx, postId, userId, value)
+	if errors.Is(err, ErrPostNotFound) {
+		return 0, err
+	}
 	if err != nil {
 		slog.Error("Failed to update likes", slog.Int("postId", postId), slog.String("error", err.Error()))
 		return -1, fmt.Errorf("failed to update likes: %w", err)
 	}
 
+	board, err := s.PostsRepo.GetPostBoard(ctx, postId)
+	if err != nil {
+		slog.Warn("Failed to retrieve post board for the likes broadcast", slog.Int("postId", postId), slog.String("error", err.Error()))
+	}
+
 	// Broadcast updated likes message
-	wsMsg := models.WebSocketMessage{
+	s.broadcast(models.WebSocketMessage{
 		Type:    "updatedLikes",
 		Message: "Likes Updated",
 		Content: map[string]interface{}{
 			"postId": postId,
+			"board":  board,
 		},
-	}
-
-	marshalledWSMsg, err := json.Marshal(wsMsg)
-	if err != nil {
-		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()))
-		return rowsAffected, nil 
-	}
-	s.hub.Broadcast <- marshalledWSMsg
+	}, board)
 
 	return rowsAffected, nil
 }
@@ -0,0 +1,76 @@
+package user
+
+import (
+	"anon-confessions/cmd/internal/config"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// signupGuard tracks recent signups to scale the proof-of-work difficulty with the signup rate,
+// and remembers redeemed challenges so a solved challenge cannot create more than one account.
+type signupGuard struct {
+	cfg config.SignupConfig
+
+	mu       sync.Mutex
+	signups  []time.Time
+	redeemed map[string]time.Time
+}
+
+func newSignupGuard(cfg config.SignupConfig) *signupGuard {
+	return &signupGuard{cfg: cfg, redeemed: make(map[string]time.Time)}
+}
+
+// difficulty returns the number of leading zero bits a new challenge requires.
+// Up to the configured threshold the base difficulty applies. Beyond it, every doubling of the
+// signup rate adds one bit, which doubles the expected work per account.
+func (g *signupGuard) difficulty(now time.Time) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pruneSignups(now)
+
+	difficulty := g.cfg.Difficulty
+	if g.cfg.RateThreshold > 0 && len(g.signups) > g.cfg.RateThreshold {
+		difficulty += bits.Len(uint((len(g.signups) - 1) / g.cfg.RateThreshold))
+	}
+
+	return min(difficulty, max(g.cfg.MaxDifficulty, g.cfg.Difficulty))
+}
+
+// redeem marks the challenge nonce as used until it expires. It reports false if it was already used.
+func (g *signupGuard) redeem(nonce string, expiresAt, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for n, exp := range g.redeemed {
+		if now.After(exp) {
+			delete(g.redeemed, n)
+		}
+	}
+
+	if _, used := g.redeemed[nonce]; used {
+		return false
+	}
+	g.redeemed[nonce] = expiresAt
+
+	return true
+}
+
+// recordSignup counts a created account towards the signup rate.
+func (g *signupGuard) recordSignup(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pruneSignups(now)
+	g.signups = append(g.signups, now)
+}
+
+func (g *signupGuard) pruneSignups(now time.Time) {
+	cutoff := now.Add(-g.cfg.RateWindow)
+	i := 0
+	for i < len(g.signups) && !g.signups[i].After(cutoff) {
+		i++
+	}
+	g.signups = g.signups[i:]
+}
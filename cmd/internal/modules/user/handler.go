@@ -1,9 +1,20 @@
 package user
 
 import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/lockout"
 	"anon-confessions/cmd/internal/models"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,18 +30,437 @@ func NewUserHandler(userService *UserService) *UserHandler {
 func (h *UserHandler) handleUserAccountCreation(c *gin.Context) {
 	slog.Info("Starting user account creation")
 
-	accNumber, err := h.userService.createUser()
+	var queryParams models.CreateAccountQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for creating a user", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	if err := h.userService.redeemChallenge(queryParams.Challenge, queryParams.Solution); err != nil {
+		slog.Warn("Rejected user account creation", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, helper.ErrorMessage{Message: "Missing or invalid proof-of-work solution. Request a new challenge and try again."})
+		return
+	}
+
+	user, err := h.userService.createUser(c.Request.Context(), queryParams.RecoveryPhrase, queryParams.Invite)
+	if errors.Is(err, ErrInvalidInvite) {
+		slog.Warn("Rejected user account creation", slog.String("error", err.Error()))
+		c.JSON(http.StatusForbidden, helper.ErrorMessage{Message: "Missing or invalid invite code."})
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to create user account", slog.String("error", err.Error()))
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	slog.Info("User account created successfully", slog.String("accountNumber", accNumber))
+	slog.Info("User account created successfully")
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) handleSignupChallenge(c *gin.Context) {
+	challenge, err := h.userService.issueChallenge()
+	if err != nil {
+		slog.Error("Failed to issue signup challenge", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to issue challenge."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, challenge)
+}
+
+func (h *UserHandler) handleSessionCreation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req models.CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for creating a session", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	tokens, err := h.userService.createSession(ctx, req.AccountNumber, c.Request.UserAgent(), c.ClientIP())
+	var lockedErr *lockout.LockedError
+	if errors.As(err, &lockedErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, helper.ErrorMessage{Message: "Too many failed attempts. Please try again later."})
+		return
+	}
+	if errors.Is(err, ErrInvalidAccountNumber) {
+		c.JSON(http.StatusUnauthorized, helper.ErrorMessage{Message: "Invalid account number"})
+		return
+	}
+	if errors.Is(err, helper.ErrInvalidRecoveryPhrase) {
+		c.JSON(http.StatusUnauthorized, helper.ErrorMessage{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to create session", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Session creation failed."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, tokens)
+}
+
+func (h *UserHandler) handleSessionRefresh(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req models.RefreshSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for refreshing a session", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	tokens, err := h.userService.refreshSession(ctx, req.RefreshToken)
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, helper.ErrorMessage{Message: "Invalid or expired refresh token."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to refresh session", slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Session refresh failed."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *UserHandler) handleSessionsList(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	sessionId := helper.RetrieveSessionId(c)
+
+	sessions, err := h.userService.listSessions(ctx, userId, sessionId)
+	if err != nil {
+		slog.Error("Failed to list sessions", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve sessions."})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) handleSessionRevocation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	sessionId := helper.ParseIDParam(c, "id")
 
-	user := models.UserResponse{
-		AccountNumber: accNumber,
+	rowsAffected, err := h.userService.revokeSession(ctx, userId, sessionId)
+	if err != nil {
+		slog.Error("Failed to revoke session", slog.Int("sessionId", sessionId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to revoke session."})
+		return
 	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Session does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Session revoked successfully."})
+}
 
+func (h *UserHandler) handleAPIKeyCreation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for creating an API key", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	apiKey, err := h.userService.createAPIKey(ctx, userId, req)
+	if errors.Is(err, ErrInvalidAPIKeyExpiry) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Expiry must be in the future."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to create API key", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "API key creation failed."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+func (h *UserHandler) handleAPIKeysList(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	apiKeys, err := h.userService.listAPIKeys(ctx, userId)
+	if err != nil {
+		slog.Error("Failed to list API keys", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve API keys."})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+func (h *UserHandler) handleAPIKeyRevocation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	apiKeyId := helper.ParseIDParam(c, "id")
+
+	rowsAffected, err := h.userService.revokeAPIKey(ctx, userId, apiKeyId)
+	if err != nil {
+		slog.Error("Failed to revoke API key", slog.Int("apiKeyId", apiKeyId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to revoke API key."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "API key does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "API key revoked successfully."})
+}
+
+func (h *UserHandler) handleInviteCreation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for creating an invite", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	invite, err := h.userService.createInvite(ctx, userId, req)
+	if errors.Is(err, ErrInvalidInviteRequest) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Expiry must be in the future and uses must not exceed the allowed maximum."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to create invite", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Invite creation failed."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, invite)
+}
+
+func (h *UserHandler) handleInvitesList(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	invites, err := h.userService.listInvites(ctx, userId)
+	if err != nil {
+		slog.Error("Failed to list invites", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve invites."})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+func (h *UserHandler) handleInviteRevocation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	inviteId := helper.ParseIDParam(c, "id")
+
+	rowsAffected, err := h.userService.revokeInvite(ctx, userId, inviteId)
+	if err != nil {
+		slog.Error("Failed to revoke invite", slog.Int("inviteId", inviteId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to revoke invite."})
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "Invite does not exist."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Invite revoked successfully."})
+}
+
+func (h *UserHandler) handleInviteTrace(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.ParseIDParam(c, "id")
+
+	trace, err := h.userService.traceInvites(ctx, userId)
+	if errors.Is(err, ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "User does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to trace invites", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to trace invites."})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+func (h *UserHandler) handleProfile(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	profile, err := h.userService.getProfile(ctx, userId)
+	if errors.Is(err, ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, helper.ErrorMessage{Message: "User does not exist."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve profile", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve profile."})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *UserHandler) handlePreferencesRetrieval(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	preferences, err := h.userService.GetPreferences(ctx, userId)
+	if err != nil {
+		slog.Error("Failed to retrieve preferences", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to retrieve preferences."})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (h *UserHandler) handlePreferencesUpdate(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.UserPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for updating preferences", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	preferences, err := h.userService.updatePreferences(ctx, userId, req)
+	if err != nil {
+		slog.Error("Failed to update preferences", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Failed to update preferences."})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (h *UserHandler) handleAccountNumberRotation(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+	sessionId := helper.RetrieveSessionId(c)
+
+	var queryParams models.AccountNumberQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for rotating an account number", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	user, err := h.userService.rotateAccountNumber(ctx, userId, sessionId, queryParams.RecoveryPhrase)
+	if err != nil {
+		slog.Error("Failed to rotate account number", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Account number rotation failed."})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
 	c.JSON(http.StatusOK, user)
 }
+
+func (h *UserHandler) handleAccountDeletion(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.Warn("Invalid request body for deleting an account", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid request body. Please check your input."})
+		return
+	}
+
+	err := h.userService.deleteAccount(ctx, userId, req)
+	if errors.Is(err, helper.ErrInvalidRecoveryPhrase) {
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, ErrConfirmationMismatch) {
+		c.JSON(http.StatusForbidden, helper.ErrorMessage{Message: "Account number does not match the authenticated account."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to delete account", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Account deletion failed."})
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.SuccessMessage{Message: "Account deleted successfully."})
+}
+
+func (h *UserHandler) handleAccountExport(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := helper.RetrieveLoggedInUserId(c)
+
+	var queryParams models.ExportQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		slog.Warn("Invalid query parameters for exporting account data", slog.String("error", err.Error()))
+		c.JSON(http.StatusBadRequest, helper.ErrorMessage{Message: "Invalid query params. Please check your input."})
+		return
+	}
+
+	export, retryAfter, err := h.userService.exportAccount(ctx, userId)
+	if errors.Is(err, ErrExportRateLimited) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, helper.ErrorMessage{Message: "Too many export requests. Please try again later."})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to export account data", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Account data export failed."})
+		return
+	}
+
+	// The file is built in memory before anything is sent, so a failure can still be answered with an error status.
+	var body bytes.Buffer
+	contentType, extension := "application/json; charset=utf-8", "json"
+	if queryParams.Format == "zip" {
+		contentType, extension = "application/zip", "zip"
+		err = writeExportZip(&body, export)
+	} else {
+		err = json.NewEncoder(&body).Encode(export)
+	}
+	if err != nil {
+		slog.Error("Failed to encode account export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.ErrorMessage{Message: "Account data export failed."})
+		return
+	}
+
+	filename := fmt.Sprintf("anon-confessions-export-%s.%s", export.ExportedAt.Format("20060102-150405"), extension)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
+
+// writeExportZip writes a ZIP archive holding the export as export.json.
+func writeExportZip(w io.Writer, export *models.AccountExport) error {
+	zipWriter := zip.NewWriter(w)
+	entry, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "export.json", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(entry).Encode(export); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
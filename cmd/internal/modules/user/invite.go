@@ -0,0 +1,135 @@
+package user
+
+import (
+	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// createInvite generates an invite code. Regular users are capped at the configured number of uses per invite,
+// moderators and admins are not. The code itself is only returned here.
+func (s *UserService) createInvite(ctx context.Context, userId int, req models.CreateInviteRequest) (*models.CreateInviteResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidInviteRequest
+	}
+
+	role, err := s.userRepo.GetUserRole(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve role: %w", err)
+	}
+	if role == models.RoleUser && req.MaxUses > s.cfg.Signup.InviteMaxUses {
+		return nil, ErrInvalidInviteRequest
+	}
+
+	code, err := helper.GenerateInviteCode()
+	if err != nil {
+		slog.Error("Failed to generate invite code", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	invite := models.InviteDBModel{
+		CreatorId: &userId,
+		CodeHash:  helper.HashToken(helper.NormalizeInviteCode(code)),
+		MaxUses:   req.MaxUses,
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.userRepo.CreateInvite(ctx, &invite); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	slog.Info("Invite created", slog.Int("userId", userId), slog.Int("inviteId", invite.ID))
+	return &models.CreateInviteResponse{Invite: toInvite(invite), Code: code}, nil
+}
+
+// listInvites returns every invite a user created, including used up and revoked ones.
+func (s *UserService) listInvites(ctx context.Context, userId int) ([]models.Invite, error) {
+	invites, err := s.userRepo.GetInvitesByCreator(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve invites: %w", err)
+	}
+
+	result := make([]models.Invite, 0, len(invites))
+	for _, invite := range invites {
+		result = append(result, toInvite(invite))
+	}
+
+	return result, nil
+}
+
+func (s *UserService) revokeInvite(ctx context.Context, userId, inviteId int) (int64, error) {
+	rowsAffected, err := s.userRepo.RevokeInvite(ctx, inviteId, userId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to revoke invite: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("Invite revoked", slog.Int("userId", userId), slog.Int("inviteId", inviteId))
+	}
+	return rowsAffected, nil
+}
+
+// traceInvites returns the invite chain that led to an account and the tree of accounts created with its invites.
+// Accounts are identified by their internal IDs only.
+func (s *UserService) traceInvites(ctx context.Context, userId int) (*models.InviteTrace, error) {
+	ancestors, err := s.userRepo.GetInviteAncestors(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve invite ancestors: %w", err)
+	}
+	if len(ancestors) == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	descendants, err := s.userRepo.GetInviteDescendants(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve invite descendants: %w", err)
+	}
+
+	invitedBy := make([]models.InvitedAccount, 0, len(ancestors)-1)
+	for _, edge := range ancestors[:len(ancestors)-1] {
+		invitedBy = append(invitedBy, toInvitedAccount(edge))
+	}
+
+	// Descendants come in creation order, which keeps the invited accounts of every node in that order.
+	children := make(map[int][]models.InviteEdge)
+	for _, edge := range descendants {
+		children[*edge.CreatorId] = append(children[*edge.CreatorId], edge)
+	}
+
+	var build func(edge models.InviteEdge) models.InvitedAccount
+	build = func(edge models.InviteEdge) models.InvitedAccount {
+		account := toInvitedAccount(edge)
+		for _, child := range children[edge.UserId] {
+			account.Invited = append(account.Invited, build(child))
+		}
+		return account
+	}
+
+	return &models.InviteTrace{
+		InvitedBy: invitedBy,
+		Account:   build(ancestors[len(ancestors)-1]),
+	}, nil
+}
+
+func toInvite(invite models.InviteDBModel) models.Invite {
+	return models.Invite{
+		ID:        invite.ID,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		Revoked:   invite.RevokedAt != nil,
+	}
+}
+
+func toInvitedAccount(edge models.InviteEdge) models.InvitedAccount {
+	return models.InvitedAccount{
+		UserID:    edge.UserId,
+		InviteID:  edge.InviteId,
+		CreatedAt: edge.CreatedAt,
+		Invited:   []models.InvitedAccount{},
+	}
+}
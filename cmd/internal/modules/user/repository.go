@@ -1,14 +1,46 @@
 package user
 
 import (
+	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/models"
+	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	CreateUser(models.Users) error
+	CreateInvitedUser(context.Context, models.Users, string) (int64, error)
+	CreateInvite(context.Context, *models.InviteDBModel) error
+	GetInvitesByCreator(context.Context, int) ([]models.InviteDBModel, error)
+	RevokeInvite(context.Context, int, int) (int64, error)
+	GetInviteAncestors(context.Context, int) ([]models.InviteEdge, error)
+	GetInviteDescendants(context.Context, int) ([]models.InviteEdge, error)
+	GetUserByLookupHash(context.Context, string) (*models.Users, error)
+	GetUsersWithoutLookupHash(context.Context) ([]models.Users, error)
+	UpdateLookupHash(context.Context, int, string) error
+	UpdateAccountNumberHash(context.Context, int, string, string) error
+	GetUserRole(context.Context, int) (string, error)
+	CreateSession(context.Context, *models.SessionDBModel) error
+	GetSession(context.Context, int) (*models.SessionDBModel, error)
+	GetSessionByRefreshTokenHash(context.Context, string) (*models.SessionDBModel, error)
+	GetActiveSessions(context.Context, int) ([]models.SessionDBModel, error)
+	RotateRefreshToken(context.Context, int, string, string, time.Time) (int64, error)
+	RevokeSession(context.Context, int, int) (int64, error)
+	CreateAPIKey(context.Context, *models.APIKeyDBModel) error
+	GetAPIKeyByHash(context.Context, string) (*models.APIKeyDBModel, error)
+	GetActiveAPIKeys(context.Context, int) ([]models.APIKeyDBModel, error)
+	RevokeAPIKey(context.Context, int, int) (int64, error)
+	RotateAccountNumber(context.Context, int, string, string, int) error
+	DeleteUser(context.Context, int, string) (*models.DeletedAccountContent, error)
+	GetAccountExport(context.Context, int) (*models.AccountExport, error)
+	GetUserProfile(context.Context, int) (*models.UserProfile, error)
+	GetPreferences(context.Context, int) (*models.UserPreferencesDBModel, error)
+	SavePreferences(context.Context, *models.UserPreferencesDBModel) error
 }
 
 type SQLiteUserRepository struct {
@@ -20,13 +52,637 @@ func NewSQLiteUserRepository(db *gorm.DB) *SQLiteUserRepository {
 }
 
 func (repo *SQLiteUserRepository) CreateUser(user models.Users) error {
-	slog.Info("Creating a new user", slog.String("accountNumber", user.AccountNumber))
+	slog.Info("Creating a new user")
 
 	if err := repo.db.Create(&user).Error; err != nil {
-		slog.Error("Failed to create user", slog.String("error", err.Error()), slog.String("accountNumber", user.AccountNumber))
+		slog.Error("Failed to create user", slog.String("error", err.Error()))
+		return err
+	}
+
+	slog.Info("User created successfully")
+	return nil
+}
+
+// CreateInvitedUser redeems an invite code and creates the user with it in one transaction.
+// The invite is only redeemed while it is neither used up, expired nor revoked; otherwise no user is
+// created and 0 is returned.
+func (repo *SQLiteUserRepository) CreateInvitedUser(ctx context.Context, user models.Users, codeHash string) (int64, error) {
+	var rowsAffected int64
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invite models.InviteDBModel
+		err := tx.Where("code_hash = ? AND uses < max_uses AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", codeHash, time.Now()).
+			First(&invite).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Uses is part of the condition so two signups racing for the last use cannot both get it.
+		result := tx.Model(&models.InviteDBModel{}).
+			Where("id = ? AND uses = ?", invite.ID, invite.Uses).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		user.InviteId = &invite.ID
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		rowsAffected = result.RowsAffected
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Transaction failed for creating an invited user", slog.String("error", err.Error()))
+		return -1, err
+	}
+
+	return rowsAffected, nil
+}
+
+// GetUserByLookupHash finds the user whose account number fingerprint matches lookupHash.
+// It returns nil without an error when no user matches.
+func (repo *SQLiteUserRepository) GetUserByLookupHash(ctx context.Context, lookupHash string) (*models.Users, error) {
+	var user models.Users
+	err := repo.db.WithContext(ctx).Where("lookup_hash = ?", lookupHash).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve user by lookup hash", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUsersWithoutLookupHash returns the users created before lookup fingerprints existed.
+// The set shrinks as those users log in and their fingerprint is filled in.
+func (repo *SQLiteUserRepository) GetUsersWithoutLookupHash(ctx context.Context) ([]models.Users, error) {
+	var users []models.Users
+	if err := repo.db.WithContext(ctx).Where("lookup_hash IS NULL").Find(&users).Error; err != nil {
+		slog.Error("Failed to retrieve users without lookup hash", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (repo *SQLiteUserRepository) UpdateLookupHash(ctx context.Context, userId int, lookupHash string) error {
+	result := repo.db.WithContext(ctx).Model(&models.Users{}).Where("id = ?", userId).Update("lookup_hash", lookupHash)
+	if result.Error != nil {
+		slog.Error("Failed to update lookup hash", slog.Int("userId", userId), slog.String("error", result.Error.Error()))
+		return result.Error
+	}
+
+	return nil
+}
+
+// UpdateAccountNumberHash replaces the account number hash of a user, provided it still equals currentHash.
+// The condition keeps a rehash from overwriting an account number that was rotated in the meantime.
+func (repo *SQLiteUserRepository) UpdateAccountNumberHash(ctx context.Context, userId int, currentHash, newHash string) error {
+	result := repo.db.WithContext(ctx).Model(&models.Users{}).
+		Where("id = ? AND account_number = ?", userId, currentHash).
+		Update("account_number", newHash)
+	if result.Error != nil {
+		slog.Error("Failed to update account number hash", slog.Int("userId", userId), slog.String("error", result.Error.Error()))
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetUserRole retrieves the role of a user. It returns an empty role without an error when the user does not exist.
+func (repo *SQLiteUserRepository) GetUserRole(ctx context.Context, userId int) (string, error) {
+	var user models.Users
+	err := repo.db.WithContext(ctx).Select("role").First(&user, userId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve user role", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return "", err
+	}
+
+	return user.Role, nil
+}
+
+// CreateSession inserts a new session. The generated ID is written back to the given model.
+func (repo *SQLiteUserRepository) CreateSession(ctx context.Context, session *models.SessionDBModel) error {
+	if err := repo.db.WithContext(ctx).Create(session).Error; err != nil {
+		slog.Error("Failed to create session", slog.Int("userId", session.UserId), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// GetSession retrieves a session by its ID. It returns nil without an error when the session does not exist.
+func (repo *SQLiteUserRepository) GetSession(ctx context.Context, id int) (*models.SessionDBModel, error) {
+	var session models.SessionDBModel
+	err := repo.db.WithContext(ctx).First(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve session", slog.Int("sessionId", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetSessionByRefreshTokenHash retrieves the session owning a refresh token.
+// It returns nil without an error when no session matches.
+func (repo *SQLiteUserRepository) GetSessionByRefreshTokenHash(ctx context.Context, refreshTokenHash string) (*models.SessionDBModel, error) {
+	var session models.SessionDBModel
+	err := repo.db.WithContext(ctx).Where("refresh_token_hash = ?", refreshTokenHash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve session by refresh token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetActiveSessions returns the sessions of a user that are neither revoked nor expired, newest first.
+func (repo *SQLiteUserRepository) GetActiveSessions(ctx context.Context, userId int) ([]models.SessionDBModel, error) {
+	var sessions []models.SessionDBModel
+	err := repo.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("created_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		slog.Error("Failed to retrieve sessions", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RotateRefreshToken replaces the refresh token of a session and extends its expiry.
+// The current hash is part of the condition so a refresh token can only ever be exchanged once,
+// even when two refresh requests race each other.
+func (repo *SQLiteUserRepository) RotateRefreshToken(ctx context.Context, id int, currentHash, newHash string, expiresAt time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.SessionDBModel{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, currentHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"expires_at":         expiresAt,
+			"last_used_at":       time.Now(),
+		})
+	if result.Error != nil {
+		slog.Error("Failed to rotate refresh token", slog.Int("sessionId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// RevokeSession marks a session of the given user as revoked.
+func (repo *SQLiteUserRepository) RevokeSession(ctx context.Context, id, userId int) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.SessionDBModel{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		slog.Error("Failed to revoke session", slog.Int("sessionId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CreateAPIKey inserts a new API key. The generated ID is written back to the given model.
+func (repo *SQLiteUserRepository) CreateAPIKey(ctx context.Context, apiKey *models.APIKeyDBModel) error {
+	if err := repo.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		slog.Error("Failed to create API key", slog.Int("userId", apiKey.UserId), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash retrieves the API key matching a key hash. It returns nil without an error when no key matches.
+func (repo *SQLiteUserRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKeyDBModel, error) {
+	var apiKey models.APIKeyDBModel
+	err := repo.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve API key", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+// GetActiveAPIKeys returns the API keys of a user that are neither revoked nor expired, newest first.
+func (repo *SQLiteUserRepository) GetActiveAPIKeys(ctx context.Context, userId int) ([]models.APIKeyDBModel, error) {
+	var apiKeys []models.APIKeyDBModel
+	err := repo.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userId, time.Now()).
+		Order("created_at desc").
+		Find(&apiKeys).Error
+	if err != nil {
+		slog.Error("Failed to retrieve API keys", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeAPIKey marks an API key of the given user as revoked.
+func (repo *SQLiteUserRepository) RevokeAPIKey(ctx context.Context, id, userId int) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.APIKeyDBModel{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		slog.Error("Failed to revoke API key", slog.Int("apiKeyId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CreateInvite inserts a new invite. The generated ID is written back to the given model.
+func (repo *SQLiteUserRepository) CreateInvite(ctx context.Context, invite *models.InviteDBModel) error {
+	if err := repo.db.WithContext(ctx).Create(invite).Error; err != nil {
+		slog.Error("Failed to create invite", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// GetInvitesByCreator returns every invite a user created, newest first.
+func (repo *SQLiteUserRepository) GetInvitesByCreator(ctx context.Context, userId int) ([]models.InviteDBModel, error) {
+	var invites []models.InviteDBModel
+	err := repo.db.WithContext(ctx).
+		Where("creator_id = ?", userId).
+		Order("created_at desc").
+		Find(&invites).Error
+	if err != nil {
+		slog.Error("Failed to retrieve invites", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+// RevokeInvite marks an invite of the given user as revoked. Accounts already created with it are kept.
+func (repo *SQLiteUserRepository) RevokeInvite(ctx context.Context, id, userId int) (int64, error) {
+	result := repo.db.WithContext(ctx).Model(&models.InviteDBModel{}).
+		Where("id = ? AND creator_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		slog.Error("Failed to revoke invite", slog.Int("inviteId", id), slog.String("error", result.Error.Error()))
+		return -1, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetInviteAncestors follows the invites from a user up to the account that started the chain.
+// The result starts at that root and ends with the user itself. It is empty when the user does not exist.
+func (repo *SQLiteUserRepository) GetInviteAncestors(ctx context.Context, userId int) ([]models.InviteEdge, error) {
+	var edges []models.InviteEdge
+	err := repo.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors(id, depth) AS (
+			SELECT ?, 0
+			UNION
+			SELECT invites.creator_id, ancestors.depth + 1
+			FROM ancestors
+			JOIN users ON users.id = ancestors.id
+			JOIN invites ON invites.id = users.invite_id
+			WHERE invites.creator_id IS NOT NULL
+		)
+		SELECT users.id AS user_id, users.invite_id, invites.creator_id, users.created_at
+		FROM ancestors
+		JOIN users ON users.id = ancestors.id
+		LEFT JOIN invites ON invites.id = users.invite_id
+		ORDER BY ancestors.depth DESC
+	`, userId).Scan(&edges).Error
+	if err != nil {
+		slog.Error("Failed to retrieve invite ancestors", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// GetInviteDescendants returns every account created, directly or indirectly, with the invites of a user,
+// in the order they were created. The user itself is not included.
+func (repo *SQLiteUserRepository) GetInviteDescendants(ctx context.Context, userId int) ([]models.InviteEdge, error) {
+	var edges []models.InviteEdge
+	err := repo.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE descendants(id) AS (
+			SELECT ?
+			UNION
+			SELECT users.id
+			FROM descendants
+			JOIN invites ON invites.creator_id = descendants.id
+			JOIN users ON users.invite_id = invites.id
+		)
+		SELECT users.id AS user_id, users.invite_id, invites.creator_id, users.created_at
+		FROM descendants
+		JOIN users ON users.id = descendants.id
+		LEFT JOIN invites ON invites.id = users.invite_id
+		WHERE users.id != ?
+		ORDER BY users.created_at, users.id
+	`, userId, userId).Scan(&edges).Error
+	if err != nil {
+		slog.Error("Failed to retrieve invite descendants", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// RotateAccountNumber replaces the account number hash and lookup fingerprint of a user.
+// In the same transaction every session of the user except keepSessionId is revoked,
+// so nothing obtained with the old account number outlives it. Pass 0 to revoke all sessions.
+func (repo *SQLiteUserRepository) RotateAccountNumber(ctx context.Context, userId int, accountNumberHash, lookupHash string, keepSessionId int) error {
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Users{}).Where("id = ?", userId).Updates(map[string]interface{}{
+			"account_number": accountNumberHash,
+			"lookup_hash":    lookupHash,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Model(&models.SessionDBModel{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, keepSessionId).
+			Update("revoked_at", time.Now()).Error
+	})
+
+	if err != nil {
+		slog.Error("Transaction failed for rotating account number", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser removes a user in a single transaction.
+// In delete mode the posts, comments and likes of the user are removed by the ON DELETE CASCADE foreign keys.
+// In anonymize mode the posts and comments are first handed over to the tombstone user so threads stay readable.
+// In both modes the likes given by the user are taken back from the like counters before they disappear.
+// Drafts are never handed over; they are removed by the cascade in both modes.
+func (repo *SQLiteUserRepository) DeleteUser(ctx context.Context, userId int, mode string) (*models.DeletedAccountContent, error) {
+	var content models.DeletedAccountContent
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PostsLikesDBModel{}).
+			Select("posts_likes.post_id AS id, boards.slug AS board").
+			Joins("JOIN posts ON posts.id = posts_likes.post_id").
+			Joins("JOIN boards ON boards.id = posts.board_id").
+			Where("posts_likes.user_id = ?", userId).
+			Scan(&content.LikedPosts).Error
+		if err != nil {
+			return err
+		}
+
+		if len(content.LikedPosts) > 0 {
+			if err := tx.Model(&models.PostDBModel{}).
+				Where("id IN ?", helper.ContentIDs(content.LikedPosts)).
+				Update("total_likes", gorm.Expr("MAX(total_likes - 1, 0)")).Error; err != nil {
+				return err
+			}
+		}
+
+		switch mode {
+		case models.AccountDeletionAnonymize:
+			tombstone, err := getOrCreateTombstoneUser(tx)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.PostDBModel{}).Where("user_id = ?", userId).Update("user_id", tombstone.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.CommentsDbModel{}).Where("user_id = ?", userId).Update("user_id", tombstone.ID).Error; err != nil {
+				return err
+			}
+		default:
+			err := tx.Model(&models.PostDBModel{}).
+				Select("posts.id, boards.slug AS board").
+				Joins("JOIN boards ON boards.id = posts.board_id").
+				Where("posts.user_id = ?", userId).
+				Scan(&content.Posts).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&models.CommentsDbModel{}).
+				Select("comments.id, boards.slug AS board").
+				Joins("JOIN posts ON posts.id = comments.post_id").
+				Joins("JOIN boards ON boards.id = posts.board_id").
+				Where("comments.user_id = ?", userId).
+				Scan(&content.Comments).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Delete(&models.Users{}, userId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Transaction failed for deleting user", slog.Int("userId", userId), slog.String("mode", mode), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// getOrCreateTombstoneUser returns the user that owns the content of anonymized accounts, creating it on first use.
+// Its account number is not a valid hash, so nobody can ever log in as the tombstone user.
+func getOrCreateTombstoneUser(tx *gorm.DB) (*models.Users, error) {
+	lookupHash := models.TombstoneLookupHash
+	tombstone := models.Users{AccountNumber: "!", LookupHash: &lookupHash, CreatedAt: time.Now()}
+
+	if err := tx.Where("lookup_hash = ?", lookupHash).FirstOrCreate(&tombstone).Error; err != nil {
+		return nil, err
+	}
+
+	return &tombstone, nil
+}
+
+// GetAccountExport collects every post, comment, like and draft tied to a user.
+// Only explicitly selected columns are read, so user IDs of other commenters never leave the database.
+// Deleted posts and comments and expired drafts are still stored until they are purged, so they are exported as well.
+func (repo *SQLiteUserRepository) GetAccountExport(ctx context.Context, userId int) (*models.AccountExport, error) {
+	db := repo.db.WithContext(ctx)
+	export := models.AccountExport{
+		Posts:    []models.ExportPost{},
+		Comments: []models.ExportComment{},
+		Likes:    []models.ExportLike{},
+		Drafts:   []models.ExportDraft{},
+	}
+
+	var user models.Users
+	if err := db.First(&user, userId).Error; err != nil {
+		slog.Error("Failed to retrieve user for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+	export.Account.CreatedAt = user.CreatedAt
+
+	err := db.Model(&models.PostDBModel{}).
+		Select("id, content, created_at, total_likes").
+		Where("user_id = ?", userId).
+		Order("created_at asc").
+		Scan(&export.Posts).Error
+	if err != nil {
+		slog.Error("Failed to retrieve posts for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	var postComments []models.ExportPostComment
+	err = db.Model(&models.CommentsDbModel{}).
+		Select("comments.id, comments.post_id, comments.content, comments.created_at, comments.user_id = ? AS own", userId).
+		Joins("JOIN posts ON posts.id = comments.post_id").
+		Where("posts.user_id = ?", userId).
+		Order("comments.created_at asc").
+		Scan(&postComments).Error
+	if err != nil {
+		slog.Error("Failed to retrieve comments on posts for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	commentsByPost := make(map[int][]models.ExportPostComment)
+	for _, comment := range postComments {
+		commentsByPost[comment.PostID] = append(commentsByPost[comment.PostID], comment)
+	}
+	for i := range export.Posts {
+		export.Posts[i].Comments = commentsByPost[export.Posts[i].ID]
+		if export.Posts[i].Comments == nil {
+			export.Posts[i].Comments = []models.ExportPostComment{}
+		}
+	}
+
+	err = db.Model(&models.CommentsDbModel{}).
+		Select("id, post_id, content, created_at").
+		Where("user_id = ?", userId).
+		Order("created_at asc").
+		Scan(&export.Comments).Error
+	if err != nil {
+		slog.Error("Failed to retrieve comments for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = db.Model(&models.PostsLikesDBModel{}).
+		Select("post_id, created_at AS liked_at").
+		Where("user_id = ?", userId).
+		Order("created_at asc").
+		Scan(&export.Likes).Error
+	if err != nil {
+		slog.Error("Failed to retrieve likes for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = db.Model(&models.DraftDBModel{}).
+		Select("id, content, board, created_at, updated_at").
+		Where("user_id = ?", userId).
+		Order("created_at asc").
+		Scan(&export.Drafts).Error
+	if err != nil {
+		slog.Error("Failed to retrieve drafts for export", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// GetUserProfile retrieves the creation date and role of a user along with its activity counters.
+// It returns nil without an error when the user does not exist.
+func (repo *SQLiteUserRepository) GetUserProfile(ctx context.Context, userId int) (*models.UserProfile, error) {
+	var row struct {
+		CreatedAt     time.Time
+		Role          string
+		Posts         int64
+		Comments      int64
+		LikesGiven    int64
+		LikesReceived int64
+	}
+
+	result := repo.db.WithContext(ctx).Raw(`
+		SELECT
+			users.created_at,
+			users.role,
+			(SELECT COUNT(*) FROM posts WHERE posts.user_id = users.id AND posts.deleted_at IS NULL) AS posts,
+			(SELECT COUNT(*) FROM comments WHERE comments.user_id = users.id AND comments.deleted_at IS NULL) AS comments,
+			(SELECT COUNT(*) FROM posts_likes WHERE posts_likes.user_id = users.id) AS likes_given,
+			(SELECT COUNT(*) FROM posts_likes
+				JOIN posts ON posts.id = posts_likes.post_id
+				WHERE posts.user_id = users.id AND posts.deleted_at IS NULL AND posts_likes.user_id != users.id) AS likes_received
+		FROM users
+		WHERE users.id = ?
+	`, userId).Scan(&row)
+	if result.Error != nil {
+		slog.Error("Failed to retrieve user profile", slog.Int("userId", userId), slog.String("error", result.Error.Error()))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &models.UserProfile{
+		CreatedAt: row.CreatedAt,
+		Role:      row.Role,
+		Stats: models.UserStats{
+			Posts:         row.Posts,
+			Comments:      row.Comments,
+			LikesGiven:    row.LikesGiven,
+			LikesReceived: row.LikesReceived,
+		},
+	}, nil
+}
+
+// GetPreferences retrieves the stored preferences of a user. It returns nil without an error when none were stored yet.
+func (repo *SQLiteUserRepository) GetPreferences(ctx context.Context, userId int) (*models.UserPreferencesDBModel, error) {
+	var preferences models.UserPreferencesDBModel
+	err := repo.db.WithContext(ctx).Where("user_id = ?", userId).First(&preferences).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Failed to retrieve user preferences", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &preferences, nil
+}
+
+// SavePreferences inserts the preferences of a user or replaces the ones already stored.
+func (repo *SQLiteUserRepository) SavePreferences(ctx context.Context, preferences *models.UserPreferencesDBModel) error {
+	err := repo.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(preferences).Error
+	if err != nil {
+		slog.Error("Failed to save user preferences", slog.Int("userId", preferences.UserId), slog.String("error", err.Error()))
 		return err
 	}
 
-	slog.Info("User created successfully", slog.String("accountNumber", user.AccountNumber))
 	return nil
 }
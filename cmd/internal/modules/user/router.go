@@ -4,19 +4,318 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterUserRoutes registers all routes related to posts.
+// RegisterUserRoutes registers all routes related to users that do not require authentication.
 func RegisterUsersRoutes(router *gin.RouterGroup, userHandler *UserHandler) {
 	userGroup := router.Group("/users")
 	{
+		userGroup.GET("/challenge", userHandler.handleSignupChallenge)
 		userGroup.POST("/register", userHandler.handleUserAccountCreation)
+		userGroup.POST("/sessions", userHandler.handleSessionCreation)
+		userGroup.POST("/sessions/refresh", userHandler.handleSessionRefresh)
 	}
 }
 
+// RegisterAuthenticatedUsersRoutes registers all routes related to users that require authentication.
+// These routes manage the account itself, so the router group is expected to turn away API keys.
+func RegisterAuthenticatedUsersRoutes(router *gin.RouterGroup, userHandler *UserHandler) {
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/sessions", userHandler.handleSessionsList)
+		userGroup.DELETE("/sessions/:id", userHandler.handleSessionRevocation)
+		userGroup.POST("/me/api-keys", userHandler.handleAPIKeyCreation)
+		userGroup.GET("/me/api-keys", userHandler.handleAPIKeysList)
+		userGroup.DELETE("/me/api-keys/:id", userHandler.handleAPIKeyRevocation)
+		userGroup.POST("/me/invites", userHandler.handleInviteCreation)
+		userGroup.GET("/me/invites", userHandler.handleInvitesList)
+		userGroup.DELETE("/me/invites/:id", userHandler.handleInviteRevocation)
+		userGroup.GET("/me", userHandler.handleProfile)
+		userGroup.GET("/me/preferences", userHandler.handlePreferencesRetrieval)
+		userGroup.PUT("/me/preferences", userHandler.handlePreferencesUpdate)
+		userGroup.POST("/me/rotate", userHandler.handleAccountNumberRotation)
+		userGroup.DELETE("/me", userHandler.handleAccountDeletion)
+		userGroup.GET("/me/export", userHandler.handleAccountExport)
+	}
+}
+
+// RegisterAdminUsersRoutes registers the moderation routes for users.
+// The router group is expected to only let moderators through.
+func RegisterAdminUsersRoutes(router *gin.RouterGroup, userHandler *UserHandler) {
+	userGroup := router.Group("/users")
+	{
+		userGroup.GET("/:id/invites", userHandler.handleInviteTrace)
+	}
+}
+
+// @Summary Request a signup challenge
+// @Description Issue a signed proof-of-work challenge that has to be solved before creating an account.
+// @Description A solution is any string of up to 64 characters for which SHA-256("<challenge>:<solution>") starts with the given number of zero bits.
+// @Description The difficulty rises automatically while many accounts are being created.
+// @Description inviteRequired is set while the server runs in invite-only mode.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.SignupChallengeResponse
+// @Router /users/challenge [get]
+func signupChallenge(c *gin.Context) {}
+
 // @Summary Create a new user account
 // @Description Generate a new 16-digit anonymous account number and return it.
+// @Description A solved challenge from GET /users/challenge is required, and each challenge can only be used once.
+// @Description In invite-only mode an invite code is required as well. The account records which invite created it.
+// @Description With recovery_phrase=true the number is also returned as an eight-word recovery phrase, which can be used anywhere the account number is accepted.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param challenge query string true "Challenge from GET /users/challenge"
+// @Param solution query string true "Proof-of-work solution for the challenge"
+// @Param invite query string false "Invite code, required in invite-only mode"
+// @Param recovery_phrase query bool false "Also return the account number as a recovery phrase"
 // @Success 200 {object} models.UserResponse
+// @Failure 403 {object} helper.ErrorMessage "Missing or invalid proof-of-work solution or invite code"
 // @Router /users/register [post]
 func createUser(c *gin.Context) {}
+
+// @Summary Create a session
+// @Description Exchanges an account number, or its recovery phrase, for a short-lived access token and a refresh token. Send the access token as `Authorization: Bearer <token>`.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.CreateSessionRequest true "Account number"
+// @Success 201 {object} models.SessionTokensResponse "Session created successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Invalid account number or recovery phrase"
+// @Failure 429 {object} helper.ErrorMessage "Too many failed attempts, retry after the Retry-After header"
+// @Failure 500 {object} helper.ErrorMessage "Internal server error"
+// @Router /users/sessions [post]
+func createSession(c *gin.Context) {}
+
+// @Summary Refresh a session
+// @Description Exchanges a refresh token for a new access token. The refresh token is rotated and can only be used once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.RefreshSessionRequest true "Refresh token"
+// @Success 200 {object} models.SessionTokensResponse "Session refreshed successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Invalid or expired refresh token"
+// @Failure 500 {object} helper.ErrorMessage "Internal server error"
+// @Router /users/sessions/refresh [post]
+func refreshSession(c *gin.Context) {}
+
+// @Summary List sessions
+// @Description Lists the active sessions of the authenticated user. The session used for the request is flagged as current.
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.Session "Sessions retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve sessions"
+// @Router /users/sessions [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func listSessions(c *gin.Context) {}
+
+// @Summary Revoke a session
+// @Description Revokes a session of the authenticated user. Its access and refresh tokens stop working immediately.
+// @Tags users
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} helper.SuccessMessage "Session revoked successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Session does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to revoke session"
+// @Router /users/sessions/{id} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+func revokeSession(c *gin.Context) {}
+
+// @Summary Create an API key
+// @Description Mints a named API key for bots and integrations. Send it as `X-API-Key: <key>`.
+// @Description Requests made with the key can only reach the routes covered by its scopes: `posts:read`, `posts:write`, `comments:read`, `comments:write`, `likes:write` and `moderate`. API keys can never manage the account itself.
+// @Description Keys without an expiry stay valid until they are revoked. The key is returned only once; store it safely.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.CreateAPIKeyRequest true "Name, scopes and optional expiry"
+// @Success 201 {object} models.CreateAPIKeyResponse "API key created successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "API key creation failed"
+// @Router /users/me/api-keys [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+func createAPIKey(c *gin.Context) {}
+
+// @Summary List API keys
+// @Description Lists the API keys of the authenticated user that are neither revoked nor expired.
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.APIKey "API keys retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve API keys"
+// @Router /users/me/api-keys [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func listAPIKeys(c *gin.Context) {}
+
+// @Summary Revoke an API key
+// @Description Revokes an API key of the authenticated user. It stops working immediately.
+// @Tags users
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} helper.SuccessMessage "API key revoked successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "API key does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to revoke API key"
+// @Router /users/me/api-keys/{id} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+func revokeAPIKey(c *gin.Context) {}
+
+// @Summary Create an invite
+// @Description Generates an invite code that can create up to maxUses accounts. Regular users are capped by INVITE_MAX_USES, moderators and admins are not.
+// @Description Codes without an expiry stay valid until they are used up or revoked. The code is returned only once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.CreateInviteRequest true "Usage limit and optional expiry"
+// @Success 201 {object} models.CreateInviteResponse "Invite created successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body, expiry or usage limit"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Invite creation failed"
+// @Router /users/me/invites [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+func createInvite(c *gin.Context) {}
+
+// @Summary List invites
+// @Description Lists every invite created by the authenticated user, including used up and revoked ones.
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.Invite "Invites retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve invites"
+// @Router /users/me/invites [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func listInvites(c *gin.Context) {}
+
+// @Summary Revoke an invite
+// @Description Revokes an invite of the authenticated user. Accounts already created with it are kept.
+// @Tags users
+// @Produce json
+// @Param id path int true "Invite ID"
+// @Success 200 {object} helper.SuccessMessage "Invite revoked successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "Invite does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to revoke invite"
+// @Router /users/me/invites/{id} [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+func revokeInvite(c *gin.Context) {}
+
+// @Summary Trace the invites of a user
+// @Description Shows the chain of accounts whose invites led to a user, and the tree of accounts created with its invites. Only available to moderators and admins.
+// @Description Accounts are identified by their internal IDs only, never by account number.
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.InviteTrace "Invites traced successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Insufficient permissions"
+// @Failure 404 {object} helper.ErrorMessage "User does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to trace invites"
+// @Router /admin/users/{id}/invites [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func traceInvites(c *gin.Context) {}
+
+// @Summary Get the current user
+// @Description Returns the profile of the authenticated user: account age, role, trust level and activity counters.
+// @Description Trust levels are `new` for accounts younger than three days, `trusted` for accounts of at least thirty days whose posts were liked at least ten times by others, and `member` otherwise.
+// @Description The account number and its hash are never included.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.UserProfile "Profile retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 404 {object} helper.ErrorMessage "User does not exist"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve profile"
+// @Router /users/me [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func getProfile(c *gin.Context) {}
+
+// @Summary Get preferences
+// @Description Returns the preferences of the authenticated user. Users who never stored any get the defaults.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.UserPreferences "Preferences retrieved successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to retrieve preferences"
+// @Router /users/me/preferences [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func getPreferences(c *gin.Context) {}
+
+// @Summary Update preferences
+// @Description Replaces the preferences of the authenticated user.
+// @Description `sortBy`, `sortOrder` and `pageSize` become the defaults of GET /posts when its query string leaves them out. Muted words and NSFW visibility are stored for clients to apply.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.UserPreferences true "Preferences"
+// @Success 200 {object} models.UserPreferences "Preferences updated successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Failed to update preferences"
+// @Router /users/me/preferences [put]
+// @security BearerAuth
+// @security AccountNumberAuth
+func updatePreferences(c *gin.Context) {}
+
+// @Summary Rotate the account number
+// @Description Replaces the account number of the authenticated user with a new one. The old number stops working immediately and every other session is revoked. The new number is returned only once; store it safely.
+// @Tags users
+// @Produce json
+// @Param recovery_phrase query bool false "Also return the new account number as a recovery phrase"
+// @Success 200 {object} models.UserResponse "Account number rotated successfully"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 500 {object} helper.ErrorMessage "Account number rotation failed"
+// @Router /users/me/rotate [post]
+// @security BearerAuth
+// @security AccountNumberAuth
+func rotateAccountNumber(c *gin.Context) {}
+
+// @Summary Delete the account
+// @Description Deletes the account of the authenticated user. The account number has to be re-sent as confirmation.
+// @Description Mode `delete` removes every post, comment and like of the account. Mode `anonymize` keeps posts and comments but hands them over to an anonymous tombstone account.
+// @Description Connected WebSocket clients receive a `contentRemoved` event listing the removed posts and comments.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.DeleteAccountRequest true "Confirmation and deletion mode"
+// @Success 200 {object} helper.SuccessMessage "Account deleted successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid request body"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 403 {object} helper.ErrorMessage "Account number does not match"
+// @Failure 500 {object} helper.ErrorMessage "Account deletion failed"
+// @Router /users/me [delete]
+// @security BearerAuth
+// @security AccountNumberAuth
+func deleteAccount(c *gin.Context) {}
+
+// @Summary Export account data
+// @Description Downloads every post, comment, like and draft of the authenticated user, with timestamps, as a JSON document or a ZIP archive containing it.
+// @Description Comments written by other users on the exported posts are included without any identifier of their authors.
+// @Description Exports are rate-limited per user; when the limit is hit the Retry-After header tells when to try again.
+// @Tags users
+// @Produce json
+// @Produce application/zip
+// @Param format query string false "Archive format (default: json)" Enums(json,zip)
+// @Success 200 {object} models.AccountExport "Account data exported successfully"
+// @Failure 400 {object} helper.ErrorMessage "Invalid query params"
+// @Failure 401 {object} helper.ErrorMessage "Missing or invalid credentials"
+// @Failure 429 {object} helper.ErrorMessage "Too many export requests"
+// @Failure 500 {object} helper.ErrorMessage "Account data export failed"
+// @Router /users/me/export [get]
+// @security BearerAuth
+// @security AccountNumberAuth
+func exportAccount(c *gin.Context) {}
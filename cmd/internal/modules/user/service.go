@@ -1,41 +1,783 @@
 package user
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper"
+	"anon-confessions/cmd/internal/lockout"
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	// ErrInvalidAccountNumber is returned when an account number does not belong to any user.
+	ErrInvalidAccountNumber = errors.New("invalid account number")
+	// ErrInvalidAccessToken is returned when an access token is malformed, expired or belongs to a revoked session.
+	ErrInvalidAccessToken = errors.New("invalid access token")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, already used, revoked or expired.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidAPIKey is returned when an API key is unknown, expired or revoked.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidAPIKeyExpiry is returned when an API key is requested with an expiry in the past.
+	ErrInvalidAPIKeyExpiry = errors.New("API key expiry must be in the future")
+	// ErrExportRateLimited is returned when a user requests data exports more often than configured.
+	ErrExportRateLimited = errors.New("data export rate limited")
+	// ErrConfirmationMismatch is returned when the account number sent to confirm an action is not the caller's.
+	ErrConfirmationMismatch = errors.New("account number confirmation does not match")
+	// ErrUserNotFound is returned when the authenticated user no longer exists.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidChallenge is returned when a proof-of-work challenge is missing, expired, already used or not solved.
+	ErrInvalidChallenge = errors.New("invalid proof-of-work challenge")
+	// ErrInvalidInvite is returned when an invite code is missing in invite-only mode, unknown, used up, expired or revoked.
+	ErrInvalidInvite = errors.New("invalid invite code")
+	// ErrInvalidInviteRequest is returned when an invite is requested with an expiry in the past or more uses than allowed.
+	ErrInvalidInviteRequest = errors.New("invalid invite request")
+)
+
+// Thresholds of the trust levels. Accounts younger than newAccountAge are new, accounts of at least
+// trustedAccountAge whose posts were liked at least trustedLikesReceived times by others are trusted.
+const (
+	newAccountAge        = 3 * 24 * time.Hour
+	trustedAccountAge    = 30 * 24 * time.Hour
+	trustedLikesReceived = 10
+)
+
 type UserService struct {
-	userRepo UserRepository
+	userRepo     UserRepository
+	lockoutGuard *lockout.Guard
+	hasher       *helper.Hasher
+	cfg          *config.Config
+	hub          *websocket.Hub
+
+	// Time of the last data export per user, used to rate-limit exports.
+	exportsMu   sync.Mutex
+	lastExports map[int]time.Time
+
+	signups *signupGuard
 }
 
-func NewUserService(userRepo UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo UserRepository, lockoutGuard *lockout.Guard, cfg *config.Config, hub *websocket.Hub) *UserService {
+	return &UserService{
+		userRepo:     userRepo,
+		lockoutGuard: lockoutGuard,
+		hasher:       helper.NewHasher(cfg.Hashing),
+		cfg:          cfg,
+		hub:          hub,
+		lastExports:  make(map[int]time.Time),
+		signups:      newSignupGuard(cfg.Signup),
+	}
 }
 
-func (s *UserService) createUser() (string, error) {
+// createUser creates a new user and returns its account number.
+// When includeRecoveryPhrase is set, the response also carries the account number spelled as a recovery phrase.
+// In invite-only mode a valid inviteCode is required; the account records which invite created it.
+func (s *UserService) createUser(ctx context.Context, includeRecoveryPhrase bool, inviteCode string) (*models.UserResponse, error) {
 	slog.Info("Generating account number for new user")
 
+	if inviteCode == "" && s.cfg.Signup.InviteOnly {
+		return nil, ErrInvalidInvite
+	}
+
 	accNumber, err := helper.GenerateAccountNumber()
 	if err != nil {
 		slog.Error("Failed to generate account number", slog.String("error", err.Error()))
-		return "", err
+		return nil, err
+	}
+
+	hashedAccNumber, err := s.hasher.Hash(accNumber)
+	if err != nil {
+		slog.Error("Failed to hash account number", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to hash account number: %w", err)
 	}
 
-	hashedAccNumber := helper.HashAccountNumber(accNumber)
+	lookupHash := helper.AccountLookupHash(s.cfg.Auth.AccountPepper, accNumber)
 	user := models.Users{
 		AccountNumber: hashedAccNumber,
+		LookupHash:    &lookupHash,
 		CreatedAt:     time.Now(),
 	}
 
-	err = s.userRepo.CreateUser(user)
+	// An invite is recorded whenever one is given, so invite trees stay complete outside invite-only mode too.
+	if inviteCode != "" {
+		rowsAffected, err := s.userRepo.CreateInvitedUser(ctx, user, helper.HashToken(helper.NormalizeInviteCode(inviteCode)))
+		if err != nil {
+			return nil, err
+		}
+		if rowsAffected == 0 {
+			return nil, ErrInvalidInvite
+		}
+	} else {
+		err = s.userRepo.CreateUser(user)
+		if err != nil {
+			slog.Error("Failed to insert user", slog.String("error", err.Error()))
+			return nil, err
+		}
+	}
+
+	s.signups.recordSignup(time.Now())
+
+	slog.Info("User created successfully")
+	return newUserResponse(accNumber, includeRecoveryPhrase)
+}
+
+// issueChallenge creates a signed proof-of-work challenge at the current signup difficulty.
+func (s *UserService) issueChallenge() (*models.SignupChallengeResponse, error) {
+	nonce, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Signup.ChallengeTTL)
+	difficulty := s.signups.difficulty(now)
+
+	challenge, err := helper.SignChallenge(s.cfg.Signup.ChallengeSecret, helper.SignupChallenge{
+		Nonce:      nonce,
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SignupChallengeResponse{
+		Challenge:      challenge,
+		Difficulty:     difficulty,
+		Algorithm:      "sha256",
+		ExpiresAt:      expiresAt,
+		InviteRequired: s.cfg.Signup.InviteOnly,
+	}, nil
+}
+
+// redeemChallenge checks that solution solves a challenge issued by issueChallenge, and uses the challenge up.
+// The difficulty signed into the challenge applies, so a solution keeps working if the difficulty rises meanwhile.
+// Nothing is checked while the challenge is disabled.
+func (s *UserService) redeemChallenge(challenge, solution string) error {
+	if s.cfg.Signup.Difficulty <= 0 {
+		return nil
+	}
+
+	parsed, err := helper.ParseChallenge(s.cfg.Signup.ChallengeSecret, challenge)
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+
+	if !helper.VerifyProofOfWork(challenge, solution, parsed.Difficulty) {
+		return ErrInvalidChallenge
+	}
+
+	if !s.signups.redeem(parsed.Nonce, time.Unix(parsed.ExpiresAt, 0), time.Now()) {
+		return ErrInvalidChallenge
+	}
+
+	return nil
+}
+
+// newUserResponse builds the response revealing an account number, optionally with its recovery phrase.
+func newUserResponse(accNumber string, includeRecoveryPhrase bool) (*models.UserResponse, error) {
+	resp := &models.UserResponse{AccountNumber: accNumber}
+	if !includeRecoveryPhrase {
+		return resp, nil
+	}
+
+	phrase, err := helper.EncodeRecoveryPhrase(accNumber)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryPhrase = phrase
+
+	return resp, nil
+}
+
+// Authenticate returns the user that owns the given credential, either an account number or its recovery phrase.
+// A recovery phrase that cannot be decoded is reported with an error wrapping helper.ErrInvalidRecoveryPhrase.
+// The user is located through the indexed lookup fingerprint, so only one hash comparison is needed.
+// Users created before fingerprints existed are matched by comparing against each of their hashes instead,
+// and their fingerprint is stored on the first successful match so later requests take the fast path.
+// Hashes made with bcrypt or outdated argon2id parameters are replaced on a successful match as well.
+func (s *UserService) Authenticate(ctx context.Context, credential string) (*models.Users, error) {
+	accountNumber, err := helper.ParseAccountCredential(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	lookupHash := helper.AccountLookupHash(s.cfg.Auth.AccountPepper, accountNumber)
+
+	user, err := s.userRepo.GetUserByLookupHash(ctx, lookupHash)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		needsRehash, err := s.hasher.Verify(user.AccountNumber, accountNumber)
+		if err != nil {
+			return nil, ErrInvalidAccountNumber
+		}
+		if needsRehash {
+			s.rehashAccountNumber(ctx, user, accountNumber)
+		}
+		return user, nil
+	}
+
+	legacyUsers, err := s.userRepo.GetUsersWithoutLookupHash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, legacyUser := range legacyUsers {
+		needsRehash, err := s.hasher.Verify(legacyUser.AccountNumber, accountNumber)
+		if err != nil {
+			continue
+		}
+		if needsRehash {
+			s.rehashAccountNumber(ctx, &legacyUser, accountNumber)
+		}
+
+		// A failed backfill only means the next login takes the slow path again.
+		if err := s.userRepo.UpdateLookupHash(ctx, legacyUser.ID, lookupHash); err != nil {
+			slog.Warn("Failed to backfill lookup hash", slog.Int("userId", legacyUser.ID), slog.String("error", err.Error()))
+		} else {
+			legacyUser.LookupHash = &lookupHash
+		}
+		return &legacyUser, nil
+	}
+
+	return nil, ErrInvalidAccountNumber
+}
+
+// rehashAccountNumber replaces the stored hash of a user with a hash made with the current parameters.
+// A failed rehash is only logged, the old hash keeps working and is replaced on a later login.
+func (s *UserService) rehashAccountNumber(ctx context.Context, user *models.Users, accountNumber string) {
+	hashedAccNumber, err := s.hasher.Hash(accountNumber)
+	if err != nil {
+		slog.Warn("Failed to rehash account number", slog.Int("userId", user.ID), slog.String("error", err.Error()))
+		return
+	}
+
+	if err := s.userRepo.UpdateAccountNumberHash(ctx, user.ID, user.AccountNumber, hashedAccNumber); err != nil {
+		slog.Warn("Failed to store rehashed account number", slog.Int("userId", user.ID), slog.String("error", err.Error()))
+		return
+	}
+
+	slog.Info("Account number rehashed", slog.Int("userId", user.ID))
+	user.AccountNumber = hashedAccNumber
+}
+
+// AuthenticateClient authenticates a credential sent by a client, throttling clients that keep guessing.
+// While the client IP is locked out, a *lockout.LockedError is returned without checking the credential.
+// A valid credential is accepted even while its account number prefix is locked out, so failing against a prefix
+// on purpose cannot lock its owners out; a wrong one against a locked prefix locks the client IP out as well.
+func (s *UserService) AuthenticateClient(ctx context.Context, credential, clientIP string) (*models.Users, error) {
+	accountNumber, err := helper.ParseAccountCredential(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lockoutGuard.Begin(ctx, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Authenticate(ctx, accountNumber)
+	if !errors.Is(err, ErrInvalidAccountNumber) {
+		// Errors other than a wrong credential say nothing about the client, so they are not held against it either.
+		if settleErr := s.lockoutGuard.Succeed(ctx, clientIP); settleErr != nil {
+			slog.Error("Failed to settle authentication attempt", slog.String("error", settleErr.Error()))
+		}
+		return user, err
+	}
+
+	var lockedErr *lockout.LockedError
+	settleErr := s.lockoutGuard.Fail(ctx, clientIP, accountNumber)
+	if errors.As(settleErr, &lockedErr) {
+		return nil, settleErr
+	}
+	if settleErr != nil {
+		slog.Error("Failed to record failed authentication", slog.String("error", settleErr.Error()))
+	}
+
+	return nil, err
+}
+
+// createSession exchanges an account number for a new session and returns its tokens.
+func (s *UserService) createSession(ctx context.Context, accountNumber, userAgent, clientIP string) (*models.SessionTokensResponse, error) {
+	user, err := s.AuthenticateClient(ctx, accountNumber, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		slog.Error("Failed to generate refresh token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	session := models.SessionDBModel{
+		UserId:           user.ID,
+		RefreshTokenHash: helper.HashToken(refreshToken),
+		UserAgent:        userAgent,
+		CreatedAt:        time.Now(),
+		ExpiresAt:        time.Now().Add(s.cfg.Auth.RefreshTokenTTL),
+	}
+	if err := s.userRepo.CreateSession(ctx, &session); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Session created", slog.Int("userId", user.ID), slog.Int("sessionId", session.ID))
+	return s.issueTokens(user.ID, session.ID, refreshToken)
+}
+
+// refreshSession exchanges a refresh token for a new access token.
+// The refresh token is rotated on every use, so each one can be exchanged only once.
+func (s *UserService) refreshSession(ctx context.Context, refreshToken string) (*models.SessionTokensResponse, error) {
+	currentHash := helper.HashToken(refreshToken)
+
+	session, err := s.userRepo.GetSessionByRefreshTokenHash(ctx, currentHash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		slog.Error("Failed to generate refresh token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	rowsAffected, err := s.userRepo.RotateRefreshToken(ctx, session.ID, currentHash, helper.HashToken(newRefreshToken), time.Now().Add(s.cfg.Auth.RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	slog.Info("Session refreshed", slog.Int("sessionId", session.ID))
+	return s.issueTokens(session.UserId, session.ID, newRefreshToken)
+}
+
+// listSessions returns the active sessions of a user, flagging the one the request was made with.
+func (s *UserService) listSessions(ctx context.Context, userId, currentSessionId int) ([]models.Session, error) {
+	sessions, err := s.userRepo.GetActiveSessions(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+
+	result := make([]models.Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, models.Session{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionId,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *UserService) revokeSession(ctx context.Context, userId, sessionId int) (int64, error) {
+	rowsAffected, err := s.userRepo.RevokeSession(ctx, sessionId, userId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("Session revoked", slog.Int("userId", userId), slog.Int("sessionId", sessionId))
+	}
+	return rowsAffected, nil
+}
+
+// AuthenticateAccessToken verifies an access token and returns its claims.
+// Besides the signature and expiry, the session it was issued for must still be active,
+// so revoking a session takes effect immediately rather than when its access tokens expire.
+func (s *UserService) AuthenticateAccessToken(ctx context.Context, token string) (*helper.AccessTokenClaims, error) {
+	claims, err := helper.ParseAccessToken(s.cfg.Auth.TokenSecret, token)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	session, err := s.userRepo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserId != claims.UserID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return claims, nil
+}
+
+// AuthenticateAPIKey looks up an API key by its hash and returns it when it is still active.
+// Revoked and expired keys are rejected with ErrInvalidAPIKey.
+func (s *UserService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKeyDBModel, error) {
+	if !strings.HasPrefix(key, models.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.userRepo.GetAPIKeyByHash(ctx, helper.HashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+// createAPIKey mints a new API key with the given scopes. The key itself is only returned here.
+func (s *UserService) createAPIKey(ctx context.Context, userId int, req models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	token, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		slog.Error("Failed to generate API key", slog.String("error", err.Error()))
+		return nil, err
+	}
+	key := models.APIKeyPrefix + token
+
+	// Scopes are deduplicated so the stored set reads the same however it was requested.
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	apiKey := models.APIKeyDBModel{
+		UserId:    userId,
+		Name:      req.Name,
+		Prefix:    key[:len(models.APIKeyPrefix)+6],
+		KeyHash:   helper.HashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.userRepo.CreateAPIKey(ctx, &apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	slog.Info("API key created", slog.Int("userId", userId), slog.Int("apiKeyId", apiKey.ID))
+	return &models.CreateAPIKeyResponse{APIKey: toAPIKey(apiKey), Key: key}, nil
+}
+
+// listAPIKeys returns the active API keys of a user.
+func (s *UserService) listAPIKeys(ctx context.Context, userId int) ([]models.APIKey, error) {
+	apiKeys, err := s.userRepo.GetActiveAPIKeys(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve API keys: %w", err)
+	}
+
+	result := make([]models.APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		result = append(result, toAPIKey(apiKey))
+	}
+
+	return result, nil
+}
+
+func (s *UserService) revokeAPIKey(ctx context.Context, userId, apiKeyId int) (int64, error) {
+	rowsAffected, err := s.userRepo.RevokeAPIKey(ctx, apiKeyId, userId)
+	if err != nil {
+		return -1, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.Info("API key revoked", slog.Int("userId", userId), slog.Int("apiKeyId", apiKeyId))
+	}
+	return rowsAffected, nil
+}
+
+func toAPIKey(apiKey models.APIKeyDBModel) models.APIKey {
+	return models.APIKey{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+		ExpiresAt: apiKey.ExpiresAt,
+	}
+}
+
+// GetUserRole returns the current role of a user. Roles are read on every request instead of being
+// embedded in access tokens, so promotions and demotions take effect immediately.
+func (s *UserService) GetUserRole(ctx context.Context, userId int) (string, error) {
+	return s.userRepo.GetUserRole(ctx, userId)
+}
+
+func (s *UserService) issueTokens(userId, sessionId int, refreshToken string) (*models.SessionTokensResponse, error) {
+	now := time.Now()
+	accessToken, err := helper.SignAccessToken(s.cfg.Auth.TokenSecret, helper.AccessTokenClaims{
+		UserID:    userId,
+		SessionID: sessionId,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.cfg.Auth.AccessTokenTTL).Unix(),
+	})
+	if err != nil {
+		slog.Error("Failed to sign access token", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &models.SessionTokensResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.cfg.Auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// rotateAccountNumber replaces the account number of a user with a newly generated one and returns it.
+// The old number stops working immediately. The new number is only ever returned here and is not stored or logged in plain text.
+func (s *UserService) rotateAccountNumber(ctx context.Context, userId, currentSessionId int, includeRecoveryPhrase bool) (*models.UserResponse, error) {
+	slog.Info("Rotating account number", slog.Int("userId", userId))
+
+	accNumber, err := helper.GenerateAccountNumber()
+	if err != nil {
+		slog.Error("Failed to generate account number", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	hashedAccNumber, err := s.hasher.Hash(accNumber)
+	if err != nil {
+		slog.Error("Failed to hash account number", slog.Int("userId", userId), slog.String("error", err.Error()))
+		return nil, fmt.Errorf("failed to hash account number: %w", err)
+	}
+
+	lookupHash := helper.AccountLookupHash(s.cfg.Auth.AccountPepper, accNumber)
+
+	if err := s.userRepo.RotateAccountNumber(ctx, userId, hashedAccNumber, lookupHash, currentSessionId); err != nil {
+		return nil, fmt.Errorf("failed to rotate account number: %w", err)
+	}
+
+	slog.Info("Account number rotated successfully", slog.Int("userId", userId))
+	return newUserResponse(accNumber, includeRecoveryPhrase)
+}
+
+// deleteAccount deletes the account of the authenticated user after checking that the re-sent account number is theirs.
+// Connected clients are told which posts and comments disappeared and which like counters changed.
+func (s *UserService) deleteAccount(ctx context.Context, userId int, req models.DeleteAccountRequest) error {
+	slog.Info("Deleting account", slog.Int("userId", userId), slog.String("mode", req.Mode))
+
+	user, err := s.Authenticate(ctx, req.AccountNumber)
+	if errors.Is(err, ErrInvalidAccountNumber) || (err == nil && user.ID != userId) {
+		return ErrConfirmationMismatch
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := s.userRepo.DeleteUser(ctx, userId, req.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	slog.Info("Account deleted successfully", slog.Int("userId", userId), slog.String("mode", req.Mode))
+
+	if len(content.Posts) > 0 || len(content.Comments) > 0 {
+		s.broadcast(models.WebSocketMessage{
+			Type:    "contentRemoved",
+			Message: "Content was removed",
+			Content: map[string]interface{}{
+				"postIds":    helper.ContentIDs(content.Posts),
+				"commentIds": helper.ContentIDs(content.Comments),
+			},
+		}, "")
+
+		// The followers of each board only hear about the content of their board
+		posts, comments := helper.GroupByBoard(content.Posts), helper.GroupByBoard(content.Comments)
+		boards := make(map[string]bool)
+		for board := range posts {
+			boards[board] = true
+		}
+		for board := range comments {
+			boards[board] = true
+		}
+		for board := range boards {
+			s.broadcastToBoard(models.WebSocketMessage{
+				Type:    "contentRemoved",
+				Message: "Content was removed",
+				Content: map[string]interface{}{
+					"board":      board,
+					"postIds":    append([]int{}, posts[board]...),
+					"commentIds": append([]int{}, comments[board]...),
+				},
+			}, board)
+		}
+	}
+
+	for _, post := range content.LikedPosts {
+		s.broadcast(models.WebSocketMessage{
+			Type:    "updatedLikes",
+			Message: "Likes Updated",
+			Content: map[string]interface{}{
+				"postId": post.ID,
+				"board":  post.Board,
+			},
+		}, post.Board)
+	}
+
+	return nil
+}
+
+// broadcast sends a message to the clients following every board and, unless board is empty,
+// to the clients following that board.
+func (s *UserService) broadcast(wsMsg models.WebSocketMessage, board string) {
+	marshalledWSMsg, err := json.Marshal(wsMsg)
+	if err != nil {
+		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()), slog.String("type", wsMsg.Type))
+		return
+	}
+	s.hub.Broadcast <- marshalledWSMsg
+	if board != "" {
+		s.hub.BoardBroadcast <- websocket.BoardMessage{Board: board, Message: marshalledWSMsg}
+	}
+}
+
+// broadcastToBoard sends a message to the clients following a board only.
+func (s *UserService) broadcastToBoard(wsMsg models.WebSocketMessage, board string) {
+	marshalledWSMsg, err := json.Marshal(wsMsg)
+	if err != nil {
+		slog.Warn("Failed to marshal websocket message", slog.String("error", err.Error()), slog.String("type", wsMsg.Type))
+		return
+	}
+	s.hub.BoardBroadcast <- websocket.BoardMessage{Board: board, Message: marshalledWSMsg}
+}
+
+// exportAccount collects the personal data of a user.
+// Only one export per user is allowed within the configured interval; when the limit is hit,
+// ErrExportRateLimited is returned together with the time left until the next export is allowed.
+func (s *UserService) exportAccount(ctx context.Context, userId int) (*models.AccountExport, time.Duration, error) {
+	slog.Info("Exporting account data", slog.Int("userId", userId))
+
+	if retryAfter := s.reserveExport(userId); retryAfter > 0 {
+		slog.Warn("Data export rate limited", slog.Int("userId", userId))
+		return nil, retryAfter, ErrExportRateLimited
+	}
+
+	export, err := s.userRepo.GetAccountExport(ctx, userId)
+	if err != nil {
+		// A failed export should not count against the limit.
+		s.exportsMu.Lock()
+		delete(s.lastExports, userId)
+		s.exportsMu.Unlock()
+		return nil, 0, fmt.Errorf("failed to export account: %w", err)
+	}
+	export.ExportedAt = time.Now()
+
+	slog.Info("Account data exported successfully", slog.Int("userId", userId))
+	return export, 0, nil
+}
+
+// reserveExport records an export for the user and returns 0, or returns the time left until the next export is allowed.
+// Entries older than the interval are pruned on the way, so the map only holds users that exported recently.
+func (s *UserService) reserveExport(userId int) time.Duration {
+	s.exportsMu.Lock()
+	defer s.exportsMu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastExports[userId]; ok {
+		if next := last.Add(s.cfg.Export.Interval); now.Before(next) {
+			return next.Sub(now)
+		}
+	}
+
+	for id, last := range s.lastExports {
+		if now.Sub(last) >= s.cfg.Export.Interval {
+			delete(s.lastExports, id)
+		}
+	}
+	s.lastExports[userId] = now
+
+	return 0
+}
+
+// getProfile returns the profile of a user, including its activity counters and trust level.
+func (s *UserService) getProfile(ctx context.Context, userId int) (*models.UserProfile, error) {
+	profile, err := s.userRepo.GetUserProfile(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve profile: %w", err)
+	}
+	if profile == nil {
+		return nil, ErrUserNotFound
+	}
+
+	age := time.Since(profile.CreatedAt)
+	profile.AccountAgeDays = int(age / (24 * time.Hour))
+
+	switch {
+	case age < newAccountAge:
+		profile.TrustLevel = models.TrustLevelNew
+	case age >= trustedAccountAge && profile.Stats.LikesReceived >= trustedLikesReceived:
+		profile.TrustLevel = models.TrustLevelTrusted
+	default:
+		profile.TrustLevel = models.TrustLevelMember
+	}
+
+	return profile, nil
+}
+
+// GetPreferences returns the preferences of a user, falling back to the defaults when none were stored yet.
+func (s *UserService) GetPreferences(ctx context.Context, userId int) (*models.UserPreferences, error) {
+	stored, err := s.userRepo.GetPreferences(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve preferences: %w", err)
+	}
+
+	preferences := models.DefaultUserPreferences()
+	if stored != nil {
+		preferences = models.UserPreferences{
+			SortBy:     stored.SortBy,
+			SortOrder:  stored.SortOrder,
+			PageSize:   stored.PageSize,
+			MutedWords: stored.MutedWords,
+			ShowNSFW:   stored.ShowNSFW,
+		}
+		if preferences.MutedWords == nil {
+			preferences.MutedWords = []string{}
+		}
+	}
+
+	return &preferences, nil
+}
+
+// updatePreferences replaces the preferences of a user. Muted words are trimmed, lowercased and deduplicated.
+func (s *UserService) updatePreferences(ctx context.Context, userId int, preferences models.UserPreferences) (*models.UserPreferences, error) {
+	mutedWords := make([]string, 0, len(preferences.MutedWords))
+	seen := make(map[string]bool, len(preferences.MutedWords))
+	for _, word := range preferences.MutedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		mutedWords = append(mutedWords, word)
+	}
+	preferences.MutedWords = mutedWords
+
+	err := s.userRepo.SavePreferences(ctx, &models.UserPreferencesDBModel{
+		UserId:     userId,
+		SortBy:     preferences.SortBy,
+		SortOrder:  preferences.SortOrder,
+		PageSize:   preferences.PageSize,
+		MutedWords: preferences.MutedWords,
+		ShowNSFW:   preferences.ShowNSFW,
+	})
 	if err != nil {
-		slog.Error("Failed to insert user", slog.String("error", err.Error()))
-		return "", err
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
 	}
 
-	slog.Info("User created successfully", slog.String("accountNumber", accNumber))
-	return accNumber, nil
+	return &preferences, nil
 }
@@ -4,16 +4,58 @@
 package user
 
 import (
+	"anon-confessions/cmd/internal/config"
 	"anon-confessions/cmd/internal/helper"
 	"anon-confessions/cmd/internal/helper/testutils"
+	"anon-confessions/cmd/internal/lockout"
 	"anon-confessions/cmd/internal/models"
+	"anon-confessions/cmd/internal/websocket"
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+var testConfig = &config.Config{
+	Auth: config.AuthConfig{
+		AccountPepper:       "test-pepper",
+		TokenSecret:         "test-secret",
+		AccessTokenTTL:      time.Minute,
+		RefreshTokenTTL:     time.Hour,
+		LegacyAccountHeader: true,
+	},
+	Export: config.ExportConfig{
+		Interval: time.Hour,
+	},
+	Hashing: config.HashingConfig{
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	},
+}
+
+// newTestGuard returns a lockout guard with the limits of testConfig, which never locks anyone out.
+func newTestGuard() *lockout.Guard {
+	return lockout.NewGuard(lockout.NewMemoryStore(), testConfig.Lockout)
+}
+
+// newTestHub starts a WebSocket hub without clients, so broadcasts from the service do not block.
+func newTestHub() *websocket.Hub {
+	hub := websocket.NewHub()
+	go hub.Run()
+	return hub
+}
+
 // TestUserIntegration validates if a user is created successfully.
 func TestUserIntegration(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -23,7 +65,7 @@ func TestUserIntegration(t *testing.T) {
 
 	// Step 2: Initialize Components
 	repo := NewSQLiteUserRepository(db)
-	service := NewUserService(repo)
+	service := NewUserService(repo, newTestGuard(), testConfig, newTestHub())
 	handler := NewUserHandler(service)
 
 	// Step 3: Setup Router
@@ -59,10 +101,912 @@ func TestUserIntegration(t *testing.T) {
 			t.Fatalf("Failed to find created user in database: %v", err)
 		}
 
-		if err := helper.CompareHashAndPassword([]byte(createdUser.AccountNumber), []byte(resp.AccountNumber)); err != nil {
+		if _, err := helper.NewHasher(testConfig.Hashing).Verify(createdUser.AccountNumber, resp.AccountNumber); err != nil {
 			t.Fatalf("Stored account number hash does not match the response account number")
 		}
 
 		t.Logf("Hashed account number stored correctly in the database")
+
+		// Ensure the lookup fingerprint was stored next to the hash
+		if createdUser.LookupHash == nil || *createdUser.LookupHash != helper.AccountLookupHash("test-pepper", resp.AccountNumber) {
+			t.Fatalf("Expected lookup hash to be stored for the created user")
+		}
+	})
+}
+
+// TestSessionsIntegration validates the session lifecycle: creation, refresh, listing and revocation.
+func TestSessionsIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	repo := NewSQLiteUserRepository(db)
+	service := NewUserService(repo, newTestGuard(), testConfig, newTestHub())
+	handler := NewUserHandler(service)
+
+	createdUser, err := service.createUser(context.Background(), false, "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	accountNumber := createdUser.AccountNumber
+	user, err := service.Authenticate(context.Background(), accountNumber)
+	if err != nil {
+		t.Fatalf("Failed to authenticate created user: %v", err)
+	}
+
+	// Mock authentication middleware for the authenticated routes
+	var currentSessionID int
+	mockAuthMiddleware := func(c *gin.Context) {
+		c.Set("userID", user.ID)
+		c.Set("sessionID", currentSessionID)
+		c.Next()
+	}
+
+	router := gin.Default()
+	RegisterUsersRoutes(router.Group("/api/v1"), handler)
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(mockAuthMiddleware)
+	RegisterAuthenticatedUsersRoutes(authenticated, handler)
+
+	// Create a session
+	body, _ := json.Marshal(models.CreateSessionRequest{AccountNumber: accountNumber})
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var tokens models.SessionTokensResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("Expected access and refresh tokens, got %+v", tokens)
+	}
+
+	claims, err := service.AuthenticateAccessToken(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected issued access token to be valid: %v", err)
+	}
+	currentSessionID = claims.SessionID
+
+	// An invalid account number must not create a session
+	body, _ = json.Marshal(models.CreateSessionRequest{AccountNumber: "0000000000000000"})
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Refresh the session; the old refresh token must stop working afterwards
+	body, _ = json.Marshal(models.RefreshSessionRequest{RefreshToken: tokens.RefreshToken})
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions/refresh", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions/refresh", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected reused refresh token to be rejected with %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// List sessions
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/sessions", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var sessions []models.Session
+	if err := json.Unmarshal(w.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("Expected exactly one current session, got %+v", sessions)
+	}
+
+	// Revoke the session; its access token must stop working immediately
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/sessions/%d", currentSessionID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if _, err := service.AuthenticateAccessToken(context.Background(), tokens.AccessToken); err != ErrInvalidAccessToken {
+		t.Fatalf("Expected access token of revoked session to be rejected, got %v", err)
+	}
+}
+
+// TestRotateAccountNumberIntegration validates that rotating replaces the account number and revokes other sessions.
+func TestRotateAccountNumberIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	repo := NewSQLiteUserRepository(db)
+	service := NewUserService(repo, newTestGuard(), testConfig, newTestHub())
+	handler := NewUserHandler(service)
+	ctx := context.Background()
+
+	createdUser, err := service.createUser(context.Background(), false, "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	oldAccountNumber := createdUser.AccountNumber
+
+	// Open two sessions; the rotation is requested from the first one
+	current, err := service.createSession(ctx, oldAccountNumber, "current", "")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	other, err := service.createSession(ctx, oldAccountNumber, "other", "")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	currentClaims, err := service.AuthenticateAccessToken(ctx, current.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected access token to be valid: %v", err)
+	}
+
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", currentClaims.UserID)
+		c.Set("sessionID", currentClaims.SessionID)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, handler)
+
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/me/rotate", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp models.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.AccountNumber == "" || resp.AccountNumber == oldAccountNumber {
+		t.Fatalf("Expected a new account number, got '%s'", resp.AccountNumber)
+	}
+
+	if _, err := service.Authenticate(ctx, oldAccountNumber); err != ErrInvalidAccountNumber {
+		t.Errorf("Expected old account number to be rejected, got %v", err)
+	}
+	if user, err := service.Authenticate(ctx, resp.AccountNumber); err != nil || user.ID != currentClaims.UserID {
+		t.Errorf("Expected new account number to authenticate the same user, got %v", err)
+	}
+
+	if _, err := service.AuthenticateAccessToken(ctx, current.AccessToken); err != nil {
+		t.Errorf("Expected the current session to survive the rotation, got %v", err)
+	}
+	if _, err := service.AuthenticateAccessToken(ctx, other.AccessToken); err != ErrInvalidAccessToken {
+		t.Errorf("Expected other sessions to be revoked, got %v", err)
+	}
+}
+
+// TestDeleteAccountIntegration validates both account deletion modes and the confirmation check.
+func TestDeleteAccountIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	repo := NewSQLiteUserRepository(db)
+	service := NewUserService(repo, newTestGuard(), testConfig, newTestHub())
+	handler := NewUserHandler(service)
+	ctx := context.Background()
+
+	var loggedInUserID int
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", loggedInUserID)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, handler)
+
+	deleteAccount := func(accountNumber, mode string) int {
+		body, _ := json.Marshal(models.DeleteAccountRequest{AccountNumber: accountNumber, Mode: mode})
+		w, req := testutils.HTTPTestRequest(http.MethodDelete, "/api/v1/users/me", body)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	createAccount := func() (string, *models.Users) {
+		createdUser, err := service.createUser(context.Background(), false, "")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user, err := service.Authenticate(ctx, createdUser.AccountNumber)
+		if err != nil {
+			t.Fatalf("Failed to authenticate created user: %v", err)
+		}
+		return createdUser.AccountNumber, user
+	}
+
+	t.Run("Anonymize keeps the content", func(t *testing.T) {
+		accountNumber, user := createAccount()
+		loggedInUserID = user.ID
+
+		post := models.PostDBModel{Content: "Anonymized post", UserId: user.ID}
+		db.Create(&post)
+
+		if code := deleteAccount(accountNumber, models.AccountDeletionAnonymize); code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+		}
+
+		var tombstone models.Users
+		if err := db.Where("lookup_hash = ?", models.TombstoneLookupHash).First(&tombstone).Error; err != nil {
+			t.Fatalf("Expected tombstone user to exist: %v", err)
+		}
+
+		var kept models.PostDBModel
+		if err := db.First(&kept, post.ID).Error; err != nil {
+			t.Fatalf("Expected post to be kept: %v", err)
+		}
+		if kept.UserId != tombstone.ID {
+			t.Errorf("Expected post to belong to the tombstone user %d, got %d", tombstone.ID, kept.UserId)
+		}
+
+		if _, err := service.Authenticate(ctx, accountNumber); err != ErrInvalidAccountNumber {
+			t.Errorf("Expected deleted account to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Delete requires the caller's account number", func(t *testing.T) {
+		_, user := createAccount()
+		otherAccountNumber, _ := createAccount()
+		loggedInUserID = user.ID
+
+		if code := deleteAccount(otherAccountNumber, models.AccountDeletionDelete); code != http.StatusForbidden {
+			t.Fatalf("Expected status code %d, got %d", http.StatusForbidden, code)
+		}
+		if code := deleteAccount("0000000000000000", models.AccountDeletionDelete); code != http.StatusForbidden {
+			t.Fatalf("Expected status code %d, got %d", http.StatusForbidden, code)
+		}
+	})
+
+	t.Run("Delete removes the account", func(t *testing.T) {
+		accountNumber, user := createAccount()
+		loggedInUserID = user.ID
+
+		if code := deleteAccount(accountNumber, models.AccountDeletionDelete); code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+		}
+
+		var count int64
+		db.Model(&models.Users{}).Where("id = ?", user.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected user to be deleted")
+		}
+	})
+
+	t.Run("Invalid mode is rejected", func(t *testing.T) {
+		accountNumber, user := createAccount()
+		loggedInUserID = user.ID
+
+		if code := deleteAccount(accountNumber, "archive"); code != http.StatusBadRequest {
+			t.Fatalf("Expected status code %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+// TestRecoveryPhraseIntegration validates that a recovery phrase is returned on request and works as a credential.
+func TestRecoveryPhraseIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), testConfig, newTestHub())
+	handler := NewUserHandler(service)
+
+	router := gin.Default()
+	RegisterUsersRoutes(router.Group("/api/v1"), handler)
+
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/register?recovery_phrase=true", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp models.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if resp.RecoveryPhrase == "" {
+		t.Fatalf("Expected a recovery phrase in the response")
+	}
+
+	byNumber, err := service.Authenticate(context.Background(), resp.AccountNumber)
+	if err != nil {
+		t.Fatalf("Failed to authenticate with account number: %v", err)
+	}
+	byPhrase, err := service.Authenticate(context.Background(), resp.RecoveryPhrase)
+	if err != nil {
+		t.Fatalf("Failed to authenticate with recovery phrase: %v", err)
+	}
+	if byNumber.ID != byPhrase.ID {
+		t.Errorf("Expected the recovery phrase to resolve to user %d, got %d", byNumber.ID, byPhrase.ID)
+	}
+
+	// A session can be created with the phrase; a mangled phrase gets a descriptive error
+	body, _ := json.Marshal(models.CreateSessionRequest{AccountNumber: resp.RecoveryPhrase})
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	body, _ = json.Marshal(models.CreateSessionRequest{AccountNumber: resp.RecoveryPhrase + " extra"})
+	w, req = testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/sessions", body)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	var errResp helper.ErrorMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if errResp.Message == "Invalid account number" {
+		t.Errorf("Expected a recovery phrase specific error, got '%s'", errResp.Message)
+	}
+}
+
+// TestAccountExportIntegration validates the content, formats and rate limit of personal data exports.
+func TestAccountExportIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), testConfig, newTestHub())
+	handler := NewUserHandler(service)
+	ctx := context.Background()
+
+	createAccount := func() *models.Users {
+		createdUser, err := service.createUser(context.Background(), false, "")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user, err := service.Authenticate(ctx, createdUser.AccountNumber)
+		if err != nil {
+			t.Fatalf("Failed to authenticate created user: %v", err)
+		}
+		return user
+	}
+	exporter, commenter, zipExporter := createAccount(), createAccount(), createAccount()
+
+	// The exporter writes a post, the commenter comments on it and writes a post the exporter likes
+	ownPost := models.PostDBModel{Content: "Exported post", UserId: exporter.ID}
+	db.Create(&ownPost)
+	otherPost := models.PostDBModel{Content: "Someone else's post", UserId: commenter.ID}
+	db.Create(&otherPost)
+	db.Create(&models.CommentsDbModel{Content: "Comment by someone else", UserId: commenter.ID, PostId: ownPost.ID})
+	db.Create(&models.CommentsDbModel{Content: "Own comment", UserId: exporter.ID, PostId: otherPost.ID})
+	likedAt := time.Now()
+	db.Create(&models.PostsLikesDBModel{PostId: otherPost.ID, UserId: exporter.ID, CreatedAt: &likedAt})
+	db.Create(&models.DraftDBModel{Content: "Unfinished draft", UserId: exporter.ID, ExpiresAt: likedAt.Add(time.Hour)})
+
+	var loggedInUserID int
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", loggedInUserID)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, handler)
+
+	t.Run("JSON export", func(t *testing.T) {
+		loggedInUserID = exporter.ID
+
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me/export", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if body := w.Body.String(); strings.Contains(body, "user_id") || strings.Contains(body, "userId") {
+			t.Fatalf("Expected export to contain no user identifiers, got %s", body)
+		}
+
+		var export models.AccountExport
+		if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(export.Posts) != 1 || len(export.Posts[0].Comments) != 1 || export.Posts[0].Comments[0].Own {
+			t.Errorf("Expected one post with one foreign comment, got %+v", export.Posts)
+		}
+		if len(export.Comments) != 1 || export.Comments[0].PostID != otherPost.ID {
+			t.Errorf("Expected one own comment on post %d, got %+v", otherPost.ID, export.Comments)
+		}
+		if len(export.Likes) != 1 || export.Likes[0].LikedAt == nil {
+			t.Errorf("Expected one timestamped like, got %+v", export.Likes)
+		}
+		if len(export.Drafts) != 1 || export.Drafts[0].Content != "Unfinished draft" {
+			t.Errorf("Expected one draft, got %+v", export.Drafts)
+		}
+	})
+
+	t.Run("Second export is rate limited", func(t *testing.T) {
+		loggedInUserID = exporter.ID
+
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me/export", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected a Retry-After header")
+		}
+	})
+
+	t.Run("ZIP export", func(t *testing.T) {
+		loggedInUserID = zipExporter.ID
+
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me/export?format=zip", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to open ZIP archive: %v", err)
+		}
+		if len(archive.File) != 1 || archive.File[0].Name != "export.json" {
+			t.Fatalf("Expected a single export.json in the archive")
+		}
+
+		f, err := archive.File[0].Open()
+		if err != nil {
+			t.Fatalf("Failed to open export.json: %v", err)
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+
+		var export models.AccountExport
+		if err := json.Unmarshal(content, &export); err != nil {
+			t.Fatalf("Failed to unmarshal export.json: %v", err)
+		}
+		if len(export.Posts) != 0 {
+			t.Errorf("Expected no posts, got %d", len(export.Posts))
+		}
+	})
+}
+
+// TestSignupChallengeIntegration validates that account creation requires a solved, unused proof-of-work challenge
+// and that the difficulty rises with the signup rate.
+func TestSignupChallengeIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := *testConfig
+	cfg.Signup = config.SignupConfig{
+		ChallengeSecret: "test-challenge-secret",
+		ChallengeTTL:    time.Minute,
+		Difficulty:      8,
+		MaxDifficulty:   10,
+		RateWindow:      time.Minute,
+		RateThreshold:   1,
+	}
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), &cfg, newTestHub())
+	router := gin.Default()
+	RegisterUsersRoutes(router.Group("/api/v1"), NewUserHandler(service))
+
+	requestChallenge := func() models.SignupChallengeResponse {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/challenge", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var challenge models.SignupChallengeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &challenge); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return challenge
+	}
+
+	solve := func(challenge models.SignupChallengeResponse) string {
+		for i := 0; ; i++ {
+			solution := fmt.Sprint(i)
+			if helper.VerifyProofOfWork(challenge.Challenge, solution, challenge.Difficulty) {
+				return solution
+			}
+		}
+	}
+
+	register := func(challenge, solution string) int {
+		url := fmt.Sprintf("/api/v1/users/register?challenge=%s&solution=%s", challenge, solution)
+		w, req := testutils.HTTPTestRequest(http.MethodPost, url, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := register("", ""); code != http.StatusForbidden {
+		t.Fatalf("Expected account creation without a challenge to fail with %d, got %d", http.StatusForbidden, code)
+	}
+
+	challenge := requestChallenge()
+	if challenge.Difficulty != 8 {
+		t.Fatalf("Expected base difficulty 8, got %d", challenge.Difficulty)
+	}
+	solution := solve(challenge)
+
+	if code := register(challenge.Challenge, ""); code != http.StatusForbidden {
+		t.Fatalf("Expected a missing solution to fail with %d, got %d", http.StatusForbidden, code)
+	}
+	if code := register(challenge.Challenge, solution); code != http.StatusOK {
+		t.Fatalf("Expected account creation with a solved challenge to succeed, got %d", code)
+	}
+	if code := register(challenge.Challenge, solution); code != http.StatusForbidden {
+		t.Fatalf("Expected a reused challenge to fail with %d, got %d", http.StatusForbidden, code)
+	}
+
+	// A second signup within the window exceeds the threshold of one, so the difficulty rises by one bit
+	challenge = requestChallenge()
+	if code := register(challenge.Challenge, solve(challenge)); code != http.StatusOK {
+		t.Fatalf("Expected account creation with a solved challenge to succeed, got %d", code)
+	}
+	if challenge = requestChallenge(); challenge.Difficulty != 9 {
+		t.Errorf("Expected difficulty 9 after the signup rate doubled, got %d", challenge.Difficulty)
+	}
+}
+
+// TestProfileIntegration validates that the profile of the authenticated user carries its activity counters
+// and trust level, without revealing the account number.
+func TestProfileIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), testConfig, newTestHub())
+	ctx := context.Background()
+
+	createAccount := func() (*models.Users, string) {
+		createdUser, err := service.createUser(context.Background(), false, "")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user, err := service.Authenticate(ctx, createdUser.AccountNumber)
+		if err != nil {
+			t.Fatalf("Failed to authenticate created user: %v", err)
+		}
+		return user, createdUser.AccountNumber
+	}
+	author, accountNumber := createAccount()
+	reader, _ := createAccount()
+
+	// The author writes two posts and a comment, likes one own post, and is liked by the reader
+	firstPost := models.PostDBModel{Content: "First post", UserId: author.ID}
+	db.Create(&firstPost)
+	db.Create(&models.PostDBModel{Content: "Second post", UserId: author.ID})
+	db.Create(&models.CommentsDbModel{Content: "Own comment", UserId: author.ID, PostId: firstPost.ID})
+	db.Create(&models.PostsLikesDBModel{PostId: firstPost.ID, UserId: author.ID})
+	db.Create(&models.PostsLikesDBModel{PostId: firstPost.ID, UserId: reader.ID})
+
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", author.ID)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, NewUserHandler(service))
+
+	w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if body := w.Body.String(); strings.Contains(body, accountNumber) || strings.Contains(body, "argon2id") {
+		t.Fatalf("Expected profile not to reveal the account number, got %s", body)
+	}
+
+	var profile models.UserProfile
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	expectedStats := models.UserStats{Posts: 2, Comments: 1, LikesGiven: 1, LikesReceived: 1}
+	if profile.Stats != expectedStats {
+		t.Errorf("Expected stats %+v, got %+v", expectedStats, profile.Stats)
+	}
+	if profile.Role != models.RoleUser || profile.TrustLevel != models.TrustLevelNew || profile.AccountAgeDays != 0 {
+		t.Errorf("Expected a new regular account, got %+v", profile)
+	}
+	if profile.CreatedAt.IsZero() {
+		t.Errorf("Expected the creation date to be set")
+	}
+}
+
+// TestPreferencesIntegration tests if preferences default, validate and persist between requests.
+func TestPreferencesIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), testConfig, newTestHub())
+
+	createdUser, err := service.createUser(context.Background(), false, "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	user, err := service.Authenticate(context.Background(), createdUser.AccountNumber)
+	if err != nil {
+		t.Fatalf("Failed to authenticate created user: %v", err)
+	}
+
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", user.ID)
+		c.Next()
 	})
+	RegisterAuthenticatedUsersRoutes(authenticated, NewUserHandler(service))
+
+	getPreferences := func() models.UserPreferences {
+		w, req := testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me/preferences", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var preferences models.UserPreferences
+		if err := json.Unmarshal(w.Body.Bytes(), &preferences); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return preferences
+	}
+
+	if preferences := getPreferences(); !reflect.DeepEqual(preferences, models.DefaultUserPreferences()) {
+		t.Fatalf("Expected default preferences, got %+v", preferences)
+	}
+
+	// Invalid preferences are rejected
+	for _, body := range []string{
+		`{"sortBy":"random","sortOrder":"asc","pageSize":10}`,
+		`{"sortBy":"likes","sortOrder":"up","pageSize":10}`,
+		`{"sortBy":"likes","sortOrder":"desc","pageSize":500}`,
+		`{"sortBy":"likes","sortOrder":"desc","pageSize":10,"mutedWords":[""]}`,
+	} {
+		w, req := testutils.HTTPTestRequest(http.MethodPut, "/api/v1/users/me/preferences", []byte(body))
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	body := `{"sortBy":"likes","sortOrder":"desc","pageSize":25,"mutedWords":[" Spoiler ","spoiler","Politics"],"showNsfw":true}`
+	w, req := testutils.HTTPTestRequest(http.MethodPut, "/api/v1/users/me/preferences", []byte(body))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	expected := models.UserPreferences{
+		SortBy:     models.PreferenceSortByLikes,
+		SortOrder:  "desc",
+		PageSize:   25,
+		MutedWords: []string{"spoiler", "politics"},
+		ShowNSFW:   true,
+	}
+	if preferences := getPreferences(); !reflect.DeepEqual(preferences, expected) {
+		t.Errorf("Expected preferences %+v, got %+v", expected, preferences)
+	}
+}
+
+// TestAPIKeysIntegration tests if API keys can be created, listed, used and revoked.
+func TestAPIKeysIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), testConfig, newTestHub())
+	ctx := context.Background()
+
+	createdUser, err := service.createUser(context.Background(), false, "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	user, err := service.Authenticate(ctx, createdUser.AccountNumber)
+	if err != nil {
+		t.Fatalf("Failed to authenticate created user: %v", err)
+	}
+
+	router := gin.Default()
+	authenticated := router.Group("/api/v1")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", user.ID)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, NewUserHandler(service))
+
+	// Unknown scopes and expiries in the past are rejected
+	for _, body := range []string{
+		`{"name":"digest","scopes":["posts:delete"]}`,
+		`{"name":"digest","scopes":[]}`,
+		`{"name":"digest","scopes":["posts:read"],"expiresAt":"2000-01-01T00:00:00Z"}`,
+	} {
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/me/api-keys", []byte(body))
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	body := `{"name":"digest","scopes":["posts:read","posts:write","posts:read"]}`
+	w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/me/api-keys", []byte(body))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var created models.CreateAPIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, models.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Fatalf("Expected a prefixed key, got %q with prefix %q", created.Key, created.Prefix)
+	}
+	if !reflect.DeepEqual(created.Scopes, []string{models.ScopePostsRead, models.ScopePostsWrite}) {
+		t.Errorf("Expected deduplicated scopes, got %v", created.Scopes)
+	}
+
+	apiKey, err := service.AuthenticateAPIKey(ctx, created.Key)
+	if err != nil || apiKey.UserId != user.ID {
+		t.Fatalf("Expected the key to authenticate the user, got %+v, %v", apiKey, err)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, "/api/v1/users/me/api-keys", nil)
+	router.ServeHTTP(w, req)
+	if body := w.Body.String(); strings.Contains(body, created.Key) {
+		t.Fatalf("Expected the key not to be listed, got %s", body)
+	}
+	var listed []models.APIKey
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Fatalf("Expected the created key to be listed, got %+v", listed)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/me/api-keys/%d", created.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if _, err := service.AuthenticateAPIKey(ctx, created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected a revoked key to be rejected, got %v", err)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/me/api-keys/%d", created.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d when revoking twice, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestInvitesIntegration tests invite-only registration, invite limits and invite tracing.
+func TestInvitesIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := *testConfig
+	cfg.Signup = config.SignupConfig{InviteOnly: true, InviteMaxUses: 2}
+
+	db := testutils.SetupMockDB()
+	service := NewUserService(NewSQLiteUserRepository(db), newTestGuard(), &cfg, newTestHub())
+	handler := NewUserHandler(service)
+	ctx := context.Background()
+
+	// The first account of an invite-only server is created directly, like an operator would
+	founder := models.Users{AccountNumber: "invite-test-founder"}
+	if err := db.Create(&founder).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	currentUser := founder.ID
+	router := gin.Default()
+	api := router.Group("/api/v1")
+	RegisterUsersRoutes(api, handler)
+	authenticated := api.Group("/")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("userID", currentUser)
+		c.Next()
+	})
+	RegisterAuthenticatedUsersRoutes(authenticated, handler)
+	RegisterAdminUsersRoutes(authenticated.Group("/admin"), handler)
+
+	register := func(invite string) (int, int) {
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/register?invite="+invite, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, 0
+		}
+		var created models.UserResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		user, err := service.Authenticate(ctx, created.AccountNumber)
+		if err != nil {
+			t.Fatalf("Failed to authenticate created user: %v", err)
+		}
+		return w.Code, user.ID
+	}
+
+	createInvite := func(body string) (int, models.CreateInviteResponse) {
+		w, req := testutils.HTTPTestRequest(http.MethodPost, "/api/v1/users/me/invites", []byte(body))
+		router.ServeHTTP(w, req)
+		var invite models.CreateInviteResponse
+		if w.Code == http.StatusCreated {
+			if err := json.Unmarshal(w.Body.Bytes(), &invite); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w.Code, invite
+	}
+
+	if code, _ := register(""); code != http.StatusForbidden {
+		t.Fatalf("Expected status code %d without invite, got %d", http.StatusForbidden, code)
+	}
+	if code, _ := createInvite(`{"maxUses":3}`); code != http.StatusBadRequest {
+		t.Fatalf("Expected status code %d above the usage cap, got %d", http.StatusBadRequest, code)
+	}
+
+	code, invite := createInvite(`{"maxUses":2}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, code)
+	}
+
+	// Codes match however they are typed, until they are used up
+	code, firstId := register(strings.ToLower(strings.ReplaceAll(invite.Code, "-", "")))
+	if code != http.StatusOK {
+		t.Fatalf("Expected status code %d with invite, got %d", http.StatusOK, code)
+	}
+	if code, _ := register(invite.Code); code != http.StatusOK {
+		t.Fatalf("Expected status code %d with invite, got %d", http.StatusOK, code)
+	}
+	if code, _ := register(invite.Code); code != http.StatusForbidden {
+		t.Fatalf("Expected status code %d with a used up invite, got %d", http.StatusForbidden, code)
+	}
+
+	// The invited account invites another one, then revokes its invite
+	currentUser = firstId
+	_, childInvite := createInvite(`{"maxUses":2}`)
+	code, grandchildId := register(childInvite.Code)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status code %d with invite, got %d", http.StatusOK, code)
+	}
+	w, req := testutils.HTTPTestRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/me/invites/%d", childInvite.ID), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if code, _ := register(childInvite.Code); code != http.StatusForbidden {
+		t.Fatalf("Expected status code %d with a revoked invite, got %d", http.StatusForbidden, code)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/admin/users/%d/invites", firstId), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var trace models.InviteTrace
+	if err := json.Unmarshal(w.Body.Bytes(), &trace); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(trace.InvitedBy) != 1 || trace.InvitedBy[0].UserID != founder.ID {
+		t.Errorf("Expected the founder to have invited the account, got %+v", trace.InvitedBy)
+	}
+	if trace.Account.InviteID == nil || *trace.Account.InviteID != invite.ID {
+		t.Errorf("Expected the account to record invite %d, got %v", invite.ID, trace.Account.InviteID)
+	}
+	if len(trace.Account.Invited) != 1 || trace.Account.Invited[0].UserID != grandchildId {
+		t.Errorf("Expected the account to have invited user %d, got %+v", grandchildId, trace.Account.Invited)
+	}
+
+	w, req = testutils.HTTPTestRequest(http.MethodGet, fmt.Sprintf("/api/v1/admin/users/%d/invites", founder.ID), nil)
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &trace); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(trace.InvitedBy) != 0 || len(trace.Account.Invited) != 2 || len(trace.Account.Invited[0].Invited) != 1 {
+		t.Errorf("Expected the founder to root a tree of three accounts, got %+v", trace)
+	}
 }
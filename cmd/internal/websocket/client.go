@@ -15,6 +15,9 @@ type Client struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// Slug of the board the client follows, empty when it follows every board.
+	board string
 }
 
 func (c *Client) writePump() {
@@ -2,13 +2,24 @@ package websocket
 
 import "sync"
 
+// BoardMessage is a message for the clients following a single board.
+type BoardMessage struct {
+	Board   string
+	Message []byte
+}
+
 type Hub struct {
 	// Registered Clients.
 	Clients map[*Client]bool
 
 	// Inbound messages from the clients.
+	// They reach the clients that follow every board, clients following a single board only get its BoardBroadcast messages.
+	// Events about a single board are sent to both.
 	Broadcast chan []byte
 
+	// Messages for the clients following a board.
+	BoardBroadcast chan BoardMessage
+
 	// Register requests from the clients.
 	Register chan *Client
 
@@ -21,10 +32,11 @@ type Hub struct {
 
 func NewHub() *Hub {
 	return &Hub{
-		Broadcast:  make(chan []byte),
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
-		Clients:    make(map[*Client]bool),
+		Broadcast:      make(chan []byte),
+		BoardBroadcast: make(chan BoardMessage),
+		Register:       make(chan *Client),
+		Unregister:     make(chan *Client),
+		Clients:        make(map[*Client]bool),
 	}
 }
 
@@ -47,16 +59,33 @@ func (h *Hub) Run() {
 		case message := <-h.Broadcast:
 			h.mu.Lock()
 			for client := range h.Clients {
-				select {
-				case client.send <- message:
+				if client.board == "" {
+					h.send(client, message)
+				}
+			}
+			h.mu.Unlock()
 
-				default:
-					// Remove Clients
-					close(client.send)
-					delete(h.Clients, client)
+		case message := <-h.BoardBroadcast:
+			h.mu.Lock()
+			for client := range h.Clients {
+				if client.board == message.Board {
+					h.send(client, message.Message)
 				}
 			}
 			h.mu.Unlock()
 		}
 	}
 }
+
+// send queues a message for a client, dropping the client when its buffer is full.
+// The caller must hold the lock.
+func (h *Hub) send(client *Client, message []byte) {
+	select {
+	case client.send <- message:
+
+	default:
+		// Remove Clients
+		close(client.send)
+		delete(h.Clients, client)
+	}
+}
@@ -0,0 +1,46 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHubBoardBroadcast(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	everything := &Client{hub: hub, send: make(chan []byte, 4)}
+	work := &Client{hub: hub, send: make(chan []byte, 4), board: "work"}
+	campus := &Client{hub: hub, send: make(chan []byte, 4), board: "campus-x"}
+	for _, client := range []*Client{everything, work, campus} {
+		hub.Register <- client
+	}
+
+	hub.Broadcast <- []byte("global")
+	hub.BoardBroadcast <- BoardMessage{Board: "work", Message: []byte("work")}
+
+	expectMessages := func(name string, client *Client, expected ...string) {
+		for _, message := range expected {
+			select {
+			case got := <-client.send:
+				if string(got) != message {
+					t.Errorf("Expected %s to receive %q, got %q", name, message, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Expected %s to receive %q", name, message)
+			}
+		}
+		select {
+		case got := <-client.send:
+			t.Errorf("Expected no more messages for %s, got %q", name, got)
+		default:
+		}
+	}
+
+	// The hub handles one message at a time, so this one is only through once the others are delivered
+	hub.Broadcast <- []byte("sync")
+
+	expectMessages("the global client", everything, "global", "sync")
+	expectMessages("the work client", work, "work")
+	expectMessages("the campus client", campus)
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/websocket"
 )
@@ -16,6 +17,8 @@ var upgrader = websocket.Upgrader{
 }
 
 // serveWs handles websocket requests.
+// Clients connecting with ?board=<slug> only receive the events of that board. Slugs are matched case-insensitively,
+// like when fetching a board.
 func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	slog.Info("Upgrading HTTP connection to WebSocket")
 
@@ -26,10 +29,10 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	}
 	slog.Info("WebSocket connection upgraded successfully")
 
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), board: strings.ToLower(strings.TrimSpace(r.URL.Query().Get("board")))}
 	client.hub.Register <- client
 
-	slog.Info("Client registered with hub")
+	slog.Info("Client registered with hub", slog.String("board", client.board))
 
 	// Start the write pump in a new goroutine.
 	go client.writePump()
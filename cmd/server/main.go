@@ -4,6 +4,7 @@ import (
 	"anon-confessions/cmd/internal/app"
 	"anon-confessions/cmd/internal/config"
 	"log/slog"
+	"os"
 )
 
 func main() {
@@ -12,12 +13,16 @@ func main() {
 
 	slog.Info("Loading configuration...")
 	cfg := config.LoadConfig()
+	if err := cfg.CheckSecrets(); err != nil {
+		slog.Error("Invalid configuration", slog.String("error", err.Error()))
+		return
+	}
 
 	// Initialize the application
 	app, err := app.NewApp(cfg)
 	if err != nil {
 		slog.Error("Could not initialize the application", slog.String("error", err.Error()))
-		return
+		os.Exit(1)
 	}
 
 	// Run the application